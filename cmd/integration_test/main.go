@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"image"
+	"image/png"
 	"io"
 	"mime/multipart"
 	"net/http"
@@ -163,8 +165,9 @@ func uploadMedia(token, activityID string) {
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 
-	part, _ := writer.CreateFormFile("file", "test.txt")
-	part.Write([]byte("dummy content"))
+	// Uploads are checked by content, so send a real image
+	part, _ := writer.CreateFormFile("file", "test.png")
+	png.Encode(part, image.NewGray(image.Rect(0, 0, 1, 1)))
 
 	writer.WriteField("activity_id", activityID)
 	writer.Close()
@@ -0,0 +1,65 @@
+// Command rates imports exchange rates into the exchange_rates table.
+//
+// Usage:
+//
+//	go run ./cmd/rates -file eurofxref-hist.xml
+//	go run ./cmd/rates -file eurofxref-hist.csv -base EUR
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/NoahFola/travel_app_backend/internal/currency"
+	"github.com/NoahFola/travel_app_backend/internal/database"
+	"github.com/NoahFola/travel_app_backend/internal/repository"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	file := flag.String("file", "", "path to an ECB XML or CSV rates file")
+	base := flag.String("base", currency.DefaultBase, "base currency of a CSV file")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("Unable to open %s: %v", *file, err)
+	}
+	defer f.Close()
+
+	var rates []currency.Rate
+	switch strings.ToLower(filepath.Ext(*file)) {
+	case ".xml":
+		rates, err = currency.ParseECBXML(f)
+	case ".csv":
+		rates, err = currency.ParseCSV(f, strings.ToUpper(*base))
+	default:
+		log.Fatalf("Unsupported file type %q, expected .xml or .csv", filepath.Ext(*file))
+	}
+	if err != nil {
+		log.Fatalf("Unable to parse %s: %v", *file, err)
+	}
+
+	dbPool := database.InitDB()
+	defer dbPool.Close()
+
+	repo := repository.NewRateRepository(dbPool)
+	if err := repo.Upsert(context.Background(), rates); err != nil {
+		log.Fatalf("Unable to store rates: %v", err)
+	}
+
+	log.Printf("Imported %d rates from %s", len(rates), *file)
+}
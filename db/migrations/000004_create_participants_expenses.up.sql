@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS trip_participants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    trip_id UUID NOT NULL REFERENCES trips(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL, -- NULL for people without an account
+    display_name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (trip_id, user_id)
+);
+
+-- Every existing trip owner is a participant of their own trip
+INSERT INTO trip_participants (trip_id, user_id, display_name)
+SELECT t.id, t.user_id, COALESCE(u.full_name, u.email, 'Owner')
+FROM trips t
+JOIN users u ON u.id = t.user_id
+ON CONFLICT (trip_id, user_id) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS expenses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    trip_id UUID NOT NULL REFERENCES trips(id) ON DELETE CASCADE,
+    activity_id UUID REFERENCES activities(id) ON DELETE SET NULL,
+    paid_by UUID NOT NULL REFERENCES trip_participants(id) ON DELETE RESTRICT, -- removing them would change everyone's balance
+    kind VARCHAR(20) NOT NULL DEFAULT 'expense', -- expense, settlement
+    description TEXT NOT NULL,
+    amount_minor BIGINT NOT NULL CHECK (amount_minor > 0), -- in the currency's minor unit (cents)
+    currency CHAR(3) NOT NULL,
+    split_method VARCHAR(20) NOT NULL DEFAULT 'equal', -- equal, shares, exact
+    incurred_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS expense_splits (
+    expense_id UUID NOT NULL REFERENCES expenses(id) ON DELETE CASCADE,
+    participant_id UUID NOT NULL REFERENCES trip_participants(id) ON DELETE RESTRICT,
+    shares INTEGER, -- only set for split_method = 'shares'
+    amount_minor BIGINT NOT NULL,
+    PRIMARY KEY (expense_id, participant_id)
+);
+
+CREATE INDEX idx_trip_participants_trip_id ON trip_participants(trip_id);
+CREATE INDEX idx_expenses_trip_id ON expenses(trip_id);
+CREATE INDEX idx_expenses_activity_id ON expenses(activity_id);
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS checklists (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    trip_id UUID NOT NULL REFERENCES trips(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    kind VARCHAR(50) NOT NULL DEFAULT 'packing', -- packing, pre_departure, documents, other
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS checklist_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    checklist_id UUID NOT NULL REFERENCES checklists(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    quantity INTEGER NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    assignee_id UUID REFERENCES trip_participants(id) ON DELETE SET NULL,
+    due_date DATE,
+    done BOOLEAN NOT NULL DEFAULT FALSE,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_checklists_trip_id ON checklists(trip_id);
+CREATE INDEX idx_checklist_items_checklist_id ON checklist_items(checklist_id, position);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS journal_entry_media;
+DROP TABLE IF EXISTS journal_entries;
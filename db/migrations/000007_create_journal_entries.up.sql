@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS journal_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    trip_id UUID NOT NULL REFERENCES trips(id) ON DELETE CASCADE,
+    itinerary_id UUID REFERENCES itineraries(id) ON DELETE SET NULL, -- optional day
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    title VARCHAR(255),
+    body TEXT NOT NULL DEFAULT '', -- markdown
+    mood VARCHAR(50),
+    weather VARCHAR(50),
+    location_id UUID REFERENCES locations(id) ON DELETE SET NULL,
+    entry_time TIMESTAMPTZ NOT NULL DEFAULT now(), -- when it happened, used for ordering
+    shared BOOLEAN NOT NULL DEFAULT FALSE, -- include in share previews
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS journal_entry_media (
+    entry_id UUID NOT NULL REFERENCES journal_entries(id) ON DELETE CASCADE,
+    media_id UUID NOT NULL REFERENCES media(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (entry_id, media_id)
+);
+
+CREATE INDEX idx_journal_entries_trip_id ON journal_entries(trip_id, entry_time);
+CREATE INDEX idx_journal_entries_itinerary_id ON journal_entries(itinerary_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_activities_itinerary_position;
+ALTER TABLE activities DROP COLUMN IF EXISTS position;
@@ -0,0 +1,12 @@
+ALTER TABLE activities ADD COLUMN IF NOT EXISTS position DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+-- Keep the current time-based order, leaving gaps so single moves don't renumber
+UPDATE activities a
+SET position = ordered.rn * 1024
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY itinerary_id ORDER BY start_time ASC NULLS LAST, created_at ASC) AS rn
+    FROM activities
+) ordered
+WHERE ordered.id = a.id;
+
+CREATE INDEX idx_activities_itinerary_position ON activities(itinerary_id, position);
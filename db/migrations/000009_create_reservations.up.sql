@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS reservations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    activity_id UUID NOT NULL REFERENCES activities(id) ON DELETE CASCADE,
+    trip_id UUID NOT NULL REFERENCES trips(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL, -- flight, hotel, food, car, train, other
+    provider VARCHAR(255), -- airline, hotel chain, restaurant, rental company
+    confirmation_number VARCHAR(100),
+    starts_at TIMESTAMPTZ, -- departure, check-in, pick-up, table time
+    ends_at TIMESTAMPTZ, -- arrival, check-out, drop-off
+    cost_minor BIGINT, -- in the currency's minor unit
+    currency CHAR(3),
+    notes TEXT,
+    details JSONB NOT NULL DEFAULT '{}', -- type-specific fields
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((cost_minor IS NULL) = (currency IS NULL))
+);
+
+CREATE TABLE IF NOT EXISTS reservation_attachments (
+    reservation_id UUID NOT NULL REFERENCES reservations(id) ON DELETE CASCADE,
+    media_id UUID NOT NULL REFERENCES media(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (reservation_id, media_id)
+);
+
+CREATE INDEX idx_reservations_activity_id ON reservations(activity_id);
+CREATE INDEX idx_reservations_trip_id ON reservations(trip_id, starts_at);
@@ -0,0 +1,24 @@
+-- Each user forwards confirmation emails to <token>@<inbound domain>
+CREATE TABLE IF NOT EXISTS inbound_addresses (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS inbox_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, assigned, dismissed
+    subject TEXT,
+    sender TEXT,
+    booking JSONB NOT NULL,
+    fingerprint TEXT NOT NULL, -- kind, confirmation number and start, to ignore repeated forwards
+    trip_id UUID REFERENCES trips(id) ON DELETE SET NULL,
+    activity_id UUID REFERENCES activities(id) ON DELETE SET NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (user_id, fingerprint)
+);
+
+CREATE INDEX idx_inbox_items_user_id ON inbox_items(user_id, status, received_at);
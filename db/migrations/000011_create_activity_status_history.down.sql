@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS activity_status_history;
+
+ALTER TABLE activities DROP CONSTRAINT IF EXISTS activities_status_check;
+ALTER TABLE activities ALTER COLUMN status DROP NOT NULL;
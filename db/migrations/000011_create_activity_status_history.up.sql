@@ -0,0 +1,24 @@
+-- Statuses outside the state machine fall back to planned
+UPDATE activities
+SET status = 'planned'
+WHERE status IS NULL
+   OR status NOT IN ('idea', 'planned', 'booked', 'confirmed', 'completed', 'skipped', 'cancelled');
+
+ALTER TABLE activities ALTER COLUMN status SET NOT NULL;
+ALTER TABLE activities ADD CONSTRAINT activities_status_check
+    CHECK (status IN ('idea', 'planned', 'booked', 'confirmed', 'completed', 'skipped', 'cancelled'));
+
+CREATE TABLE IF NOT EXISTS activity_status_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    activity_id UUID NOT NULL REFERENCES activities(id) ON DELETE CASCADE,
+    from_status VARCHAR(50), -- NULL when the activity was created
+    to_status VARCHAR(50) NOT NULL,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_activity_status_history_activity_id ON activity_status_history(activity_id, changed_at);
+
+-- Existing activities start their history at their current status
+INSERT INTO activity_status_history (activity_id, from_status, to_status, changed_at)
+SELECT id, NULL, status, created_at FROM activities;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS activity_occurrence_overrides;
+
+ALTER TABLE activities DROP CONSTRAINT IF EXISTS activities_span_or_recurrence_check;
+ALTER TABLE activities DROP COLUMN IF EXISTS recurrence;
+ALTER TABLE activities DROP COLUMN IF EXISTS end_date;
@@ -0,0 +1,21 @@
+-- Multi-day activities cover their itinerary's date through end_date;
+-- recurring ones repeat per an RRULE subset from their itinerary's date
+ALTER TABLE activities ADD COLUMN end_date DATE;
+ALTER TABLE activities ADD COLUMN recurrence TEXT;
+ALTER TABLE activities ADD CONSTRAINT activities_span_or_recurrence_check
+    CHECK (end_date IS NULL OR recurrence IS NULL);
+
+-- Per-occurrence changes; a cancelled row is an exception that hides that day
+CREATE TABLE IF NOT EXISTS activity_occurrence_overrides (
+    activity_id UUID NOT NULL REFERENCES activities(id) ON DELETE CASCADE,
+    occurrence_date DATE NOT NULL,
+    cancelled BOOLEAN NOT NULL DEFAULT false,
+    name VARCHAR(255),
+    description TEXT,
+    location VARCHAR(255),
+    start_time TIMESTAMPTZ,
+    end_time TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (activity_id, occurrence_date)
+);
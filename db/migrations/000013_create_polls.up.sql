@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS polls (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    trip_id UUID NOT NULL REFERENCES trips(id) ON DELETE CASCADE,
+    created_by UUID REFERENCES trip_participants(id) ON DELETE SET NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    mode VARCHAR(20) NOT NULL DEFAULT 'updown' CHECK (mode IN ('updown', 'rank')),
+    closes_at TIMESTAMPTZ, -- scheduled closing
+    closed_at TIMESTAMPTZ, -- closed early, or when the winner was promoted
+    promoted_activity_id UUID REFERENCES activities(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_polls_trip_id ON polls(trip_id);
+
+-- Each option is an activity, usually an idea from the trip's backlog
+CREATE TABLE IF NOT EXISTS poll_options (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    activity_id UUID NOT NULL REFERENCES activities(id) ON DELETE CASCADE,
+    proposed_by UUID REFERENCES trip_participants(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (poll_id, activity_id)
+);
+
+-- A participant's ballot: +1/-1 per option in updown polls, the rank
+-- (1 = favourite) in rank polls
+CREATE TABLE IF NOT EXISTS poll_votes (
+    poll_id UUID NOT NULL REFERENCES polls(id) ON DELETE CASCADE,
+    option_id UUID NOT NULL REFERENCES poll_options(id) ON DELETE CASCADE,
+    participant_id UUID NOT NULL REFERENCES trip_participants(id) ON DELETE CASCADE,
+    value INT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (option_id, participant_id)
+);
+
+CREATE INDEX idx_poll_votes_poll_id ON poll_votes(poll_id);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS place_search_cache;
@@ -0,0 +1,11 @@
+-- Place search results by normalized query, locale and bias. Each result
+-- carries the ID of the location it was saved as.
+CREATE TABLE IF NOT EXISTS place_search_cache (
+    key TEXT PRIMARY KEY,
+    query TEXT NOT NULL,
+    results JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_place_search_cache_expires_at ON place_search_cache(expires_at);
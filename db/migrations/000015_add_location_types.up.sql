@@ -0,0 +1,6 @@
+-- Place types from the provider (restaurant, museum, ...) so known
+-- locations can be filtered like a provider search
+ALTER TABLE locations ADD COLUMN IF NOT EXISTS types TEXT[] NOT NULL DEFAULT '{}';
+
+-- Bounding-box prefilter for nearby lookups
+CREATE INDEX IF NOT EXISTS idx_locations_lat_lng ON locations(latitude, longitude);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS saved_places;
+DROP TABLE IF EXISTS place_collections;
@@ -0,0 +1,3 @@
+-- IANA timezone resolved from the coordinates when the location is saved.
+-- Locations saved before this column existed are resolved when read.
+ALTER TABLE locations ADD COLUMN IF NOT EXISTS timezone TEXT;
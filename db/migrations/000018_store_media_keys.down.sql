@@ -0,0 +1 @@
+UPDATE media SET url = '/uploads/' || url WHERE url NOT LIKE '/%' AND url NOT LIKE '%://%';
@@ -0,0 +1,2 @@
+-- media.url now holds the blob store key; download URLs come from the store
+UPDATE media SET url = substr(url, length('/uploads/') + 1) WHERE url LIKE '/uploads/%';
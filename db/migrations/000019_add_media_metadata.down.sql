@@ -0,0 +1,5 @@
+ALTER TABLE media
+    DROP COLUMN IF EXISTS sha256,
+    DROP COLUMN IF EXISTS mime_type,
+    DROP COLUMN IF EXISTS size_bytes,
+    DROP COLUMN IF EXISTS original_name;
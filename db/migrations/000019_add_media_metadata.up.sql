@@ -0,0 +1,7 @@
+-- What was uploaded, checked and recorded by the server. Rows from before
+-- upload validation have NULLs.
+ALTER TABLE media
+    ADD COLUMN IF NOT EXISTS original_name TEXT,
+    ADD COLUMN IF NOT EXISTS size_bytes BIGINT,
+    ADD COLUMN IF NOT EXISTS mime_type TEXT,
+    ADD COLUMN IF NOT EXISTS sha256 TEXT;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_media_variants_pending;
+
+ALTER TABLE media
+    DROP COLUMN IF EXISTS variants_updated_at,
+    DROP COLUMN IF EXISTS variants_status,
+    DROP COLUMN IF EXISTS variants;
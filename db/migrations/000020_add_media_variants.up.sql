@@ -0,0 +1,15 @@
+-- Downsized copies of images, by longest side in pixels, e.g.
+-- {"256": "media/<name>_256.jpg"}, made by a background worker.
+-- variants_status is pending, processing, done or failed; NULL for files
+-- that get no variants.
+ALTER TABLE media
+    ADD COLUMN IF NOT EXISTS variants JSONB NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS variants_status TEXT,
+    ADD COLUMN IF NOT EXISTS variants_updated_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_media_variants_pending ON media (created_at)
+    WHERE variants_status IN ('pending', 'processing');
+
+-- Images uploaded so far get their variants too
+UPDATE media SET variants_status = 'pending'
+WHERE type = 'image' AND (mime_type IS NULL OR mime_type IN ('image/jpeg', 'image/png', 'image/gif', 'image/webp'));
@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS idx_media_trip_unassigned;
+
+-- Media without an activity can't be kept without trip_id
+DELETE FROM media WHERE activity_id IS NULL;
+
+ALTER TABLE media
+    DROP CONSTRAINT IF EXISTS media_activity_id_fkey,
+    ADD CONSTRAINT media_activity_id_fkey FOREIGN KEY (activity_id) REFERENCES activities(id) ON DELETE CASCADE;
+
+ALTER TABLE media
+    DROP COLUMN IF EXISTS longitude,
+    DROP COLUMN IF EXISTS latitude,
+    DROP COLUMN IF EXISTS taken_local,
+    DROP COLUMN IF EXISTS taken_at,
+    DROP COLUMN IF EXISTS itinerary_id,
+    DROP COLUMN IF EXISTS trip_id;
@@ -0,0 +1,26 @@
+-- Photos can be uploaded to a trip before they are placed on an activity,
+-- or only on a day when no activity matches.
+ALTER TABLE media
+    ADD COLUMN IF NOT EXISTS trip_id UUID REFERENCES trips(id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS itinerary_id UUID REFERENCES itineraries(id) ON DELETE SET NULL;
+
+UPDATE media m SET trip_id = a.trip_id
+FROM activities a
+WHERE a.id = m.activity_id AND m.trip_id IS NULL;
+
+-- Deleting an activity sends its photos back to the trip's unassigned ones
+-- instead of deleting them
+ALTER TABLE media
+    DROP CONSTRAINT IF EXISTS media_activity_id_fkey,
+    ADD CONSTRAINT media_activity_id_fkey FOREIGN KEY (activity_id) REFERENCES activities(id) ON DELETE SET NULL;
+
+-- From the EXIF data. taken_local is the camera's wall clock; taken_at is
+-- only set when the UTC offset is known, from the EXIF data or the GPS
+-- position.
+ALTER TABLE media
+    ADD COLUMN IF NOT EXISTS taken_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS taken_local TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+
+CREATE INDEX IF NOT EXISTS idx_media_trip_unassigned ON media (trip_id) WHERE activity_id IS NULL;
@@ -1,10 +1,19 @@
 package api
 
 import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
 	"github.com/NoahFola/travel_app_backend/internal/handlers"
 	"github.com/NoahFola/travel_app_backend/internal/middleware"
+	"github.com/NoahFola/travel_app_backend/internal/places"
 	"github.com/NoahFola/travel_app_backend/internal/repository"
 	"github.com/NoahFola/travel_app_backend/internal/service"
+	"github.com/NoahFola/travel_app_backend/internal/storage"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -35,14 +44,43 @@ func NewRouter(db *pgxpool.Pool) *gin.Engine {
 	locationRepo := repository.NewLocationRepository(db)
 	mediaRepo := repository.NewMediaRepository(db)
 	deviceRepo := repository.NewDeviceRepository(db)
+	participantRepo := repository.NewParticipantRepository(db)
+	expenseRepo := repository.NewExpenseRepository(db)
+	rateRepo := repository.NewRateRepository(db)
+	checklistRepo := repository.NewChecklistRepository(db)
+	journalRepo := repository.NewJournalRepository(db)
+	reservationRepo := repository.NewReservationRepository(db)
+	inboxRepo := repository.NewInboxRepository(db)
+	pollRepo := repository.NewPollRepository(db)
+	collectionRepo := repository.NewCollectionRepository(db)
+	statsRepo := repository.NewStatsRepository(db)
+
+	mediaURLs := mediaURLSigner()
+	blobs := blobStore(mediaURLs)
 
 	// --- 2. Initialize Services ---
 	authService := &service.AuthService{Repo: userRepo}
-	tripService := &service.TripService{Repo: tripRepo, ShareRepo: repository.NewShareRepository(db)}
+	tripService := &service.TripService{Repo: tripRepo, ShareRepo: repository.NewShareRepository(db), ParticipantRepo: participantRepo, JournalRepo: journalRepo, Blobs: blobs}
 	itineraryService := &service.ItineraryService{Repo: itineraryRepo, TripRepo: tripRepo}
-	activityService := &service.ActivityService{Repo: activityRepo, ItineraryRepo: itineraryRepo}
-	locationService := &service.LocationService{Repo: locationRepo}
-	mediaService := &service.MediaService{Repo: mediaRepo}
+	conflictService := &service.ConflictService{ActivityRepo: activityRepo, ItineraryRepo: itineraryRepo, TripRepo: tripRepo, SpeedKmh: travelSpeedKmh()}
+	agendaService := &service.AgendaService{ActivityRepo: activityRepo, ItineraryRepo: itineraryRepo, TripRepo: tripRepo}
+	routeService := &service.RouteService{ActivityRepo: activityRepo, ItineraryRepo: itineraryRepo, LocationRepo: locationRepo}
+	locationService := &service.LocationService{Repo: locationRepo, Places: placesProvider(), Cache: repository.NewPlaceCacheRepository(db), CacheTTL: placeCacheTTL()}
+	activityService := &service.ActivityService{Repo: activityRepo, ItineraryRepo: itineraryRepo, TripRepo: tripRepo, Conflicts: conflictService, Locations: locationService}
+	// Image variants are made in the background
+	mediaVariants := service.NewMediaVariantWorker(mediaRepo, blobs)
+	go mediaVariants.Run(context.Background())
+	mediaService := &service.MediaService{Repo: mediaRepo, ParticipantRepo: participantRepo, ActivityRepo: activityRepo, ItineraryRepo: itineraryRepo, Blobs: blobs, URLs: mediaURLs, Variants: mediaVariants}
+	participantService := &service.ParticipantService{Repo: participantRepo, TripRepo: tripRepo}
+	currencyService := &service.CurrencyService{Repo: rateRepo}
+	checklistService := &service.ChecklistService{Repo: checklistRepo, TripRepo: tripRepo, ParticipantRepo: participantRepo}
+	journalService := &service.JournalService{Repo: journalRepo, TripRepo: tripRepo, ItineraryRepo: itineraryRepo, LocationRepo: locationRepo, MediaRepo: mediaRepo, ParticipantRepo: participantRepo, Blobs: blobs}
+	reservationService := &service.ReservationService{Repo: reservationRepo, ActivityRepo: activityRepo, TripRepo: tripRepo, MediaRepo: mediaRepo, ParticipantRepo: participantRepo, Blobs: blobs}
+	inboundService := &service.InboundService{Repo: inboxRepo, TripRepo: tripRepo, ItineraryRepo: itineraryRepo, ParticipantRepo: participantRepo, Reservations: reservationService, Domain: os.Getenv("INBOUND_EMAIL_DOMAIN")}
+	pollService := &service.PollService{Repo: pollRepo, TripRepo: tripRepo, ParticipantRepo: participantRepo, ActivityRepo: activityRepo, Activities: activityService}
+	collectionService := &service.CollectionService{Repo: collectionRepo, Locations: locationService, Activities: activityService, ParticipantRepo: participantRepo}
+	statsService := &service.StatsService{Repo: statsRepo, TripRepo: tripRepo, Currency: currencyService}
+	expenseService := &service.ExpenseService{Repo: expenseRepo, ParticipantRepo: participantRepo, TripRepo: tripRepo, ActivityRepo: activityRepo, Currency: currencyService}
 
 	// --- 3. Initialize Handlers ---
 	authHandler := &handlers.AuthHandler{Service: authService}
@@ -53,9 +91,22 @@ func NewRouter(db *pgxpool.Pool) *gin.Engine {
 	mediaHandler := &handlers.MediaHandler{Service: mediaService}
 	userHandler := &handlers.UserHandler{DeviceRepo: deviceRepo}
 	healthHandler := &handlers.HealthHandler{DB: db}
-
-	// Serve static files
-	r.Static("/uploads", "./uploads")
+	participantHandler := &handlers.ParticipantHandler{Service: participantService}
+	expenseHandler := &handlers.ExpenseHandler{Service: expenseService}
+	currencyHandler := &handlers.CurrencyHandler{Service: currencyService}
+	checklistHandler := &handlers.ChecklistHandler{Service: checklistService}
+	journalHandler := &handlers.JournalHandler{Service: journalService}
+	reservationHandler := &handlers.ReservationHandler{Service: reservationService}
+	inboundHandler := &handlers.InboundHandler{Service: inboundService, WebhookSecret: os.Getenv("INBOUND_EMAIL_SECRET")}
+	if inboundHandler.WebhookSecret == "" {
+		log.Println("Warning: INBOUND_EMAIL_SECRET not set, the inbound email webhook is disabled")
+	}
+	pollHandler := &handlers.PollHandler{Service: pollService}
+	collectionHandler := &handlers.CollectionHandler{Service: collectionService}
+	statsHandler := &handlers.StatsHandler{Service: statsService}
+	conflictHandler := &handlers.ConflictHandler{Service: conflictService}
+	routeHandler := &handlers.RouteHandler{Service: routeService}
+	agendaHandler := &handlers.AgendaHandler{Service: agendaService}
 
 	// Health Check
 	r.GET("/health", healthHandler.HealthCheck)
@@ -80,6 +131,9 @@ func NewRouter(db *pgxpool.Pool) *gin.Engine {
 		users.Use(middleware.AuthMiddleware())
 		{
 			users.POST("/device-token", userHandler.RegisterDevice)
+			users.GET("/me/stats", statsHandler.MyStats)
+			users.GET("/me/inbound-address", inboundHandler.GetAddress)
+			users.POST("/me/inbound-address/rotate", inboundHandler.RotateAddress)
 		}
 
 		// Trips Routes
@@ -105,11 +159,75 @@ func NewRouter(db *pgxpool.Pool) *gin.Engine {
 					itineraries.POST("", itineraryHandler.CreateItinerary)
 					itineraries.GET("", itineraryHandler.ListItineraries)
 				}
+
+				// Activities across all days, and the backlog of unscheduled ones
+				trip.POST("/activities", activityHandler.CreateTripActivity)
+				trip.GET("/activities", activityHandler.ListTripActivities)
+
+				// Participants
+				trip.POST("/participants", participantHandler.AddParticipant)
+				trip.GET("/participants", participantHandler.ListParticipants)
+				trip.DELETE("/participants/:participantId", participantHandler.RemoveParticipant)
+
+				// Group votes on proposed activities
+				trip.POST("/polls", pollHandler.CreatePoll)
+				trip.GET("/polls", pollHandler.ListPolls)
+
+				// Shared expenses
+				trip.POST("/expenses", expenseHandler.CreateExpense)
+				trip.GET("/expenses", expenseHandler.ListExpenses)
+				trip.GET("/expenses/:expenseId", expenseHandler.GetExpense)
+				trip.DELETE("/expenses/:expenseId", expenseHandler.DeleteExpense)
+				trip.GET("/balances", expenseHandler.GetBalances)
+				trip.POST("/settlements", expenseHandler.CreateSettlement)
+
+				// Checklists
+				trip.POST("/checklists", checklistHandler.CreateChecklist)
+				trip.GET("/checklists", checklistHandler.ListChecklists)
+				trip.POST("/checklists/apply-template", checklistHandler.ApplyTemplate)
+
+				// Day-by-day agenda with multi-day and recurring activities expanded
+				trip.GET("/agenda", agendaHandler.TripAgenda)
+
+				// Schedule conflicts across all days
+				trip.GET("/conflicts", conflictHandler.TripConflicts)
+
+				// Statistics
+				trip.GET("/stats", statsHandler.TripStats)
+
+				// Journal
+				trip.POST("/journal", journalHandler.CreateEntry)
+				trip.GET("/journal", journalHandler.ListEntries)
+
+				// Reservations across all activities
+				trip.GET("/reservations", reservationHandler.ListTripReservations)
+
+				// Photos uploaded in bulk and placed from their EXIF data
+				trip.POST("/media", mediaHandler.BulkUpload)
+				trip.GET("/media", mediaHandler.ListTripMedia)
+				trip.POST("/media/auto-assign", mediaHandler.AutoAssign)
 			}
 		}
 
 		// Public Routes for Preview
 		v1.GET("/preview/:token", tripHandler.GetSharedTrip)
+		v1.GET("/preview/collections/:token", collectionHandler.GetSharedCollection)
+
+		// Signed, expiring links handed out in media URLs
+		v1.GET("/files/*key", mediaHandler.ServeSigned)
+
+		// Inbound email webhook, authenticated by INBOUND_EMAIL_SECRET
+		v1.POST("/inbound/email", inboundHandler.ReceiveEmail)
+
+		// Inbox of bookings parsed from forwarded emails
+		inbox := v1.Group("/inbox")
+		inbox.Use(middleware.AuthMiddleware())
+		{
+			inbox.GET("", inboundHandler.ListInbox)
+			inbox.POST("/upload", inboundHandler.UploadEmail)
+			inbox.POST("/:id/assign", inboundHandler.AssignItem)
+			inbox.DELETE("/:id", inboundHandler.DismissItem)
+		}
 
 		// Itineraries Routes (Direct access or strictly nested? User asked for /itineraries/{id}/activities)
 		itineraries := v1.Group("/itineraries/:id")
@@ -122,15 +240,103 @@ func NewRouter(db *pgxpool.Pool) *gin.Engine {
 			// Nested Activities
 			itineraries.POST("/activities", activityHandler.CreateActivity)
 			itineraries.GET("/activities", activityHandler.ListActivities)
+			itineraries.POST("/activities/reorder", activityHandler.ReorderActivities)
+			itineraries.GET("/agenda", agendaHandler.DayAgenda)
+			itineraries.GET("/conflicts", conflictHandler.ItineraryConflicts)
+			itineraries.POST("/optimize", routeHandler.OptimizeItinerary)
 		}
 
 		// Activities Routes
+		v1.GET("/activity-statuses", middleware.AuthMiddleware(), activityHandler.ListStatuses)
+
 		activities := v1.Group("/activities")
 		activities.Use(middleware.AuthMiddleware())
 		{
 			activities.GET("/:id", activityHandler.GetActivity)
 			activities.PUT("/:id", activityHandler.UpdateActivity)
 			activities.DELETE("/:id", activityHandler.DeleteActivity)
+			activities.POST("/:id/move", activityHandler.MoveActivity)
+
+			// Per-day changes to multi-day and recurring activities
+			activities.PUT("/:id/occurrences/:date", agendaHandler.OverrideOccurrence)
+			activities.DELETE("/:id/occurrences/:date", agendaHandler.DeleteOverride)
+
+			// Bookings
+			activities.POST("/:id/reservations", reservationHandler.CreateReservation)
+			activities.GET("/:id/reservations", reservationHandler.ListActivityReservations)
+		}
+
+		// Reservation Routes
+		reservations := v1.Group("/reservations")
+		reservations.Use(middleware.AuthMiddleware())
+		{
+			reservations.GET("/:id", reservationHandler.GetReservation)
+			reservations.PUT("/:id", reservationHandler.UpdateReservation)
+			reservations.DELETE("/:id", reservationHandler.DeleteReservation)
+		}
+
+		// Poll Routes
+		polls := v1.Group("/polls/:id")
+		polls.Use(middleware.AuthMiddleware())
+		{
+			polls.GET("", pollHandler.GetPoll)
+			polls.PUT("", pollHandler.UpdatePoll)
+			polls.DELETE("", pollHandler.DeletePoll)
+			polls.POST("/close", pollHandler.ClosePoll)
+			polls.POST("/options", pollHandler.ProposeOption)
+			polls.DELETE("/options/:optionId", pollHandler.RemoveOption)
+			polls.PUT("/vote", pollHandler.Vote)
+			polls.DELETE("/vote", pollHandler.WithdrawVote)
+			polls.POST("/promote", pollHandler.PromoteOption)
+		}
+
+		// Saved place collections
+		collections := v1.Group("/collections")
+		collections.Use(middleware.AuthMiddleware())
+		{
+			collections.POST("", collectionHandler.CreateCollection)
+			collections.GET("", collectionHandler.ListCollections)
+			collections.GET("/:id", collectionHandler.GetCollection)
+			collections.PUT("/:id", collectionHandler.UpdateCollection)
+			collections.DELETE("/:id", collectionHandler.DeleteCollection)
+			collections.POST("/:id/share", collectionHandler.ShareCollection)
+			collections.DELETE("/:id/share", collectionHandler.UnshareCollection)
+			collections.POST("/:id/places", collectionHandler.SavePlace)
+			collections.PUT("/:id/places/:placeId", collectionHandler.UpdateSavedPlace)
+			collections.DELETE("/:id/places/:placeId", collectionHandler.RemovePlace)
+			collections.POST("/:id/places/:placeId/activity", collectionHandler.ScheduleSavedPlace)
+		}
+
+		// Checklist Routes
+		v1.GET("/checklist-templates", middleware.AuthMiddleware(), checklistHandler.ListTemplates)
+
+		checklists := v1.Group("/checklists/:id")
+		checklists.Use(middleware.AuthMiddleware())
+		{
+			checklists.GET("", checklistHandler.GetChecklist)
+			checklists.PUT("", checklistHandler.UpdateChecklist)
+			checklists.DELETE("", checklistHandler.DeleteChecklist)
+
+			checklists.POST("/items", checklistHandler.AddItem)
+			checklists.POST("/items/reorder", checklistHandler.ReorderItems)
+			checklists.PUT("/items/:itemId", checklistHandler.UpdateItem)
+			checklists.DELETE("/items/:itemId", checklistHandler.DeleteItem)
+		}
+
+		// Journal Routes
+		journal := v1.Group("/journal")
+		journal.Use(middleware.AuthMiddleware())
+		{
+			journal.GET("/:id", journalHandler.GetEntry)
+			journal.PUT("/:id", journalHandler.UpdateEntry)
+			journal.DELETE("/:id", journalHandler.DeleteEntry)
+		}
+
+		// Currency Routes
+		currencies := v1.Group("/currency")
+		currencies.Use(middleware.AuthMiddleware())
+		{
+			currencies.GET("/convert", currencyHandler.Convert)
 		}
 
 		// Location Routes
@@ -138,6 +344,10 @@ func NewRouter(db *pgxpool.Pool) *gin.Engine {
 		locations.Use(middleware.AuthMiddleware())
 		{
 			locations.GET("/search", locationHandler.Search)
+			locations.GET("/nearby", locationHandler.Nearby)
+			locations.GET("/autocomplete", locationHandler.Autocomplete)
+			locations.GET("/reverse-geocode", locationHandler.ReverseGeocode)
+			locations.GET("/places/:placeId", locationHandler.PlaceDetails)
 		}
 
 		// Media Routes
@@ -145,8 +355,97 @@ func NewRouter(db *pgxpool.Pool) *gin.Engine {
 		media.Use(middleware.AuthMiddleware())
 		{
 			media.POST("/upload", mediaHandler.Upload)
+			media.GET("/:id/file", mediaHandler.Download)
 		}
 	}
 
 	return r
 }
+
+// placesProvider picks the place search backend from PLACES_PROVIDER:
+// "google" (default) or "fake", which answers from PLACES_FIXTURES or the
+// built-in fixtures without network access
+func placesProvider() service.PlacesProvider {
+	if os.Getenv("PLACES_PROVIDER") == "fake" {
+		path := os.Getenv("PLACES_FIXTURES")
+		if path == "" {
+			return places.NewFake()
+		}
+		fake, err := places.LoadFake(path)
+		if err != nil {
+			log.Fatalf("loading PLACES_FIXTURES: %v", err)
+		}
+		return fake
+	}
+
+	google := places.NewGoogle(os.Getenv("GOOGLE_MAPS_API_KEY"))
+	if base := os.Getenv("GOOGLE_MAPS_BASE_URL"); base != "" {
+		google.BaseURL = base
+	}
+	return google
+}
+
+// travelSpeedKmh reads TRAVEL_SPEED_KMH, the speed assumed between activities
+func travelSpeedKmh() float64 {
+	speed, err := strconv.ParseFloat(os.Getenv("TRAVEL_SPEED_KMH"), 64)
+	if err != nil || speed <= 0 {
+		return service.DefaultTravelSpeedKmh
+	}
+	return speed
+}
+
+// placeCacheTTL reads PLACES_CACHE_TTL, how long search results are reused,
+// as a Go duration such as "12h"
+func placeCacheTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("PLACES_CACHE_TTL"))
+	if err != nil || ttl <= 0 {
+		return service.DefaultPlaceCacheTTL
+	}
+	return ttl
+}
+
+// mediaURLSigner signs links to /api/v1/files with MEDIA_URL_SECRET, valid
+// for MEDIA_URL_EXPIRY
+func mediaURLSigner() *storage.URLSigner {
+	secret := os.Getenv("MEDIA_URL_SECRET")
+	if secret == "" {
+		log.Fatalf("MEDIA_URL_SECRET not set")
+	}
+	expiry, _ := time.ParseDuration(os.Getenv("MEDIA_URL_EXPIRY"))
+	return storage.NewURLSigner(secret, "/api/v1/files", expiry)
+}
+
+// blobStore picks where uploads are kept from STORAGE_BACKEND: "local"
+// (default) writes under STORAGE_DIR and hands out links signed by urls;
+// "s3" uses an S3-compatible bucket configured by the S3_* variables
+func blobStore(urls *storage.URLSigner) storage.BlobStore {
+	if os.Getenv("STORAGE_BACKEND") != "s3" {
+		dir := os.Getenv("STORAGE_DIR")
+		if dir == "" {
+			dir = "./uploads"
+		}
+		return storage.NewLocal(dir, urls)
+	}
+
+	region := os.Getenv("S3_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+	endpoint := os.Getenv("S3_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	if os.Getenv("S3_BUCKET") == "" {
+		log.Fatalf("STORAGE_BACKEND=s3 needs S3_BUCKET")
+	}
+	return &storage.S3{
+		Endpoint:  endpoint,
+		Bucket:    os.Getenv("S3_BUCKET"),
+		Region:    region,
+		AccessKey: os.Getenv("S3_ACCESS_KEY_ID"),
+		SecretKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		PathStyle: os.Getenv("S3_PATH_STYLE") == "true",
+		URLExpiry: urls.Expiry,
+		Client:    &http.Client{Timeout: 5 * time.Minute},
+	}
+}
@@ -0,0 +1,69 @@
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/money"
+)
+
+// DefaultBase is the base currency of the ECB reference rates
+const DefaultBase = "EUR"
+
+// DateLayout is how rate dates are written in files and query params
+const DateLayout = "2006-01-02"
+
+// Rate says that on Date, 1 unit of Base buys Value units of Quote
+type Rate struct {
+	Date  time.Time
+	Base  string
+	Quote string
+	Value *big.Rat
+}
+
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// ParseRate parses a decimal rate such as "1.0934" exactly
+func ParseRate(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || r.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid rate %q", s)
+	}
+	return r, nil
+}
+
+// CrossRate returns how many units of `to` one unit of `from` buys, given
+// both currencies' rates against the same base.
+func CrossRate(fromRate, toRate *big.Rat) *big.Rat {
+	return new(big.Rat).Quo(toRate, fromRate)
+}
+
+// Convert applies rate to an amount in from's minor units and returns the
+// amount in to's minor units, rounding half away from zero.
+func Convert(amountMinor int64, from, to string, rate *big.Rat) int64 {
+	v := new(big.Rat).SetInt64(amountMinor)
+	v.Mul(v, rate)
+	v.Mul(v, pow10(money.MinorUnits(to)))
+	v.Quo(v, pow10(money.MinorUnits(from)))
+	return roundHalfAway(v)
+}
+
+func pow10(n int) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil))
+}
+
+func roundHalfAway(v *big.Rat) int64 {
+	num := new(big.Int).Abs(v.Num())
+	den := v.Denom()
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Mul(rem, big.NewInt(2)).Cmp(den) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if v.Sign() < 0 {
+		q.Neg(q)
+	}
+	return q.Int64()
+}
@@ -0,0 +1,132 @@
+package currency
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func rat(t *testing.T, s string) *big.Rat {
+	t.Helper()
+	r, err := ParseRate(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestParseRate(t *testing.T) {
+	if r := rat(t, " 1.0934 "); r.Cmp(big.NewRat(10934, 10000)) != 0 {
+		t.Errorf("got %s, want 1.0934 exactly", r.RatString())
+	}
+	for _, s := range []string{"", "abc", "0", "-1.2"} {
+		if _, err := ParseRate(s); err == nil {
+			t.Errorf("ParseRate(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestCrossRate(t *testing.T) {
+	// Against EUR: 1 EUR = 1.10 USD = 160 JPY = 0.85 GBP
+	usd, jpy, gbp := rat(t, "1.10"), rat(t, "160"), rat(t, "0.85")
+
+	tests := []struct {
+		name     string
+		from, to *big.Rat
+		want     *big.Rat
+	}{
+		{"USD to JPY", usd, jpy, big.NewRat(1600, 11)},
+		{"JPY to USD", jpy, usd, big.NewRat(11, 1600)},
+		{"GBP to USD", gbp, usd, big.NewRat(22, 17)},
+		{"same currency", usd, usd, big.NewRat(1, 1)},
+	}
+	for _, tt := range tests {
+		if got := CrossRate(tt.from, tt.to); got.Cmp(tt.want) != 0 {
+			t.Errorf("%s: got %s, want %s", tt.name, got.RatString(), tt.want.RatString())
+		}
+	}
+
+	// Going there and back again is exact
+	if back := new(big.Rat).Mul(CrossRate(usd, jpy), CrossRate(jpy, usd)); back.Cmp(big.NewRat(1, 1)) != 0 {
+		t.Errorf("USD to JPY and back gives %s, want 1", back.RatString())
+	}
+}
+
+func TestConvert(t *testing.T) {
+	usd, jpy, kwd := rat(t, "1.10"), rat(t, "160"), rat(t, "0.33")
+
+	tests := []struct {
+		name     string
+		amount   int64
+		from, to string
+		rate     *big.Rat
+		want     int64
+	}{
+		{"EUR cents to USD cents", 1000, "EUR", "USD", usd, 1100},
+		{"USD cents to whole yen", 1000, "USD", "JPY", CrossRate(usd, jpy), 1455}, // 1454.54...
+		{"whole yen to USD cents", 1600, "JPY", "USD", CrossRate(jpy, usd), 1100},
+		{"EUR cents to KWD fils", 1000, "EUR", "KWD", kwd, 3300},
+		{"half a cent rounds up", 5, "EUR", "USD", big.NewRat(1, 2), 3},       // 2.5
+		{"negative half rounds down", -5, "EUR", "USD", big.NewRat(1, 2), -3}, // -2.5
+		{"below half rounds down", 4, "EUR", "USD", big.NewRat(3, 5), 2},      // 2.4
+	}
+	for _, tt := range tests {
+		if got := Convert(tt.amount, tt.from, tt.to, tt.rate); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseECBXML(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<Cube>
+		<Cube time="2024-01-05">
+			<Cube currency="USD" rate="1.0921"/>
+			<Cube currency="jpy" rate="158.15"/>
+		</Cube>
+		<Cube time="2024-01-04">
+			<Cube currency="USD" rate="1.0953"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+	rates, err := ParseECBXML(strings.NewReader(xml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 3 {
+		t.Fatalf("got %d rates, want 3", len(rates))
+	}
+	r := rates[1]
+	if r.Base != "EUR" || r.Quote != "JPY" || r.Value.Cmp(big.NewRat(15815, 100)) != 0 || !r.Date.Equal(time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("got %+v", r)
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	csv := "Date, USD, JPY, XYZ1, GBP\n" +
+		"2024-01-05, 1.0921, 158.15, 9, N/A\n" +
+		"2024-01-04, 1.0953, , 9, 0.86\n"
+	rates, err := ParseCSV(strings.NewReader(csv), "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range rates {
+		got = append(got, r.Date.Format(DateLayout)+" "+r.Base+"/"+r.Quote+" "+r.Value.FloatString(4))
+	}
+	want := []string{
+		"2024-01-05 EUR/USD 1.0921",
+		"2024-01-05 EUR/JPY 158.1500",
+		"2024-01-04 EUR/USD 1.0953",
+		"2024-01-04 EUR/GBP 0.8600",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if _, err := ParseCSV(strings.NewReader("Day,USD\n2024-01-05,1.09\n"), "EUR"); err == nil {
+		t.Error("accepted a CSV without a Date column")
+	}
+}
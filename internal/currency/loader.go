@@ -0,0 +1,97 @@
+package currency
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/money"
+)
+
+// ecbEnvelope matches the ECB eurofxref XML files (daily, 90-day and history):
+//
+//	<Cube><Cube time="2024-01-05"><Cube currency="USD" rate="1.0921"/>...</Cube></Cube>
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// ParseECBXML reads an ECB-style XML file. All rates are against EUR.
+func ParseECBXML(r io.Reader) ([]Rate, error) {
+	var env ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&env); err != nil {
+		return nil, err
+	}
+
+	var rates []Rate
+	for _, day := range env.Days {
+		date, err := time.Parse(DateLayout, day.Time)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q", day.Time)
+		}
+		for _, c := range day.Rates {
+			value, err := ParseRate(c.Rate)
+			if err != nil {
+				return nil, err
+			}
+			rates = append(rates, Rate{Date: date, Base: DefaultBase, Quote: strings.ToUpper(c.Currency), Value: value})
+		}
+	}
+	return rates, nil
+}
+
+// ParseCSV reads rates in the ECB historical CSV layout: a "Date" column
+// followed by one column per currency, each row holding one day's rates
+// against base. Empty and "N/A" cells are skipped.
+func ParseCSV(r io.Reader, base string) ([]Rate, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	if len(header) < 2 || !strings.EqualFold(strings.TrimSpace(header[0]), "date") {
+		return nil, fmt.Errorf("csv must start with a Date column")
+	}
+	for i := range header {
+		header[i] = strings.ToUpper(strings.TrimSpace(header[i]))
+	}
+
+	var rates []Rate
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		date, err := time.Parse(DateLayout, strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q", record[0])
+		}
+		for i := 1; i < len(record) && i < len(header); i++ {
+			cell := strings.TrimSpace(record[i])
+			if cell == "" || cell == "N/A" || !money.ValidCurrency(header[i]) {
+				continue
+			}
+			value, err := ParseRate(cell)
+			if err != nil {
+				return nil, err
+			}
+			rates = append(rates, Rate{Date: date, Base: base, Quote: header[i], Value: value})
+		}
+	}
+	return rates, nil
+}
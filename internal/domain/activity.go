@@ -4,17 +4,115 @@ import (
 	"time"
 )
 
+// Activity statuses. Allowed transitions are enforced by the service layer.
+const (
+	ActivityIdea      = "idea"
+	ActivityPlanned   = "planned"
+	ActivityBooked    = "booked"
+	ActivityConfirmed = "confirmed"
+	ActivityCompleted = "completed"
+	ActivitySkipped   = "skipped"
+	ActivityCancelled = "cancelled"
+)
+
 type Activity struct {
 	ID          string     `json:"id"`
 	TripID      string     `json:"trip_id"`
 	ItineraryID *string    `json:"itinerary_id"`
 	Name        string     `json:"name"`
 	Description *string    `json:"description"`
-	Location    *string    `json:"location"`
-	StartTime   *time.Time `json:"start_time"`
-	EndTime     *time.Time `json:"end_time"`
+	Location    *string    `json:"location"` // free-text, kept for activities without a place
+	LocationID  *string    `json:"location_id"`
+	Place       *Location  `json:"place"`      // resolved from location_id
+	StartTime   *time.Time `json:"start_time"` // UTC
+	EndTime     *time.Time `json:"end_time"`   // UTC
+	EndDate     *time.Time `json:"end_date"`   // last day a multi-day activity covers
+	Recurrence  *string    `json:"recurrence"` // RRULE subset, e.g. FREQ=DAILY;COUNT=5
 	Type        *string    `json:"type"`
 	Status      string     `json:"status"`
+	Position    float64    `json:"position"` // order within the itinerary
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Start and end in the place's timezone, filled by Localize
+	Timezone       string  `json:"timezone,omitempty"`
+	LocalStartTime *string `json:"local_start_time,omitempty"`
+	LocalEndTime   *string `json:"local_end_time,omitempty"`
+
+	StatusHistory []ActivityStatusChange `json:"status_history,omitempty"` // only on single-activity responses
+	Overrides     []ActivityOverride     `json:"overrides,omitempty"`      // only on single-activity responses
+}
+
+// LocalTimeLayout formats local times: the wall-clock time with the zone's
+// offset, e.g. 2024-05-01T09:00:00+09:00
+const LocalTimeLayout = time.RFC3339
+
+// Localize returns start and end times in UTC and, when the place's
+// timezone is known, also as local times in that zone
+func (a *Activity) Localize() {
+	a.Timezone, a.LocalStartTime, a.LocalEndTime = "", nil, nil
+	a.StartTime = inUTC(a.StartTime)
+	a.EndTime = inUTC(a.EndTime)
+
+	if a.Place == nil || a.Place.Timezone == "" {
+		return
+	}
+	zone, err := time.LoadLocation(a.Place.Timezone)
+	if err != nil {
+		return
+	}
+	a.Timezone = a.Place.Timezone
+	a.LocalStartTime = formatLocal(a.StartTime, zone)
+	a.LocalEndTime = formatLocal(a.EndTime, zone)
+}
+
+func inUTC(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+func formatLocal(t *time.Time, zone *time.Location) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.In(zone).Format(LocalTimeLayout)
+	return &s
+}
+
+// ActivityStatusChange records one status transition and who made it
+type ActivityStatusChange struct {
+	ID         string    `json:"id"`
+	ActivityID string    `json:"activity_id"`
+	FromStatus *string   `json:"from_status"` // nil for the initial status
+	ToStatus   string    `json:"to_status"`
+	ActorID    *string   `json:"actor_id"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+// ActivityOverride changes one occurrence of a multi-day or recurring
+// activity. A cancelled override is an exception: that day is skipped.
+type ActivityOverride struct {
+	ActivityID  string     `json:"activity_id"`
+	Date        time.Time  `json:"date"`
+	Cancelled   bool       `json:"cancelled"`
+	Name        *string    `json:"name"`
+	Description *string    `json:"description"`
+	Location    *string    `json:"location"`
+	StartTime   *time.Time `json:"start_time"` // UTC
+	EndTime     *time.Time `json:"end_time"`   // UTC
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ActivityOccurrence is an activity as it appears on one day, with any
+// override for that day applied
+type ActivityOccurrence struct {
+	Activity
+	Date       time.Time `json:"date"`
+	Occurrence int       `json:"occurrence"`  // 1-based, e.g. day 2 of a hotel stay
+	Total      int       `json:"occurrences"` // days covered, or repetitions within the trip
+	Overridden bool      `json:"overridden"`
 }
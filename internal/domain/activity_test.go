@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocalize(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	end := start.Add(90 * time.Minute)
+
+	tests := []struct {
+		name      string
+		place     *Location
+		wantZone  string
+		wantStart *string
+	}{
+		{"no place", nil, "", nil},
+		{"place without a timezone", &Location{}, "", nil},
+		{"unknown timezone", &Location{Timezone: "Mars/Olympus_Mons"}, "", nil},
+		{"Tokyo", &Location{Timezone: "Asia/Tokyo"}, "Asia/Tokyo", ptr("2024-05-01T07:00:00+09:00")},
+	}
+	for _, tt := range tests {
+		a := Activity{Place: tt.place, StartTime: &start, EndTime: &end, Timezone: "stale"}
+		a.Localize()
+		if a.StartTime.Location() != time.UTC || !a.StartTime.Equal(start) || !a.EndTime.Equal(end) {
+			t.Errorf("%s: got %v to %v, want the same instants in UTC", tt.name, a.StartTime, a.EndTime)
+		}
+		if a.Timezone != tt.wantZone {
+			t.Errorf("%s: got timezone %q, want %q", tt.name, a.Timezone, tt.wantZone)
+		}
+		if (a.LocalStartTime == nil) != (tt.wantStart == nil) || a.LocalStartTime != nil && *a.LocalStartTime != *tt.wantStart {
+			t.Errorf("%s: got local start %v, want %v", tt.name, a.LocalStartTime, tt.wantStart)
+		}
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
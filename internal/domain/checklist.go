@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"time"
+)
+
+const (
+	ChecklistPacking      = "packing"
+	ChecklistPreDeparture = "pre_departure"
+	ChecklistDocuments    = "documents"
+	ChecklistOther        = "other"
+)
+
+type Checklist struct {
+	ID        string          `json:"id"`
+	TripID    string          `json:"trip_id"`
+	Title     string          `json:"title"`
+	Kind      string          `json:"kind"`
+	Items     []ChecklistItem `json:"items"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+type ChecklistItem struct {
+	ID          string     `json:"id"`
+	ChecklistID string     `json:"checklist_id"`
+	Title       string     `json:"title"`
+	Quantity    int        `json:"quantity"`
+	AssigneeID  *string    `json:"assignee_id"` // trip participant
+	DueDate     *time.Time `json:"due_date"`
+	Done        bool       `json:"done"`
+	Position    int        `json:"position"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// PlaceCollection is a user's list of bookmarked places
+type PlaceCollection struct {
+	ID          string       `json:"id"`
+	UserID      string       `json:"user_id"`
+	Name        string       `json:"name"`
+	Description *string      `json:"description"`
+	ShareToken  *string      `json:"share_token,omitempty"`
+	PlaceCount  int          `json:"place_count"`
+	Places      []SavedPlace `json:"places,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// SavedPlace is a location bookmarked in a collection
+type SavedPlace struct {
+	ID           string    `json:"id"`
+	CollectionID string    `json:"collection_id"`
+	LocationID   string    `json:"location_id"`
+	Place        *Location `json:"place"`
+	Note         *string   `json:"note"`
+	Tags         []string  `json:"tags"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"time"
+)
+
+const (
+	ExpenseKindExpense    = "expense"
+	ExpenseKindSettlement = "settlement"
+
+	SplitEqual  = "equal"
+	SplitShares = "shares"
+	SplitExact  = "exact"
+)
+
+// Expense is a shared cost paid by one participant and split among others.
+// Amounts are kept in the currency's minor unit (see internal/money).
+type Expense struct {
+	ID          string         `json:"id"`
+	TripID      string         `json:"trip_id"`
+	ActivityID  *string        `json:"activity_id"`
+	PaidBy      string         `json:"paid_by"`
+	Kind        string         `json:"kind"`
+	Description string         `json:"description"`
+	AmountMinor int64          `json:"amount_minor"`
+	Amount      string         `json:"amount"`
+	Currency    string         `json:"currency"`
+	SplitMethod string         `json:"split_method"`
+	IncurredAt  time.Time      `json:"incurred_at"`
+	Splits      []ExpenseSplit `json:"splits"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+type ExpenseSplit struct {
+	ParticipantID string `json:"participant_id"`
+	Shares        *int   `json:"shares,omitempty"`
+	AmountMinor   int64  `json:"amount_minor"`
+	Amount        string `json:"amount"`
+}
+
+// Balance is a participant's net position in one currency.
+// Positive means they are owed money, negative means they owe.
+type Balance struct {
+	ParticipantID string `json:"participant_id"`
+	Currency      string `json:"currency"`
+	NetMinor      int64  `json:"net_minor"`
+	Net           string `json:"net"`
+}
+
+// Transfer is a suggested payment that settles balances
+type Transfer struct {
+	From        string `json:"from_participant_id"`
+	To          string `json:"to_participant_id"`
+	Currency    string `json:"currency"`
+	AmountMinor int64  `json:"amount_minor"`
+	Amount      string `json:"amount"`
+}
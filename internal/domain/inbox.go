@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"time"
+)
+
+const (
+	InboxPending   = "pending"
+	InboxAssigned  = "assigned"
+	InboxDismissed = "dismissed"
+)
+
+// ParsedBooking is a reservation read from a forwarded confirmation email.
+// Kind is the reservation type it becomes (flight, hotel or car).
+type ParsedBooking struct {
+	Kind               string             `json:"kind"`
+	Name               string             `json:"name"` // suggested activity name
+	Provider           string             `json:"provider,omitempty"`
+	ConfirmationNumber string             `json:"confirmation_number,omitempty"`
+	StartsAt           *time.Time         `json:"starts_at,omitempty"`
+	EndsAt             *time.Time         `json:"ends_at,omitempty"`
+	AllDay             bool               `json:"all_day,omitempty"` // StartsAt had no time of day
+	Location           string             `json:"location,omitempty"`
+	Cost               string             `json:"cost,omitempty"` // decimal string
+	Currency           string             `json:"currency,omitempty"`
+	Details            ReservationDetails `json:"details"`
+}
+
+// InboxItem is a parsed booking waiting to be placed on a trip, or the
+// record of one that was placed automatically
+type InboxItem struct {
+	ID         string        `json:"id"`
+	UserID     string        `json:"user_id"`
+	Status     string        `json:"status"`
+	Subject    *string       `json:"subject"`
+	Sender     *string       `json:"sender"`
+	Booking    ParsedBooking `json:"booking"`
+	TripID     *string       `json:"trip_id"`
+	ActivityID *string       `json:"activity_id"`
+	ReceivedAt time.Time     `json:"received_at"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+}
+
+// InboundAddress is the address a user forwards confirmation emails to
+type InboundAddress struct {
+	UserID    string    `json:"user_id"`
+	Token     string    `json:"-"`
+	Address   string    `json:"address"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"time"
+)
+
+// JournalEntry is a travel diary entry written for a trip or one of its days
+type JournalEntry struct {
+	ID          string         `json:"id"`
+	TripID      string         `json:"trip_id"`
+	ItineraryID *string        `json:"itinerary_id"`
+	AuthorID    *string        `json:"author_id"`
+	Title       *string        `json:"title"`
+	Body        string         `json:"body"` // markdown
+	Mood        *string        `json:"mood"`
+	Weather     *string        `json:"weather"`
+	LocationID  *string        `json:"location_id"`
+	EntryTime   time.Time      `json:"entry_time"`
+	Shared      bool           `json:"shared"`
+	Media       []JournalMedia `json:"media"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// JournalMedia is a media row attached to a journal entry
+type JournalMedia struct {
+	ID   string `json:"id"`
+	Key  string `json:"-"` // blob store key
+	URL  string `json:"url"`
+	Type string `json:"type"`
+}
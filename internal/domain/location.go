@@ -0,0 +1,18 @@
+package domain
+
+type Location struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Address       string   `json:"address"`
+	Latitude      float64  `json:"latitude"`
+	Longitude     float64  `json:"longitude"`
+	GooglePlaceID *string  `json:"google_place_id"`
+	Types         []string `json:"types,omitempty"`
+	Timezone      string   `json:"timezone"` // IANA zone, e.g. Asia/Tokyo
+}
+
+// NearbyLocation is a known location with its distance from a search point
+type NearbyLocation struct {
+	Location
+	DistanceM float64 `json:"distance_m"`
+}
@@ -0,0 +1,15 @@
+package domain
+
+import (
+	"time"
+)
+
+// Participant is someone taking part in a trip. UserID is nil for
+// travel companions who don't have an account.
+type Participant struct {
+	ID          string    `json:"id"`
+	TripID      string    `json:"trip_id"`
+	UserID      *string   `json:"user_id"`
+	DisplayName string    `json:"display_name"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"time"
+)
+
+// Poll modes
+const (
+	PollUpDown = "updown" // participants up- or downvote each option
+	PollRank   = "rank"   // participants rank the options, scored by Borda count
+)
+
+// Poll statuses, derived from closes_at and closed_at
+const (
+	PollOpen   = "open"
+	PollClosed = "closed"
+)
+
+// Poll lets a trip's participants vote on proposed activities
+type Poll struct {
+	ID                 string       `json:"id"`
+	TripID             string       `json:"trip_id"`
+	CreatedBy          *string      `json:"created_by"` // trip participant
+	Title              string       `json:"title"`
+	Description        *string      `json:"description"`
+	Mode               string       `json:"mode"`
+	Status             string       `json:"status"`
+	ClosesAt           *time.Time   `json:"closes_at"` // scheduled closing
+	ClosedAt           *time.Time   `json:"closed_at"`
+	PromotedActivityID *string      `json:"promoted_activity_id"`
+	Options            []PollOption `json:"options"`
+	Results            *PollResults `json:"results,omitempty"` // only on single-poll responses
+	MyBallot           []PollVote   `json:"my_ballot,omitempty"`
+	CreatedAt          time.Time    `json:"created_at"`
+	UpdatedAt          time.Time    `json:"updated_at"`
+}
+
+// PollOption is an activity proposed in a poll
+type PollOption struct {
+	ID         string    `json:"id"`
+	PollID     string    `json:"poll_id"`
+	ActivityID string    `json:"activity_id"`
+	ProposedBy *string   `json:"proposed_by"` // trip participant
+	Activity   *Activity `json:"activity,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// PollVote is one entry of a participant's ballot: +1 or -1 in updown
+// polls, the rank (1 = favourite) in rank polls
+type PollVote struct {
+	OptionID      string    `json:"option_id"`
+	ParticipantID string    `json:"participant_id"`
+	Value         int       `json:"value"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// PollResults aggregates all ballots, best option first
+type PollResults struct {
+	Ballots  int          `json:"ballots"`
+	Options  []PollResult `json:"options"`
+	WinnerID *string      `json:"winner_option_id"` // nil without votes or on a tie
+	Tied     bool         `json:"tied"`
+}
+
+type PollResult struct {
+	OptionID     string `json:"option_id"`
+	ActivityID   string `json:"activity_id"`
+	Score        int    `json:"score"` // upvotes minus downvotes, or Borda points
+	Upvotes      int    `json:"upvotes,omitempty"`
+	Downvotes    int    `json:"downvotes,omitempty"`
+	FirstChoices int    `json:"first_choices,omitempty"`
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"time"
+)
+
+// Reservation types, matching the activity types they are booked for
+const (
+	ReservationFlight = "flight"
+	ReservationHotel  = "hotel"
+	ReservationFood   = "food"
+	ReservationCar    = "car"
+	ReservationTrain  = "train"
+	ReservationOther  = "other"
+)
+
+// Reservation is a booking attached to an activity. StartsAt/EndsAt mean
+// departure/arrival for transport, check-in/check-out for hotels, pick-up/
+// drop-off for cars and the table time for restaurants.
+type Reservation struct {
+	ID                 string                  `json:"id"`
+	ActivityID         string                  `json:"activity_id"`
+	TripID             string                  `json:"trip_id"`
+	Type               string                  `json:"type"`
+	Provider           *string                 `json:"provider"`
+	ConfirmationNumber *string                 `json:"confirmation_number"`
+	StartsAt           *time.Time              `json:"starts_at"`
+	EndsAt             *time.Time              `json:"ends_at"`
+	CostMinor          *int64                  `json:"cost_minor"`
+	Cost               *string                 `json:"cost"`
+	Currency           *string                 `json:"currency"`
+	Notes              *string                 `json:"notes"`
+	Details            ReservationDetails      `json:"details"`
+	Attachments        []ReservationAttachment `json:"attachments"`
+	CreatedAt          time.Time               `json:"created_at"`
+	UpdatedAt          time.Time               `json:"updated_at"`
+}
+
+// ReservationDetails holds the fields specific to the reservation type.
+// Only the member matching Reservation.Type is set.
+type ReservationDetails struct {
+	Flight *FlightDetails `json:"flight,omitempty"`
+	Hotel  *HotelDetails  `json:"hotel,omitempty"`
+	Food   *FoodDetails   `json:"food,omitempty"`
+	Car    *CarDetails    `json:"car,omitempty"`
+	Train  *TrainDetails  `json:"train,omitempty"`
+}
+
+type FlightDetails struct {
+	FlightNumber      string     `json:"flight_number"`     // e.g. "BA117"
+	DepartureAirport  string     `json:"departure_airport"` // IATA code
+	ArrivalAirport    string     `json:"arrival_airport"`
+	DepartureTerminal *string    `json:"departure_terminal,omitempty"`
+	ArrivalTerminal   *string    `json:"arrival_terminal,omitempty"`
+	Gate              *string    `json:"gate,omitempty"`
+	Seat              *string    `json:"seat,omitempty"`
+	Cabin             *string    `json:"cabin,omitempty"` // economy, premium_economy, business, first
+	BoardingTime      *time.Time `json:"boarding_time,omitempty"`
+}
+
+type HotelDetails struct {
+	Address  *string `json:"address,omitempty"`
+	RoomType *string `json:"room_type,omitempty"`
+	Rooms    int     `json:"rooms"`
+	Guests   int     `json:"guests"`
+}
+
+type FoodDetails struct {
+	PartySize int     `json:"party_size"`
+	Table     *string `json:"table,omitempty"`
+}
+
+type CarDetails struct {
+	PickupLocation  string  `json:"pickup_location"`
+	DropoffLocation *string `json:"dropoff_location,omitempty"` // defaults to the pick-up location
+	CarClass        *string `json:"car_class,omitempty"`
+}
+
+type TrainDetails struct {
+	TrainNumber      *string `json:"train_number,omitempty"`
+	DepartureStation string  `json:"departure_station"`
+	ArrivalStation   string  `json:"arrival_station"`
+	Coach            *string `json:"coach,omitempty"`
+	Seat             *string `json:"seat,omitempty"`
+}
+
+// ReservationAttachment is a media row (ticket, voucher, receipt) attached to a reservation
+type ReservationAttachment struct {
+	ID   string `json:"id"`
+	Key  string `json:"-"` // blob store key
+	URL  string `json:"url"`
+	Type string `json:"type"`
+}
@@ -0,0 +1,29 @@
+package domain
+
+// TravelStats summarises one trip, all of a user's trips, or one calendar year
+type TravelStats struct {
+	Year             *int           `json:"year,omitempty"`
+	Trips            int            `json:"trips"`
+	DaysTravelled    int            `json:"days_travelled"`
+	Countries        []string       `json:"countries"`
+	Cities           []string       `json:"cities"`
+	DistanceKm       float64        `json:"distance_km"`
+	Activities       int            `json:"activities"`
+	ActivitiesByType map[string]int `json:"activities_by_type"`
+	BusiestDay       *BusiestDay    `json:"busiest_day"`
+	Photos           int            `json:"photos"`
+	Spend            []SpendTotal   `json:"spend"`
+	TotalSpend       *SpendTotal    `json:"total_spend,omitempty"` // only when a target currency is requested
+}
+
+type BusiestDay struct {
+	Date       string `json:"date"` // YYYY-MM-DD
+	TripID     string `json:"trip_id"`
+	Activities int    `json:"activities"`
+}
+
+type SpendTotal struct {
+	Currency    string `json:"currency"`
+	AmountMinor int64  `json:"amount_minor"`
+	Amount      string `json:"amount"`
+}
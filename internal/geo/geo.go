@@ -0,0 +1,44 @@
+package geo
+
+import "math"
+
+// EarthRadiusKm is the mean Earth radius used for great-circle distances
+const EarthRadiusKm = 6371.0088
+
+// Point is a WGS84 coordinate in degrees
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// Haversine returns the great-circle distance between two points in km
+func Haversine(a, b Point) float64 {
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// BoundingBox returns the south-west and north-east corners of a box that
+// contains every point within radiusKm of center. Near the poles and the
+// antimeridian the box widens to all longitudes.
+func BoundingBox(center Point, radiusKm float64) (min, max Point) {
+	dLat := radiusKm / EarthRadiusKm * 180 / math.Pi
+	min = Point{Lat: math.Max(-90, center.Lat-dLat), Lng: -180}
+	max = Point{Lat: math.Min(90, center.Lat+dLat), Lng: 180}
+
+	cos := math.Cos(center.Lat * math.Pi / 180)
+	if min.Lat == -90 || max.Lat == 90 || cos <= 0 {
+		return min, max
+	}
+	dLng := dLat / cos
+	if center.Lng-dLng >= -180 && center.Lng+dLng <= 180 {
+		min.Lng = center.Lng - dLng
+		max.Lng = center.Lng + dLng
+	}
+	return min, max
+}
@@ -0,0 +1,46 @@
+package geo
+
+import (
+	"log"
+	"sync"
+	"time"
+	_ "time/tzdata" // zones must load on hosts without a zoneinfo database
+
+	"github.com/ringsaturn/tzf"
+)
+
+var (
+	finderOnce sync.Once
+	finder     tzf.F
+)
+
+// Timezone returns the IANA timezone at a point, e.g. "Asia/Tokyo", from
+// an embedded timezone boundary dataset. Points at sea get a nautical
+// "Etc/GMT±N" zone. The dataset is loaded on first use, which takes about
+// a second.
+func Timezone(p Point) string {
+	finderOnce.Do(func() {
+		f, err := tzf.NewDefaultFinder()
+		if err != nil {
+			log.Printf("geo: loading timezone boundaries: %v", err)
+			return
+		}
+		finder = f
+	})
+	if finder == nil {
+		return ""
+	}
+	return finder.GetTimezoneName(p.Lng, p.Lat)
+}
+
+// LoadZone returns the named zone, or UTC for empty or unknown names
+func LoadZone(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
@@ -0,0 +1,34 @@
+package geo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimezone(t *testing.T) {
+	tests := []struct {
+		name  string
+		point Point
+		want  string
+	}{
+		{"Tokyo", Point{Lat: 35.6762, Lng: 139.6503}, "Asia/Tokyo"},
+		{"Paris", Point{Lat: 48.8566, Lng: 2.3522}, "Europe/Paris"},
+		{"New York", Point{Lat: 40.7128, Lng: -74.0060}, "America/New_York"},
+	}
+	for _, tt := range tests {
+		if got := Timezone(tt.point); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadZone(t *testing.T) {
+	if got := LoadZone("Asia/Tokyo"); got.String() != "Asia/Tokyo" {
+		t.Errorf("got %s", got)
+	}
+	for _, name := range []string{"", "Mars/Olympus_Mons"} {
+		if got := LoadZone(name); got != time.UTC {
+			t.Errorf("%q: got %s, want UTC", name, got)
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/places"
+	"github.com/NoahFola/travel_app_backend/internal/repository"
 	"github.com/NoahFola/travel_app_backend/internal/service"
 	"github.com/gin-gonic/gin"
 )
@@ -14,24 +17,48 @@ type ActivityHandler struct {
 }
 
 type createActivityRequest struct {
-	Name        string     `json:"name" binding:"required"`
-	Description *string    `json:"description"`
-	Location    *string    `json:"location"`
-	StartTime   *time.Time `json:"start_time"`
-	EndTime     *time.Time `json:"end_time"`
-	Type        *string    `json:"type"`
-	Status      string     `json:"status"`
+	Name           string        `json:"name" binding:"required"`
+	Description    *string       `json:"description"`
+	Location       *string       `json:"location"`
+	LocationID     *string       `json:"location_id"`
+	Place          *places.Place `json:"place"` // a /locations/search result
+	StartTime      *time.Time    `json:"start_time"`
+	EndTime        *time.Time    `json:"end_time"`
+	LocalStartTime *string       `json:"local_start_time"` // wall-clock time in timezone, instead of start_time
+	LocalEndTime   *string       `json:"local_end_time"`
+	Timezone       string        `json:"timezone"`   // defaults to the place's timezone
+	EndDate        *time.Time    `json:"end_date"`   // last day of a multi-day activity
+	Recurrence     *string       `json:"recurrence"` // RRULE subset
+	Type           *string       `json:"type"`
+	Status         string        `json:"status"`
+	ItineraryID    *string       `json:"itinerary_id"` // only read by POST /trips/:tripId/activities
 }
 
 type updateActivityRequest struct {
-	Name        string     `json:"name"`
-	Description *string    `json:"description"`
-	Location    *string    `json:"location"`
-	StartTime   *time.Time `json:"start_time"`
-	EndTime     *time.Time `json:"end_time"`
-	Type        *string    `json:"type"`
-	Status      string     `json:"status"`
-	ItineraryID *string    `json:"itinerary_id"` // can move between days
+	Name           string        `json:"name"`
+	Description    *string       `json:"description"`
+	Location       *string       `json:"location"`
+	LocationID     *string       `json:"location_id"` // "" unlinks the location
+	Place          *places.Place `json:"place"`
+	StartTime      *time.Time    `json:"start_time"`
+	EndTime        *time.Time    `json:"end_time"`
+	LocalStartTime *string       `json:"local_start_time"`
+	LocalEndTime   *string       `json:"local_end_time"`
+	Timezone       string        `json:"timezone"`
+	EndDate        *time.Time    `json:"end_date"`   // the activity's own day makes it single-day again
+	Recurrence     *string       `json:"recurrence"` // "" stops the repetition
+	Type           *string       `json:"type"`
+	Status         string        `json:"status"`
+	ItineraryID    *string       `json:"itinerary_id"` // can move between days, "" moves it to the backlog
+}
+
+type reorderActivitiesRequest struct {
+	IDs []string `json:"ids" binding:"required"`
+}
+
+type moveActivityRequest struct {
+	ItineraryID string `json:"itinerary_id" binding:"required"`
+	Position    *int   `json:"position"` // 0-based index, omit to append
 }
 
 func (h *ActivityHandler) CreateActivity(c *gin.Context) {
@@ -47,8 +74,11 @@ func (h *ActivityHandler) CreateActivity(c *gin.Context) {
 		Name:        req.Name,
 		Description: req.Description,
 		Location:    req.Location,
+		LocationID:  req.LocationID,
 		StartTime:   req.StartTime,
 		EndTime:     req.EndTime,
+		EndDate:     req.EndDate,
+		Recurrence:  req.Recurrence,
 		Type:        req.Type,
 		Status:      req.Status,
 	}
@@ -64,6 +94,13 @@ func (h *ActivityHandler) CreateActivity(c *gin.Context) {
 		activity.Status = "planned"
 	}
 
+	if req.Place != nil {
+		if err := h.Service.AttachPlace(c.Request.Context(), activity, *req.Place); err != nil {
+			respondActivityError(c, err)
+			return
+		}
+	}
+
 	// We need to set TripID.
 	// We can add a helper in ActivityHandler or Service to fill this.
 	// Let's do it in the Service. No, Service expects a domain object.
@@ -96,14 +133,75 @@ func (h *ActivityHandler) CreateActivity(c *gin.Context) {
 	// Actually, better to fix `ActivityService` first? Or just implement Handler and then fix Service.
 	// I'll implement Handler, then I'll see I need to fix Service.
 
-	if err := h.Service.CreateActivity(c.Request.Context(), activity); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.Service.SetLocalTimes(c.Request.Context(), activity, req.LocalStartTime, req.LocalEndTime, req.Timezone); err != nil {
+		respondActivityError(c, err)
+		return
+	}
+
+	if err := h.Service.CreateActivity(c.Request.Context(), activity, c.GetString("userID"), c.Query("reject_conflicts") == "true"); err != nil {
+		respondActivityError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, activity)
 }
 
+// CreateTripActivity adds an activity to the trip, on a day when
+// itinerary_id is given and to the unscheduled backlog otherwise
+func (h *ActivityHandler) CreateTripActivity(c *gin.Context) {
+	var req createActivityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	activity := &domain.Activity{
+		TripID:      c.Param("tripId"),
+		ItineraryID: req.ItineraryID,
+		Name:        req.Name,
+		Description: req.Description,
+		Location:    req.Location,
+		LocationID:  req.LocationID,
+		StartTime:   req.StartTime,
+		EndTime:     req.EndTime,
+		EndDate:     req.EndDate,
+		Recurrence:  req.Recurrence,
+		Type:        req.Type,
+		Status:      req.Status,
+	}
+	if activity.ItineraryID != nil && *activity.ItineraryID == "" {
+		activity.ItineraryID = nil
+	}
+	if req.Place != nil {
+		if err := h.Service.AttachPlace(c.Request.Context(), activity, *req.Place); err != nil {
+			respondActivityError(c, err)
+			return
+		}
+	}
+
+	if err := h.Service.SetLocalTimes(c.Request.Context(), activity, req.LocalStartTime, req.LocalEndTime, req.Timezone); err != nil {
+		respondActivityError(c, err)
+		return
+	}
+
+	if err := h.Service.CreateActivity(c.Request.Context(), activity, c.GetString("userID"), c.Query("reject_conflicts") == "true"); err != nil {
+		respondActivityError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, activity)
+}
+
+// ListTripActivities lists every activity of the trip, or with
+// ?unscheduled=true only the ones not assigned to a day
+func (h *ActivityHandler) ListTripActivities(c *gin.Context) {
+	activities, err := h.Service.ListTripActivities(c.Request.Context(), c.Param("tripId"), c.Query("unscheduled") == "true")
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, activities)
+}
+
 func (h *ActivityHandler) ListActivities(c *gin.Context) {
 	itineraryID := c.Param("id")
 	activities, err := h.Service.ListActivities(c.Request.Context(), itineraryID)
@@ -147,12 +245,34 @@ func (h *ActivityHandler) UpdateActivity(c *gin.Context) {
 	if req.Location != nil {
 		activity.Location = req.Location
 	}
+	if req.LocationID != nil {
+		activity.LocationID = req.LocationID
+		if *req.LocationID == "" {
+			activity.LocationID = nil
+		}
+	}
+	if req.Place != nil {
+		if err := h.Service.AttachPlace(c.Request.Context(), activity, *req.Place); err != nil {
+			respondActivityError(c, err)
+			return
+		}
+	}
 	if req.StartTime != nil {
 		activity.StartTime = req.StartTime
 	}
 	if req.EndTime != nil {
 		activity.EndTime = req.EndTime
 	}
+	if err := h.Service.SetLocalTimes(c.Request.Context(), activity, req.LocalStartTime, req.LocalEndTime, req.Timezone); err != nil {
+		respondActivityError(c, err)
+		return
+	}
+	if req.EndDate != nil {
+		activity.EndDate = req.EndDate
+	}
+	if req.Recurrence != nil {
+		activity.Recurrence = req.Recurrence
+	}
 	if req.Type != nil {
 		activity.Type = req.Type
 	}
@@ -161,16 +281,24 @@ func (h *ActivityHandler) UpdateActivity(c *gin.Context) {
 	}
 	if req.ItineraryID != nil {
 		activity.ItineraryID = req.ItineraryID
+		if *req.ItineraryID == "" {
+			activity.ItineraryID = nil
+		}
 	}
 
-	if err := h.Service.UpdateActivity(c.Request.Context(), activity); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.Service.UpdateActivity(c.Request.Context(), activity, c.GetString("userID"), c.Query("reject_conflicts") == "true"); err != nil {
+		respondActivityError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, activity)
 }
 
+// ListStatuses returns the activity statuses and the transitions allowed from each
+func (h *ActivityHandler) ListStatuses(c *gin.Context) {
+	c.JSON(http.StatusOK, service.ActivityStatuses())
+}
+
 func (h *ActivityHandler) DeleteActivity(c *gin.Context) {
 	id := c.Param("id")
 	if err := h.Service.DeleteActivity(c.Request.Context(), id); err != nil {
@@ -179,3 +307,74 @@ func (h *ActivityHandler) DeleteActivity(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "activity deleted"})
 }
+
+func (h *ActivityHandler) ReorderActivities(c *gin.Context) {
+	itineraryID := c.Param("id")
+	var req reorderActivitiesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	activities, err := h.Service.ReorderActivities(c.Request.Context(), itineraryID, req.IDs)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidOrder) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, activities)
+}
+
+func (h *ActivityHandler) MoveActivity(c *gin.Context) {
+	id := c.Param("id")
+	var req moveActivityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	index := -1
+	if req.Position != nil {
+		index = *req.Position
+	}
+
+	activity, err := h.Service.MoveActivity(c.Request.Context(), id, req.ItineraryID, index)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, activity)
+}
+
+// respondActivityError answers 409 with the conflict list for rejected writes,
+// 404 for unknown trips or days, 400 for unknown locations, incomplete places
+// or invalid schedules and 422 for invalid statuses
+func respondActivityError(c *gin.Context, err error) {
+	var conflictErr *service.ConflictError
+	if errors.As(err, &conflictErr) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "conflicts": conflictErr.Conflicts})
+		return
+	}
+	if errors.Is(err, service.ErrTripNotFound) || errors.Is(err, service.ErrItineraryNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrLocationNotFound) || errors.Is(err, service.ErrInvalidPlace) || errors.Is(err, service.ErrInvalidSchedule) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidStatus) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, repository.ErrStatusChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
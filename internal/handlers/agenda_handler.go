@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type AgendaHandler struct {
+	Service *service.AgendaService
+}
+
+type overrideOccurrenceRequest struct {
+	Cancelled   bool       `json:"cancelled"` // skip this day
+	Name        *string    `json:"name"`
+	Description *string    `json:"description"`
+	Location    *string    `json:"location"`
+	StartTime   *time.Time `json:"start_time"`
+	EndTime     *time.Time `json:"end_time"`
+}
+
+func (h *AgendaHandler) DayAgenda(c *gin.Context) {
+	occurrences, err := h.Service.DayAgenda(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, occurrences)
+}
+
+func (h *AgendaHandler) TripAgenda(c *gin.Context) {
+	days, err := h.Service.TripAgenda(c.Request.Context(), c.Param("tripId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, days)
+}
+
+// OverrideOccurrence changes or cancels the occurrence on :date (YYYY-MM-DD)
+func (h *AgendaHandler) OverrideOccurrence(c *gin.Context) {
+	date, ok := occurrenceDate(c)
+	if !ok {
+		return
+	}
+	var req overrideOccurrenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	override := &domain.ActivityOverride{
+		ActivityID:  c.Param("id"),
+		Date:        date,
+		Cancelled:   req.Cancelled,
+		Name:        req.Name,
+		Description: req.Description,
+		Location:    req.Location,
+		StartTime:   req.StartTime,
+		EndTime:     req.EndTime,
+	}
+	if err := h.Service.SetOverride(c.Request.Context(), override); err != nil {
+		if errors.Is(err, service.ErrInvalidSchedule) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, override)
+}
+
+// DeleteOverride restores the occurrence on :date to the activity's details
+func (h *AgendaHandler) DeleteOverride(c *gin.Context) {
+	date, ok := occurrenceDate(c)
+	if !ok {
+		return
+	}
+	if err := h.Service.DeleteOverride(c.Request.Context(), c.Param("id"), date); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "override deleted"})
+}
+
+func occurrenceDate(c *gin.Context) (time.Time, bool) {
+	date, err := time.Parse("2006-01-02", c.Param("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date must look like 2024-01-31"})
+		return time.Time{}, false
+	}
+	return date, true
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ChecklistHandler struct {
+	Service *service.ChecklistService
+}
+
+type createChecklistRequest struct {
+	Title string `json:"title" binding:"required"`
+	Kind  string `json:"kind"` // packing (default), pre_departure, documents, other
+}
+
+type updateChecklistRequest struct {
+	Title string `json:"title"`
+	Kind  string `json:"kind"`
+}
+
+type applyTemplateRequest struct {
+	Template string `json:"template" binding:"required"`
+}
+
+type createChecklistItemRequest struct {
+	Title      string     `json:"title" binding:"required"`
+	Quantity   int        `json:"quantity"`
+	AssigneeID *string    `json:"assignee_id"`
+	DueDate    *time.Time `json:"due_date"`
+}
+
+type updateChecklistItemRequest struct {
+	Title      string     `json:"title"`
+	Quantity   int        `json:"quantity"`
+	AssigneeID *string    `json:"assignee_id"`
+	DueDate    *time.Time `json:"due_date"`
+	Done       *bool      `json:"done"`
+}
+
+type reorderRequest struct {
+	IDs []string `json:"ids" binding:"required"`
+}
+
+func (h *ChecklistHandler) CreateChecklist(c *gin.Context) {
+	tripID := c.Param("tripId")
+	var req createChecklistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	checklist := &domain.Checklist{
+		TripID: tripID,
+		Title:  req.Title,
+		Kind:   req.Kind,
+	}
+
+	if err := h.Service.CreateChecklist(c.Request.Context(), checklist); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, checklist)
+}
+
+func (h *ChecklistHandler) ListChecklists(c *gin.Context) {
+	tripID := c.Param("tripId")
+	checklists, err := h.Service.ListChecklists(c.Request.Context(), tripID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, checklists)
+}
+
+func (h *ChecklistHandler) ListTemplates(c *gin.Context) {
+	c.JSON(http.StatusOK, h.Service.Templates())
+}
+
+func (h *ChecklistHandler) ApplyTemplate(c *gin.Context) {
+	tripID := c.Param("tripId")
+	var req applyTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	checklists, err := h.Service.ApplyTemplate(c.Request.Context(), tripID, req.Template)
+	switch {
+	case errors.Is(err, service.ErrUnknownTemplate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrTripNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, checklists)
+}
+
+func (h *ChecklistHandler) GetChecklist(c *gin.Context) {
+	id := c.Param("id")
+	checklist, err := h.Service.GetChecklist(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "checklist not found"})
+		return
+	}
+	c.JSON(http.StatusOK, checklist)
+}
+
+func (h *ChecklistHandler) UpdateChecklist(c *gin.Context) {
+	id := c.Param("id")
+	var req updateChecklistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	checklist, err := h.Service.GetChecklist(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "checklist not found"})
+		return
+	}
+
+	if req.Title != "" {
+		checklist.Title = req.Title
+	}
+	if req.Kind != "" {
+		checklist.Kind = req.Kind
+	}
+
+	if err := h.Service.UpdateChecklist(c.Request.Context(), checklist); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, checklist)
+}
+
+func (h *ChecklistHandler) DeleteChecklist(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.Service.DeleteChecklist(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "checklist not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "checklist deleted"})
+}
+
+func (h *ChecklistHandler) AddItem(c *gin.Context) {
+	checklistID := c.Param("id")
+	var req createChecklistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item := &domain.ChecklistItem{
+		ChecklistID: checklistID,
+		Title:       req.Title,
+		Quantity:    req.Quantity,
+		AssigneeID:  req.AssigneeID,
+		DueDate:     req.DueDate,
+	}
+
+	if err := h.Service.AddItem(c.Request.Context(), item); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, item)
+}
+
+func (h *ChecklistHandler) UpdateItem(c *gin.Context) {
+	checklistID := c.Param("id")
+	itemID := c.Param("itemId")
+	var req updateChecklistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := h.Service.GetItem(c.Request.Context(), checklistID, itemID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "checklist item not found"})
+		return
+	}
+
+	if req.Title != "" {
+		item.Title = req.Title
+	}
+	if req.Quantity != 0 {
+		item.Quantity = req.Quantity
+	}
+	if req.AssigneeID != nil {
+		item.AssigneeID = req.AssigneeID
+	}
+	if req.DueDate != nil {
+		item.DueDate = req.DueDate
+	}
+	if req.Done != nil {
+		item.Done = *req.Done
+	}
+
+	if err := h.Service.UpdateItem(c.Request.Context(), item); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+func (h *ChecklistHandler) DeleteItem(c *gin.Context) {
+	checklistID := c.Param("id")
+	itemID := c.Param("itemId")
+	if err := h.Service.DeleteItem(c.Request.Context(), checklistID, itemID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "checklist item not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "checklist item deleted"})
+}
+
+func (h *ChecklistHandler) ReorderItems(c *gin.Context) {
+	checklistID := c.Param("id")
+	var req reorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	checklist, err := h.Service.ReorderItems(c.Request.Context(), checklistID, req.IDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, checklist)
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/places"
+	"github.com/NoahFola/travel_app_backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type CollectionHandler struct {
+	Service *service.CollectionService
+}
+
+type collectionRequest struct {
+	Name        string  `json:"name" binding:"required"`
+	Description *string `json:"description"`
+}
+
+type updateCollectionRequest struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+}
+
+type savePlaceRequest struct {
+	LocationID string        `json:"location_id"` // a known location
+	Place      *places.Place `json:"place"`       // or a /locations/search result
+	Note       *string       `json:"note"`
+	Tags       []string      `json:"tags"`
+}
+
+type updateSavedPlaceRequest struct {
+	Note *string   `json:"note"`
+	Tags *[]string `json:"tags"` // replaces the tags, [] clears them
+}
+
+type scheduleSavedPlaceRequest struct {
+	TripID      string     `json:"trip_id"`      // backlog of this trip
+	ItineraryID *string    `json:"itinerary_id"` // or this day
+	Name        string     `json:"name"`         // defaults to the place name
+	Description *string    `json:"description"`  // defaults to the note
+	StartTime   *time.Time `json:"start_time"`
+	EndTime     *time.Time `json:"end_time"`
+	Type        *string    `json:"type"`
+	Status      string     `json:"status"`
+}
+
+func (h *CollectionHandler) CreateCollection(c *gin.Context) {
+	var req collectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection := &domain.PlaceCollection{
+		UserID:      c.GetString("userID"),
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	if err := h.Service.CreateCollection(c.Request.Context(), collection); err != nil {
+		respondCollectionError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, collection)
+}
+
+func (h *CollectionHandler) ListCollections(c *gin.Context) {
+	collections, err := h.Service.ListCollections(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, collections)
+}
+
+// GetCollection returns the collection with its places, or with ?tag= only
+// the places with that tag
+func (h *CollectionHandler) GetCollection(c *gin.Context) {
+	collection, err := h.Service.GetCollection(c.Request.Context(), c.Param("id"), c.GetString("userID"), c.Query("tag"))
+	if err != nil {
+		respondCollectionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, collection)
+}
+
+func (h *CollectionHandler) UpdateCollection(c *gin.Context) {
+	var req updateCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("userID")
+	collection, err := h.Service.GetCollection(c.Request.Context(), c.Param("id"), userID, "")
+	if err != nil {
+		respondCollectionError(c, err)
+		return
+	}
+	if req.Name != "" {
+		collection.Name = req.Name
+	}
+	if req.Description != nil {
+		collection.Description = req.Description
+	}
+
+	if err := h.Service.UpdateCollection(c.Request.Context(), collection, userID); err != nil {
+		respondCollectionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, collection)
+}
+
+func (h *CollectionHandler) DeleteCollection(c *gin.Context) {
+	if err := h.Service.DeleteCollection(c.Request.Context(), c.Param("id"), c.GetString("userID")); err != nil {
+		respondCollectionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "collection deleted"})
+}
+
+func (h *CollectionHandler) SavePlace(c *gin.Context) {
+	var req savePlaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	saved := &domain.SavedPlace{LocationID: req.LocationID, Note: req.Note, Tags: req.Tags}
+	if err := h.Service.SavePlace(c.Request.Context(), c.Param("id"), c.GetString("userID"), saved, req.Place); err != nil {
+		respondCollectionError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, saved)
+}
+
+func (h *CollectionHandler) UpdateSavedPlace(c *gin.Context) {
+	var req updateSavedPlaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("userID")
+	saved, err := h.Service.GetSavedPlace(c.Request.Context(), c.Param("id"), c.Param("placeId"), userID)
+	if err != nil {
+		respondCollectionError(c, err)
+		return
+	}
+	if req.Note != nil {
+		saved.Note = req.Note
+	}
+	if req.Tags != nil {
+		saved.Tags = *req.Tags
+	}
+
+	if err := h.Service.UpdateSavedPlace(c.Request.Context(), saved, userID); err != nil {
+		respondCollectionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, saved)
+}
+
+func (h *CollectionHandler) RemovePlace(c *gin.Context) {
+	if err := h.Service.RemovePlace(c.Request.Context(), c.Param("id"), c.Param("placeId"), c.GetString("userID")); err != nil {
+		respondCollectionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "place removed"})
+}
+
+// ScheduleSavedPlace creates an activity at a saved place
+func (h *CollectionHandler) ScheduleSavedPlace(c *gin.Context) {
+	var req scheduleSavedPlaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	activity := &domain.Activity{
+		TripID:      req.TripID,
+		ItineraryID: req.ItineraryID,
+		Name:        req.Name,
+		Description: req.Description,
+		StartTime:   req.StartTime,
+		EndTime:     req.EndTime,
+		Type:        req.Type,
+		Status:      req.Status,
+	}
+	if activity.ItineraryID != nil && *activity.ItineraryID == "" {
+		activity.ItineraryID = nil
+	}
+	err := h.Service.ScheduleSavedPlace(c.Request.Context(), c.Param("id"), c.Param("placeId"), c.GetString("userID"), activity, c.Query("reject_conflicts") == "true")
+	if err != nil {
+		respondCollectionError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, activity)
+}
+
+func (h *CollectionHandler) ShareCollection(c *gin.Context) {
+	token, err := h.Service.Share(c.Request.Context(), c.Param("id"), c.GetString("userID"))
+	if err != nil {
+		respondCollectionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"share_token": token, "url": "/preview/collections/" + token})
+}
+
+func (h *CollectionHandler) UnshareCollection(c *gin.Context) {
+	if err := h.Service.Unshare(c.Request.Context(), c.Param("id"), c.GetString("userID")); err != nil {
+		respondCollectionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "collection is no longer shared"})
+}
+
+// GetSharedCollection is the public, read-only view of a shared collection
+func (h *CollectionHandler) GetSharedCollection(c *gin.Context) {
+	collection, err := h.Service.GetSharedCollection(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invalid or revoked share token"})
+		return
+	}
+	c.JSON(http.StatusOK, collection)
+}
+
+// respondCollectionError answers 403 for other users' collections and trips
+// the user doesn't take part in, 400 for invalid input, the activity codes
+// when scheduling a place fails, and 404 otherwise
+func respondCollectionError(c *gin.Context, err error) {
+	var conflictErr *service.ConflictError
+	switch {
+	case errors.Is(err, service.ErrNotCollectionOwner), errors.Is(err, service.ErrNotParticipant):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidCollection):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.As(err, &conflictErr),
+		errors.Is(err, service.ErrLocationNotFound),
+		errors.Is(err, service.ErrInvalidPlace),
+		errors.Is(err, service.ErrInvalidSchedule),
+		errors.Is(err, service.ErrInvalidStatus),
+		errors.Is(err, service.ErrTripNotFound),
+		errors.Is(err, service.ErrItineraryNotFound):
+		respondActivityError(c, err)
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/NoahFola/travel_app_backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ConflictHandler struct {
+	Service *service.ConflictService
+}
+
+// speedParam reads the optional ?speed_kmh= override; 0 means use the default
+func speedParam(c *gin.Context) (float64, bool) {
+	v := c.Query("speed_kmh")
+	if v == "" {
+		return 0, true
+	}
+	speed, err := strconv.ParseFloat(v, 64)
+	if err != nil || speed <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "speed_kmh must be a positive number"})
+		return 0, false
+	}
+	return speed, true
+}
+
+func (h *ConflictHandler) ItineraryConflicts(c *gin.Context) {
+	id := c.Param("id")
+	speed, ok := speedParam(c)
+	if !ok {
+		return
+	}
+
+	conflicts, err := h.Service.ItineraryConflicts(c.Request.Context(), id, speed)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"conflicts": conflicts})
+}
+
+func (h *ConflictHandler) TripConflicts(c *gin.Context) {
+	tripID := c.Param("tripId")
+	speed, ok := speedParam(c)
+	if !ok {
+		return
+	}
+
+	conflicts, err := h.Service.TripConflicts(c.Request.Context(), tripID, speed)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"conflicts": conflicts})
+}
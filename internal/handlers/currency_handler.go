@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/currency"
+	"github.com/NoahFola/travel_app_backend/internal/money"
+	"github.com/NoahFola/travel_app_backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type CurrencyHandler struct {
+	Service *service.CurrencyService
+}
+
+// Convert handles GET /currency/convert?amount=12.50&from=USD&to=EUR&date=2024-01-05
+func (h *CurrencyHandler) Convert(c *gin.Context) {
+	from := strings.ToUpper(c.Query("from"))
+	to := strings.ToUpper(c.Query("to"))
+	if !money.ValidCurrency(from) || !money.ValidCurrency(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be 3-letter currency codes"})
+		return
+	}
+
+	amount, err := money.Parse(c.Query("amount"), from)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	date := time.Now()
+	if d := c.Query("date"); d != "" {
+		date, err = time.Parse(currency.DateLayout, d)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be YYYY-MM-DD"})
+			return
+		}
+	}
+
+	conversion, err := h.Service.Convert(c.Request.Context(), amount, from, to, date)
+	if err != nil {
+		if errors.Is(err, currency.ErrRateNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, conversion)
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/currency"
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/money"
+	"github.com/NoahFola/travel_app_backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ExpenseHandler struct {
+	Service *service.ExpenseService
+}
+
+// Amounts are decimal strings ("12.50") so clients never send floats
+type createExpenseRequest struct {
+	Description string                `json:"description" binding:"required"`
+	Amount      string                `json:"amount" binding:"required"`
+	Currency    string                `json:"currency" binding:"required"`
+	PaidBy      string                `json:"paid_by" binding:"required"`
+	ActivityID  *string               `json:"activity_id"`
+	SplitMethod string                `json:"split_method"` // equal (default), shares, exact
+	Splits      []expenseSplitRequest `json:"splits"`
+	IncurredAt  *time.Time            `json:"incurred_at"`
+}
+
+type expenseSplitRequest struct {
+	ParticipantID string `json:"participant_id" binding:"required"`
+	Shares        *int   `json:"shares"` // for split_method = shares
+	Amount        string `json:"amount"` // for split_method = exact
+}
+
+type createSettlementRequest struct {
+	From     string `json:"from_participant_id" binding:"required"`
+	To       string `json:"to_participant_id" binding:"required"`
+	Amount   string `json:"amount" binding:"required"`
+	Currency string `json:"currency" binding:"required"`
+}
+
+func (h *ExpenseHandler) CreateExpense(c *gin.Context) {
+	tripID := c.Param("tripId")
+	var req createExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	amount, err := money.Parse(req.Amount, req.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	expense := &domain.Expense{
+		TripID:      tripID,
+		ActivityID:  req.ActivityID,
+		PaidBy:      req.PaidBy,
+		Description: req.Description,
+		AmountMinor: amount,
+		Currency:    req.Currency,
+		SplitMethod: req.SplitMethod,
+	}
+	if req.IncurredAt != nil {
+		expense.IncurredAt = *req.IncurredAt
+	}
+
+	for _, s := range req.Splits {
+		split := domain.ExpenseSplit{ParticipantID: s.ParticipantID, Shares: s.Shares}
+		if req.SplitMethod == domain.SplitExact {
+			split.AmountMinor, err = money.Parse(s.Amount, req.Currency)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		expense.Splits = append(expense.Splits, split)
+	}
+
+	if err := h.Service.CreateExpense(c.Request.Context(), expense, c.GetString("userID")); err != nil {
+		respondExpenseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, expense)
+}
+
+func (h *ExpenseHandler) ListExpenses(c *gin.Context) {
+	tripID := c.Param("tripId")
+	expenses, err := h.Service.ListExpenses(c.Request.Context(), tripID, c.GetString("userID"))
+	if err != nil {
+		respondExpenseError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, expenses)
+}
+
+func (h *ExpenseHandler) GetExpense(c *gin.Context) {
+	expense, err := h.Service.GetExpense(c.Request.Context(), c.Param("tripId"), c.Param("expenseId"), c.GetString("userID"))
+	if err != nil {
+		respondExpenseError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, expense)
+}
+
+func (h *ExpenseHandler) DeleteExpense(c *gin.Context) {
+	if err := h.Service.DeleteExpense(c.Request.Context(), c.Param("tripId"), c.Param("expenseId"), c.GetString("userID")); err != nil {
+		respondExpenseError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "expense deleted"})
+}
+
+func (h *ExpenseHandler) GetBalances(c *gin.Context) {
+	tripID := c.Param("tripId")
+	// Optional ?currency=EUR converts everything into a single currency
+	balances, transfers, err := h.Service.Balances(c.Request.Context(), tripID, c.GetString("userID"), c.Query("currency"))
+	if err != nil {
+		respondExpenseError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"balances": balances, "settle_up": transfers})
+}
+
+func (h *ExpenseHandler) CreateSettlement(c *gin.Context) {
+	tripID := c.Param("tripId")
+	var req createSettlementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	amount, err := money.Parse(req.Amount, req.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settlement, err := h.Service.RecordSettlement(c.Request.Context(), tripID, c.GetString("userID"), req.From, req.To, amount, req.Currency)
+	if err != nil {
+		respondExpenseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, settlement)
+}
+
+func respondExpenseError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidExpense):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotParticipant):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTripNotFound), errors.Is(err, service.ErrExpenseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, currency.ErrRateNotFound):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/NoahFola/travel_app_backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// maxEmailSize caps raw .eml uploads, attachments included
+const maxEmailSize = 25 << 20
+
+type InboundHandler struct {
+	Service *service.InboundService
+	// WebhookSecret must be sent by the mail provider in X-Inbound-Secret.
+	// The webhook refuses every request while it is empty.
+	WebhookSecret string
+}
+
+type assignInboxItemRequest struct {
+	TripID string `json:"trip_id" binding:"required"`
+}
+
+func (h *InboundHandler) GetAddress(c *gin.Context) {
+	addr, err := h.Service.Address(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, addr)
+}
+
+func (h *InboundHandler) RotateAddress(c *gin.Context) {
+	addr, err := h.Service.RotateAddress(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, addr)
+}
+
+// ReceiveEmail is the public webhook the mail provider posts raw messages to
+func (h *InboundHandler) ReceiveEmail(c *gin.Context) {
+	if h.WebhookSecret == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "inbound email is not configured"})
+		return
+	}
+	got := c.GetHeader("X-Inbound-Secret")
+	if subtle.ConstantTimeCompare([]byte(got), []byte(h.WebhookSecret)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	raw, err := emailBody(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer raw.Close()
+
+	items, err := h.Service.Receive(c.Request.Context(), raw)
+	if err != nil {
+		respondInboundError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// UploadEmail ingests a .eml file for the logged-in user
+func (h *InboundHandler) UploadEmail(c *gin.Context) {
+	raw, err := emailBody(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer raw.Close()
+
+	items, err := h.Service.Upload(c.Request.Context(), c.GetString("userID"), raw)
+	if err != nil {
+		respondInboundError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+func (h *InboundHandler) ListInbox(c *gin.Context) {
+	items, err := h.Service.ListInbox(c.Request.Context(), c.GetString("userID"), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+func (h *InboundHandler) AssignItem(c *gin.Context) {
+	var req assignInboxItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := h.Service.AssignItem(c.Request.Context(), c.GetString("userID"), c.Param("id"), req.TripID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+func (h *InboundHandler) DismissItem(c *gin.Context) {
+	if err := h.Service.DismissItem(c.Request.Context(), c.GetString("userID"), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "inbox item dismissed"})
+}
+
+// emailBody accepts the message either as a multipart "file" field or as
+// the raw request body (message/rfc822)
+func emailBody(c *gin.Context) (io.ReadCloser, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxEmailSize)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, err := c.FormFile("file")
+		if err != nil {
+			return nil, errors.New("file is required")
+		}
+		return file.Open()
+	}
+	return c.Request.Body, nil
+}
+
+func respondInboundError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUnknownRecipient):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNoBookings):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
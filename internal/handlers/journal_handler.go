@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type JournalHandler struct {
+	Service *service.JournalService
+}
+
+type createJournalEntryRequest struct {
+	ItineraryID *string    `json:"itinerary_id"`
+	Title       *string    `json:"title"`
+	Body        string     `json:"body"` // markdown
+	Mood        *string    `json:"mood"`
+	Weather     *string    `json:"weather"`
+	LocationID  *string    `json:"location_id"`
+	EntryTime   *time.Time `json:"entry_time"`
+	Shared      bool       `json:"shared"`
+	MediaIDs    []string   `json:"media_ids"`
+}
+
+type updateJournalEntryRequest struct {
+	ItineraryID *string    `json:"itinerary_id"`
+	Title       *string    `json:"title"`
+	Body        *string    `json:"body"`
+	Mood        *string    `json:"mood"`
+	Weather     *string    `json:"weather"`
+	LocationID  *string    `json:"location_id"`
+	EntryTime   *time.Time `json:"entry_time"`
+	Shared      *bool      `json:"shared"`
+	MediaIDs    []string   `json:"media_ids"` // replaces attachments when present
+}
+
+func (h *JournalHandler) CreateEntry(c *gin.Context) {
+	tripID := c.Param("tripId")
+	var req createJournalEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("userID")
+	entry := &domain.JournalEntry{
+		TripID:      tripID,
+		ItineraryID: req.ItineraryID,
+		AuthorID:    &userID,
+		Title:       req.Title,
+		Body:        req.Body,
+		Mood:        req.Mood,
+		Weather:     req.Weather,
+		LocationID:  req.LocationID,
+		Shared:      req.Shared,
+	}
+	if req.EntryTime != nil {
+		entry.EntryTime = *req.EntryTime
+	}
+
+	if err := h.Service.CreateEntry(c.Request.Context(), entry, req.MediaIDs); err != nil {
+		respondJournalError(c, err, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+func (h *JournalHandler) ListEntries(c *gin.Context) {
+	tripID := c.Param("tripId")
+	var itineraryID *string
+	if id := c.Query("itinerary_id"); id != "" {
+		itineraryID = &id
+	}
+
+	entries, err := h.Service.ListEntries(c.Request.Context(), tripID, c.GetString("userID"), itineraryID)
+	if err != nil {
+		respondJournalError(c, err, http.StatusNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+func (h *JournalHandler) GetEntry(c *gin.Context) {
+	id := c.Param("id")
+	entry, err := h.Service.GetEntry(c.Request.Context(), id, c.GetString("userID"))
+	if err != nil {
+		respondJournalError(c, err, http.StatusNotFound, "journal entry not found")
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+func (h *JournalHandler) UpdateEntry(c *gin.Context) {
+	id := c.Param("id")
+	var req updateJournalEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.Service.GetEntry(c.Request.Context(), id, c.GetString("userID"))
+	if err != nil {
+		respondJournalError(c, err, http.StatusNotFound, "journal entry not found")
+		return
+	}
+
+	if req.ItineraryID != nil {
+		entry.ItineraryID = req.ItineraryID
+	}
+	if req.Title != nil {
+		entry.Title = req.Title
+	}
+	if req.Body != nil {
+		entry.Body = *req.Body
+	}
+	if req.Mood != nil {
+		entry.Mood = req.Mood
+	}
+	if req.Weather != nil {
+		entry.Weather = req.Weather
+	}
+	if req.LocationID != nil {
+		entry.LocationID = req.LocationID
+	}
+	if req.EntryTime != nil {
+		entry.EntryTime = *req.EntryTime
+	}
+	if req.Shared != nil {
+		entry.Shared = *req.Shared
+	}
+
+	if err := h.Service.UpdateEntry(c.Request.Context(), entry, req.MediaIDs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+func (h *JournalHandler) DeleteEntry(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.Service.DeleteEntry(c.Request.Context(), id, c.GetString("userID")); err != nil {
+		respondJournalError(c, err, http.StatusNotFound, "journal entry not found")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "journal entry deleted"})
+}
+
+// respondJournalError answers 403 for users outside the trip, otherwise
+// status with message
+func respondJournalError(c *gin.Context, err error, status int, message string) {
+	if errors.Is(err, service.ErrNotParticipant) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(status, gin.H{"error": message})
+}
@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/NoahFola/travel_app_backend/internal/places"
 	"github.com/NoahFola/travel_app_backend/internal/service"
 	"github.com/gin-gonic/gin"
 )
@@ -11,14 +15,41 @@ type LocationHandler struct {
 	Service *service.LocationService
 }
 
+// Search runs a place search. Besides query, it takes an optional lat/lng
+// bias with a radius in meters, a place type and a language, which
+// defaults to the Accept-Language header.
 func (h *LocationHandler) Search(c *gin.Context) {
-	query := c.Query("query")
-	if query == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "query parameter is required"})
+	req := places.SearchRequest{
+		Query:    c.Query("query"),
+		Type:     c.Query("type"),
+		Language: c.Query("language"),
+	}
+	if req.Query == "" && req.Type == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query or type parameter is required"})
 		return
 	}
+	if req.Language == "" {
+		req.Language = acceptLanguage(c)
+	}
+
+	if c.Query("lat") != "" || c.Query("lng") != "" {
+		lat, lng, ok := parseCoordinates(c)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lng must be valid coordinates"})
+			return
+		}
+		req.Location = &places.LatLng{Lat: lat, Lng: lng}
+	}
+	if c.Query("radius") != "" {
+		radius, err := strconv.Atoi(c.Query("radius"))
+		if err != nil || radius <= 0 || radius > service.MaxSearchRadiusM || req.Location == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "radius must be between 1 and 50000 meters and needs lat and lng"})
+			return
+		}
+		req.RadiusM = radius
+	}
 
-	results, err := h.Service.SearchPlaces(query)
+	results, err := h.Service.SearchPlaces(c.Request.Context(), req)
 	if err != nil {
 		// Log error internally
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -27,3 +58,102 @@ func (h *LocationHandler) Search(c *gin.Context) {
 
 	c.JSON(http.StatusOK, results)
 }
+
+// Nearby lists locations we already know around a point, so it also works
+// without calling the places provider
+func (h *LocationHandler) Nearby(c *gin.Context) {
+	lat, lng, ok := parseCoordinates(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lng must be valid coordinates"})
+		return
+	}
+	radius, limit := 0, 0
+	if c.Query("radius") != "" {
+		var err error
+		radius, err = strconv.Atoi(c.Query("radius"))
+		if err != nil || radius <= 0 || radius > service.MaxSearchRadiusM {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "radius must be between 1 and 50000 meters"})
+			return
+		}
+	}
+	if c.Query("limit") != "" {
+		var err error
+		limit, err = strconv.Atoi(c.Query("limit"))
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+			return
+		}
+	}
+
+	nearby, err := h.Service.Nearby(c.Request.Context(), lat, lng, radius, c.Query("type"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, nearby)
+}
+
+func (h *LocationHandler) Autocomplete(c *gin.Context) {
+	input := c.Query("input")
+	if input == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "input parameter is required"})
+		return
+	}
+
+	predictions, err := h.Service.Autocomplete(c.Request.Context(), input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, predictions)
+}
+
+func (h *LocationHandler) PlaceDetails(c *gin.Context) {
+	place, err := h.Service.PlaceDetails(c.Request.Context(), c.Param("placeId"))
+	if err != nil {
+		if errors.Is(err, places.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, place)
+}
+
+func (h *LocationHandler) ReverseGeocode(c *gin.Context) {
+	lat, lng, ok := parseCoordinates(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lng must be valid coordinates"})
+		return
+	}
+
+	results, err := h.Service.ReverseGeocode(c.Request.Context(), lat, lng)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// parseCoordinates reads the lat and lng query parameters
+func parseCoordinates(c *gin.Context) (lat, lng float64, ok bool) {
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.Query("lng"), 64)
+	if errLat != nil || errLng != nil || !(lat >= -90 && lat <= 90) || !(lng >= -180 && lng <= 180) {
+		return 0, 0, false
+	}
+	return lat, lng, true
+}
+
+// acceptLanguage returns the first language of the Accept-Language header,
+// e.g. "fr-CA" for "fr-CA,fr;q=0.9,en;q=0.8"
+func acceptLanguage(c *gin.Context) string {
+	first, _, _ := strings.Cut(c.GetHeader("Accept-Language"), ",")
+	tag, _, _ := strings.Cut(first, ";")
+	tag = strings.TrimSpace(tag)
+	if tag == "*" {
+		return ""
+	}
+	return tag
+}
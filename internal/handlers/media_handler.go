@@ -1,21 +1,45 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"path"
+	"strings"
+	"time"
 
 	"github.com/NoahFola/travel_app_backend/internal/service"
+	"github.com/NoahFola/travel_app_backend/internal/storage"
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	// maxBulkFiles caps the files of one bulk upload
+	maxBulkFiles = 100
+
+	// maxBulkUploadSize caps a bulk upload's body, each file also has its own limit
+	maxBulkUploadSize = 2 << 30
+)
+
 type MediaHandler struct {
 	Service *service.MediaService
 }
 
+// Upload stores a file on an activity, or on a trip (trip_id) to be placed
+// later
 func (h *MediaHandler) Upload(c *gin.Context) {
-	// 1. Get Activity ID
+	// Leave room for the rest of the form around the largest allowed file
+	if !parseUploadForm(c, service.MaxMediaSize+1<<20) {
+		return
+	}
+
+	// 1. Get Activity or Trip ID
 	activityID := c.PostForm("activity_id")
-	if activityID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "activity_id is required"})
+	tripID := c.PostForm("trip_id")
+	if activityID == "" && tripID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "activity_id or trip_id is required"})
 		return
 	}
 
@@ -27,11 +51,145 @@ func (h *MediaHandler) Upload(c *gin.Context) {
 	}
 
 	// 3. Upload
-	media, err := h.Service.UploadMedia(c.Request.Context(), file, activityID)
+	media, err := h.Service.UploadMedia(c.Request.Context(), file, activityID, tripID, c.GetString("userID"))
 	if err != nil {
+		if isUploadRejection(err) || errors.Is(err, service.ErrNotParticipant) {
+			respondMediaError(c, err)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, media)
 }
+
+// BulkUpload stores several files ("files" fields) on a trip without placing
+// them. Rejected files are listed in "failed" while the others are kept.
+func (h *MediaHandler) BulkUpload(c *gin.Context) {
+	if !parseUploadForm(c, maxBulkUploadSize) {
+		return
+	}
+	files := c.Request.MultipartForm.File["files"]
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "files are required"})
+		return
+	}
+	if len(files) > maxBulkFiles {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d files can be uploaded at once", maxBulkFiles)})
+		return
+	}
+
+	uploaded, failed, err := h.Service.BulkUpload(c.Request.Context(), files, c.Param("tripId"), c.GetString("userID"))
+	if err != nil {
+		respondMediaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"uploaded": uploaded, "failed": failed})
+}
+
+// ListTripMedia lists a trip's media, with ?unassigned=true only those not
+// placed on an activity yet
+func (h *MediaHandler) ListTripMedia(c *gin.Context) {
+	medias, err := h.Service.ListTripMedia(c.Request.Context(), c.Param("tripId"), c.GetString("userID"), c.Query("unassigned") == "true")
+	if err != nil {
+		respondMediaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, medias)
+}
+
+// AutoAssign places the trip's unassigned photos from their capture time
+// and position
+func (h *MediaHandler) AutoAssign(c *gin.Context) {
+	result, err := h.Service.AutoAssign(c.Request.Context(), c.Param("tripId"), c.GetString("userID"))
+	if err != nil {
+		respondMediaError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// parseUploadForm reads a multipart body of at most limit bytes, answering
+// the request itself when it can't
+func parseUploadForm(c *gin.Context, limit int64) bool {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	if err := c.Request.ParseMultipartForm(32 << 20); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondMediaError(c, fmt.Errorf("%w: uploads can be at most %d MB", service.ErrMediaTooLarge, limit>>20))
+			return false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expected a multipart/form-data body"})
+		return false
+	}
+	return true
+}
+
+// isUploadRejection tells files refused by validation from failures to store them
+func isUploadRejection(err error) bool {
+	return errors.Is(err, service.ErrInvalidMedia) ||
+		errors.Is(err, service.ErrUnsupportedMedia) ||
+		errors.Is(err, service.ErrMediaTooLarge)
+}
+
+// Download streams a media file to a participant of its trip. Range
+// requests are supported so videos can be seeked.
+func (h *MediaHandler) Download(c *gin.Context) {
+	media, file, err := h.Service.OpenMedia(c.Request.Context(), c.Param("id"), c.GetString("userID"))
+	if err != nil {
+		respondMediaError(c, err)
+		return
+	}
+	defer file.Close()
+
+	c.Header("Cache-Control", "private, no-cache")
+	if media.MimeType != "" {
+		c.Header("Content-Type", media.MimeType)
+	}
+	name := media.OriginalName
+	if name == "" {
+		name = path.Base(media.Key)
+	}
+	serveFile(c, name, file)
+}
+
+// ServeSigned serves a file through a signed link from a media URL. No login
+// is needed so links work in image tags and shared trip previews.
+func (h *MediaHandler) ServeSigned(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	file, err := h.Service.OpenSigned(c.Request.Context(), key, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		respondMediaError(c, err)
+		return
+	}
+	defer file.Close()
+
+	c.Header("Cache-Control", "private")
+	serveFile(c, path.Base(key), file)
+}
+
+// serveFile answers with the file content, handling Range and If-Range.
+// The content type comes from the name's extension unless already set.
+func serveFile(c *gin.Context, name string, file io.ReadSeeker) {
+	c.Header("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": name}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	http.ServeContent(c.Writer, c.Request, name, time.Time{}, file)
+}
+
+// respondMediaError answers 400, 415 and 413 for rejected uploads, 403 for
+// users outside the trip and bad links, 404 otherwise
+func respondMediaError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidMedia):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrUnsupportedMedia):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrMediaTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotParticipant), errors.Is(err, storage.ErrInvalidSignature):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	}
+}
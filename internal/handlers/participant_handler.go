@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ParticipantHandler struct {
+	Service *service.ParticipantService
+}
+
+type addParticipantRequest struct {
+	DisplayName string  `json:"display_name" binding:"required"`
+	UserID      *string `json:"user_id"` // optional, only the caller's own account
+}
+
+func (h *ParticipantHandler) AddParticipant(c *gin.Context) {
+	tripID := c.Param("tripId")
+	var req addParticipantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	participant := &domain.Participant{
+		TripID:      tripID,
+		UserID:      req.UserID,
+		DisplayName: req.DisplayName,
+	}
+
+	if err := h.Service.AddParticipant(c.Request.Context(), participant, c.GetString("userID")); err != nil {
+		respondParticipantError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, participant)
+}
+
+func (h *ParticipantHandler) ListParticipants(c *gin.Context) {
+	tripID := c.Param("tripId")
+	participants, err := h.Service.ListParticipants(c.Request.Context(), tripID, c.GetString("userID"))
+	if err != nil {
+		respondParticipantError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, participants)
+}
+
+func (h *ParticipantHandler) RemoveParticipant(c *gin.Context) {
+	tripID := c.Param("tripId")
+	id := c.Param("participantId")
+	if err := h.Service.RemoveParticipant(c.Request.Context(), tripID, id, c.GetString("userID")); err != nil {
+		respondParticipantError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "participant removed"})
+}
+
+func respondParticipantError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidParticipant):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotParticipant), errors.Is(err, service.ErrLinkOtherAccount):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAlreadyParticipant), errors.Is(err, service.ErrParticipantHasExpenses):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTripNotFound), errors.Is(err, service.ErrParticipantNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/repository"
+	"github.com/NoahFola/travel_app_backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type PollHandler struct {
+	Service *service.PollService
+}
+
+type createPollRequest struct {
+	Title       string     `json:"title" binding:"required"`
+	Description *string    `json:"description"`
+	Mode        string     `json:"mode"`         // updown (default) or rank
+	ClosesAt    *time.Time `json:"closes_at"`    // optional scheduled closing
+	ActivityIDs []string   `json:"activity_ids"` // existing activities to start with
+}
+
+type updatePollRequest struct {
+	Title       string     `json:"title"`
+	Description *string    `json:"description"`
+	ClosesAt    *time.Time `json:"closes_at"`
+}
+
+type proposeOptionRequest struct {
+	ActivityID  string  `json:"activity_id"` // an existing activity of the trip
+	Name        string  `json:"name"`        // or a new idea
+	Description *string `json:"description"`
+	Location    *string `json:"location"`
+	Type        *string `json:"type"`
+}
+
+type voteRequest struct {
+	Votes   []voteEntry `json:"votes"`   // updown polls
+	Ranking []string    `json:"ranking"` // rank polls, favourite first
+}
+
+type voteEntry struct {
+	OptionID string `json:"option_id" binding:"required"`
+	Value    int    `json:"value"` // 1 or -1
+}
+
+type promoteOptionRequest struct {
+	ItineraryID string `json:"itinerary_id" binding:"required"`
+	OptionID    string `json:"option_id"` // defaults to the winner
+	Position    *int   `json:"position"`  // 0-based index in the day, omit to append
+}
+
+func (h *PollHandler) CreatePoll(c *gin.Context) {
+	var req createPollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	poll := &domain.Poll{
+		TripID:      c.Param("tripId"),
+		Title:       req.Title,
+		Description: req.Description,
+		Mode:        req.Mode,
+		ClosesAt:    req.ClosesAt,
+	}
+	if err := h.Service.CreatePoll(c.Request.Context(), poll, c.GetString("userID"), req.ActivityIDs); err != nil {
+		respondPollError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, poll)
+}
+
+func (h *PollHandler) ListPolls(c *gin.Context) {
+	polls, err := h.Service.ListPolls(c.Request.Context(), c.Param("tripId"), c.GetString("userID"))
+	if err != nil {
+		respondPollError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, polls)
+}
+
+func (h *PollHandler) GetPoll(c *gin.Context) {
+	poll, err := h.Service.GetPoll(c.Request.Context(), c.Param("id"), c.GetString("userID"))
+	if err != nil {
+		respondPollError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, poll)
+}
+
+func (h *PollHandler) UpdatePoll(c *gin.Context) {
+	var req updatePollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetString("userID")
+	poll, err := h.Service.GetPoll(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		respondPollError(c, err)
+		return
+	}
+	if req.Title != "" {
+		poll.Title = req.Title
+	}
+	if req.Description != nil {
+		poll.Description = req.Description
+	}
+	if req.ClosesAt != nil {
+		poll.ClosesAt = req.ClosesAt
+	}
+
+	if err := h.Service.UpdatePoll(c.Request.Context(), poll, userID); err != nil {
+		respondPollError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, poll)
+}
+
+func (h *PollHandler) ClosePoll(c *gin.Context) {
+	poll, err := h.Service.ClosePoll(c.Request.Context(), c.Param("id"), c.GetString("userID"))
+	if err != nil {
+		respondPollError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, poll)
+}
+
+func (h *PollHandler) DeletePoll(c *gin.Context) {
+	if err := h.Service.DeletePoll(c.Request.Context(), c.Param("id"), c.GetString("userID")); err != nil {
+		respondPollError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "poll deleted"})
+}
+
+func (h *PollHandler) ProposeOption(c *gin.Context) {
+	var req proposeOptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var activity *domain.Activity
+	if req.ActivityID == "" {
+		activity = &domain.Activity{
+			Name:        req.Name,
+			Description: req.Description,
+			Location:    req.Location,
+			Type:        req.Type,
+		}
+	}
+	option, err := h.Service.Propose(c.Request.Context(), c.Param("id"), c.GetString("userID"), req.ActivityID, activity)
+	if err != nil {
+		respondPollError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, option)
+}
+
+func (h *PollHandler) RemoveOption(c *gin.Context) {
+	if err := h.Service.RemoveOption(c.Request.Context(), c.Param("id"), c.Param("optionId"), c.GetString("userID")); err != nil {
+		respondPollError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "option removed"})
+}
+
+// Vote replaces the caller's ballot
+func (h *PollHandler) Vote(c *gin.Context) {
+	var req voteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	votes := make([]domain.PollVote, len(req.Votes))
+	for i, v := range req.Votes {
+		votes[i] = domain.PollVote{OptionID: v.OptionID, Value: v.Value}
+	}
+	ballot, err := h.Service.Vote(c.Request.Context(), c.Param("id"), c.GetString("userID"), votes, req.Ranking)
+	if err != nil {
+		respondPollError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ballot": ballot})
+}
+
+// WithdrawVote removes the caller's ballot
+func (h *PollHandler) WithdrawVote(c *gin.Context) {
+	if _, err := h.Service.Vote(c.Request.Context(), c.Param("id"), c.GetString("userID"), nil, nil); err != nil {
+		respondPollError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "vote withdrawn"})
+}
+
+func (h *PollHandler) PromoteOption(c *gin.Context) {
+	var req promoteOptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	index := -1
+	if req.Position != nil {
+		index = *req.Position
+	}
+	poll, err := h.Service.Promote(c.Request.Context(), c.Param("id"), c.GetString("userID"), req.OptionID, req.ItineraryID, index)
+	if err != nil {
+		respondPollError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, poll)
+}
+
+// respondPollError answers 403 for non-participants and owner-only
+// actions, 400 for invalid input, 409 once the poll is closed and 404
+// otherwise
+func respondPollError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotParticipant), errors.Is(err, service.ErrNotTripOwner):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidPoll), errors.Is(err, service.ErrInvalidSchedule):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPollClosed), errors.Is(err, repository.ErrPollPromoted):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/money"
+	"github.com/NoahFola/travel_app_backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ReservationHandler struct {
+	Service *service.ReservationService
+}
+
+type createReservationRequest struct {
+	Type               string                     `json:"type"` // defaults to the activity's type
+	Provider           *string                    `json:"provider"`
+	ConfirmationNumber *string                    `json:"confirmation_number"`
+	StartsAt           *time.Time                 `json:"starts_at"`
+	EndsAt             *time.Time                 `json:"ends_at"`
+	Cost               *string                    `json:"cost"` // decimal string, e.g. "249.99"
+	Currency           *string                    `json:"currency"`
+	Notes              *string                    `json:"notes"`
+	Details            *domain.ReservationDetails `json:"details"`
+	AttachmentIDs      []string                   `json:"attachment_ids"` // media IDs
+}
+
+type updateReservationRequest struct {
+	Type               *string                    `json:"type"`
+	Provider           *string                    `json:"provider"`
+	ConfirmationNumber *string                    `json:"confirmation_number"`
+	StartsAt           *time.Time                 `json:"starts_at"`
+	EndsAt             *time.Time                 `json:"ends_at"`
+	Cost               *string                    `json:"cost"` // "" removes the cost
+	Currency           *string                    `json:"currency"`
+	Notes              *string                    `json:"notes"`
+	Details            *domain.ReservationDetails `json:"details"`        // replaces the details
+	AttachmentIDs      []string                   `json:"attachment_ids"` // replaces attachments when present
+}
+
+func (h *ReservationHandler) CreateReservation(c *gin.Context) {
+	activityID := c.Param("id")
+	var req createReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := &domain.Reservation{
+		ActivityID:         activityID,
+		Type:               req.Type,
+		Provider:           req.Provider,
+		ConfirmationNumber: req.ConfirmationNumber,
+		StartsAt:           req.StartsAt,
+		EndsAt:             req.EndsAt,
+		Currency:           req.Currency,
+		Notes:              req.Notes,
+	}
+	if req.Details != nil {
+		res.Details = *req.Details
+	}
+	if req.Cost != nil && *req.Cost != "" {
+		if err := setReservationCost(res, *req.Cost); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := h.Service.CreateReservation(c.Request.Context(), res, req.AttachmentIDs, c.GetString("userID")); err != nil {
+		respondReservationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, res)
+}
+
+func (h *ReservationHandler) ListActivityReservations(c *gin.Context) {
+	activityID := c.Param("id")
+	reservations, err := h.Service.ListByActivity(c.Request.Context(), activityID, c.GetString("userID"))
+	if err != nil {
+		respondReservationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reservations)
+}
+
+func (h *ReservationHandler) ListTripReservations(c *gin.Context) {
+	tripID := c.Param("tripId")
+	reservations, err := h.Service.ListByTrip(c.Request.Context(), tripID, c.GetString("userID"))
+	if err != nil {
+		respondReservationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reservations)
+}
+
+func (h *ReservationHandler) GetReservation(c *gin.Context) {
+	id := c.Param("id")
+	res, err := h.Service.GetReservation(c.Request.Context(), id, c.GetString("userID"))
+	if err != nil {
+		respondReservationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *ReservationHandler) UpdateReservation(c *gin.Context) {
+	id := c.Param("id")
+	var req updateReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, err := h.Service.GetReservation(c.Request.Context(), id, c.GetString("userID"))
+	if err != nil {
+		respondReservationError(c, err)
+		return
+	}
+
+	if req.Type != nil {
+		res.Type = *req.Type
+	}
+	if req.Provider != nil {
+		res.Provider = req.Provider
+	}
+	if req.ConfirmationNumber != nil {
+		res.ConfirmationNumber = req.ConfirmationNumber
+	}
+	if req.StartsAt != nil {
+		res.StartsAt = req.StartsAt
+	}
+	if req.EndsAt != nil {
+		res.EndsAt = req.EndsAt
+	}
+	if req.Currency != nil {
+		res.Currency = req.Currency
+	}
+	if req.Notes != nil {
+		res.Notes = req.Notes
+	}
+	if req.Details != nil {
+		res.Details = *req.Details
+	}
+	if req.Cost != nil {
+		res.CostMinor = nil
+		if *req.Cost != "" {
+			if err := setReservationCost(res, *req.Cost); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+	}
+
+	if err := h.Service.UpdateReservation(c.Request.Context(), res, req.AttachmentIDs); err != nil {
+		respondReservationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *ReservationHandler) DeleteReservation(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.Service.DeleteReservation(c.Request.Context(), id, c.GetString("userID")); err != nil {
+		respondReservationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "reservation deleted"})
+}
+
+// setReservationCost parses a decimal cost in the reservation's currency
+func setReservationCost(res *domain.Reservation, cost string) error {
+	if res.Currency == nil {
+		return errors.New("currency is required with a cost")
+	}
+	minor, err := money.Parse(cost, strings.ToUpper(*res.Currency))
+	if err != nil {
+		return err
+	}
+	res.CostMinor = &minor
+	return nil
+}
+
+// respondReservationError answers 400 for validation failures, 403 for
+// users outside the trip and 404 otherwise, which covers the missing
+// activity or reservation
+func respondReservationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidReservation):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotParticipant):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/NoahFola/travel_app_backend/internal/geo"
+	"github.com/NoahFola/travel_app_backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type RouteHandler struct {
+	Service *service.RouteService
+}
+
+// Start and end are optional; give either coordinates or a stored location
+type optimizeRouteRequest struct {
+	Start           *geo.Point `json:"start"`
+	StartLocationID *string    `json:"start_location_id"`
+	End             *geo.Point `json:"end"`
+	EndLocationID   *string    `json:"end_location_id"`
+	Apply           bool       `json:"apply"`
+}
+
+func (h *RouteHandler) OptimizeItinerary(c *gin.Context) {
+	itineraryID := c.Param("id")
+	var req optimizeRouteRequest
+	// An empty body is fine: optimise without start/end and don't apply
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	start, end := req.Start, req.End
+	var err error
+	if req.StartLocationID != nil {
+		if start, err = h.Service.ResolvePoint(c.Request.Context(), *req.StartLocationID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "start location: " + err.Error()})
+			return
+		}
+	}
+	if req.EndLocationID != nil {
+		if end, err = h.Service.ResolvePoint(c.Request.Context(), *req.EndLocationID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end location: " + err.Error()})
+			return
+		}
+	}
+
+	proposal, err := h.Service.OptimizeItinerary(c.Request.Context(), itineraryID, start, end, req.Apply)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, proposal)
+}
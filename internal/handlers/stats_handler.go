@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/NoahFola/travel_app_backend/internal/currency"
+	"github.com/NoahFola/travel_app_backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type StatsHandler struct {
+	Service *service.StatsService
+}
+
+// MyStats handles GET /users/me/stats[?year=2024][&currency=EUR]
+func (h *StatsHandler) MyStats(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var year *int
+	if y := c.Query("year"); y != "" {
+		parsed, err := strconv.Atoi(y)
+		if err != nil || parsed < 1900 || parsed > 9999 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "year must be a calendar year"})
+			return
+		}
+		year = &parsed
+	}
+
+	stats, err := h.Service.UserStats(c.Request.Context(), userID, year, c.Query("currency"))
+	if err != nil {
+		respondStatsError(c, err, http.StatusInternalServerError)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// TripStats handles GET /trips/:tripId/stats[?currency=EUR]
+func (h *StatsHandler) TripStats(c *gin.Context) {
+	tripID := c.Param("tripId")
+	stats, err := h.Service.TripStats(c.Request.Context(), tripID, c.Query("currency"))
+	if err != nil {
+		respondStatsError(c, err, http.StatusNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// respondStatsError reports a missing exchange rate as 422, anything else with status
+func respondStatsError(c *gin.Context, err error, status int) {
+	if errors.Is(err, currency.ErrRateNotFound) {
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
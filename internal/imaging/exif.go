@@ -0,0 +1,234 @@
+package imaging
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrNoExif is returned for files without readable EXIF data
+var ErrNoExif = errors.New("no EXIF data")
+
+// exifDateLayout is how EXIF writes dates, in the camera's wall clock
+const exifDateLayout = "2006:01:02 15:04:05"
+
+// EXIF tags read here
+const (
+	tagOrientation        = 0x0112
+	tagExifIFD            = 0x8769
+	tagGPSIFD             = 0x8825
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+	tagGPSLatitudeRef     = 0x0001
+	tagGPSLatitude        = 0x0002
+	tagGPSLongitudeRef    = 0x0003
+	tagGPSLongitude       = 0x0004
+)
+
+// Exif holds the EXIF fields of a photo used to place it in a trip
+type Exif struct {
+	DateTime    string // DateTimeOriginal, e.g. "2024:05:01 14:03:22"
+	Offset      string // OffsetTimeOriginal, e.g. "+02:00"; often missing
+	Latitude    float64
+	Longitude   float64
+	HasGPS      bool
+	Orientation int // 1-8, 0 when missing
+}
+
+// TakenLocal returns when the photo was taken on the camera's clock. The
+// time is in UTC but means the local time wherever the photo was taken.
+func (e *Exif) TakenLocal() (time.Time, bool) {
+	t, err := time.Parse(exifDateLayout, strings.TrimSpace(e.DateTime))
+	return t, err == nil
+}
+
+// TakenAt returns the exact time the photo was taken, which needs the UTC
+// offset the camera recorded
+func (e *Exif) TakenAt() (time.Time, bool) {
+	t, err := time.Parse(exifDateLayout+"-07:00", strings.TrimSpace(e.DateTime)+strings.TrimSpace(e.Offset))
+	return t, err == nil
+}
+
+// ReadExif reads the EXIF block of a JPEG file
+func ReadExif(r io.Reader) (*Exif, error) {
+	br := bufio.NewReader(r)
+	var soi [2]byte
+	if _, err := io.ReadFull(br, soi[:]); err != nil || soi != [2]byte{0xFF, 0xD8} {
+		return nil, ErrNoExif
+	}
+
+	// Walk the segments before the image data looking for APP1 "Exif"
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return nil, ErrNoExif
+		}
+		if b != 0xFF {
+			continue
+		}
+		marker, err := br.ReadByte()
+		if err != nil {
+			return nil, ErrNoExif
+		}
+		switch {
+		case marker == 0xFF || marker == 0x00:
+			br.UnreadByte() // fill byte or escaped 0xFF
+			continue
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			continue // no length
+		case marker == 0xDA || marker == 0xD9:
+			return nil, ErrNoExif // image data starts, EXIF would come before
+		}
+
+		var length [2]byte
+		if _, err := io.ReadFull(br, length[:]); err != nil {
+			return nil, ErrNoExif
+		}
+		size := int(binary.BigEndian.Uint16(length[:])) - 2
+		if size < 0 {
+			return nil, ErrNoExif
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return nil, ErrNoExif
+		}
+		if marker == 0xE1 && len(data) > 6 && string(data[:6]) == "Exif\x00\x00" {
+			return parseTIFF(data[6:])
+		}
+	}
+}
+
+// tiff reads IFD entries from the TIFF structure inside the EXIF block
+type tiff struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+type ifdEntry struct {
+	typ   uint16
+	count uint32
+	value []byte // the value bytes, inline or at their offset
+}
+
+func parseTIFF(data []byte) (*Exif, error) {
+	if len(data) < 8 {
+		return nil, ErrNoExif
+	}
+	t := &tiff{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, ErrNoExif
+	}
+	if t.order.Uint16(data[2:]) != 42 {
+		return nil, ErrNoExif
+	}
+
+	ifd0 := t.readIFD(t.order.Uint32(data[4:]))
+	e := &Exif{}
+	if v, ok := t.uint(ifd0[tagOrientation]); ok {
+		e.Orientation = int(v)
+	}
+	if offset, ok := t.uint(ifd0[tagExifIFD]); ok {
+		exif := t.readIFD(offset)
+		e.DateTime = t.ascii(exif[tagDateTimeOriginal])
+		e.Offset = t.ascii(exif[tagOffsetTimeOriginal])
+	}
+	if offset, ok := t.uint(ifd0[tagGPSIFD]); ok {
+		gps := t.readIFD(offset)
+		lat, okLat := t.degrees(gps[tagGPSLatitude])
+		lng, okLng := t.degrees(gps[tagGPSLongitude])
+		if okLat && okLng && !(lat == 0 && lng == 0) {
+			if t.ascii(gps[tagGPSLatitudeRef]) == "S" {
+				lat = -lat
+			}
+			if t.ascii(gps[tagGPSLongitudeRef]) == "W" {
+				lng = -lng
+			}
+			if lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180 {
+				e.Latitude, e.Longitude, e.HasGPS = lat, lng, true
+			}
+		}
+	}
+	return e, nil
+}
+
+// typeSizes are the byte sizes of the TIFF field types
+var typeSizes = map[uint16]uint32{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+
+// readIFD returns the entries of the IFD at offset, skipping broken ones
+func (t *tiff) readIFD(offset uint32) map[uint16]ifdEntry {
+	entries := map[uint16]ifdEntry{}
+	if uint64(offset)+2 > uint64(len(t.data)) {
+		return entries
+	}
+	n := int(t.order.Uint16(t.data[offset:]))
+	for i := 0; i < n; i++ {
+		at := uint64(offset) + 2 + uint64(i)*12
+		if at+12 > uint64(len(t.data)) {
+			break
+		}
+		raw := t.data[at : at+12]
+		e := ifdEntry{typ: t.order.Uint16(raw[2:]), count: t.order.Uint32(raw[4:])}
+		size, ok := typeSizes[e.typ]
+		if !ok {
+			continue
+		}
+		total := uint64(size) * uint64(e.count)
+		if total <= 4 {
+			e.value = raw[8 : 8+total]
+		} else {
+			start := uint64(t.order.Uint32(raw[8:]))
+			if start+total > uint64(len(t.data)) {
+				continue
+			}
+			e.value = t.data[start : start+total]
+		}
+		entries[t.order.Uint16(raw)] = e
+	}
+	return entries
+}
+
+// uint reads a SHORT or LONG value
+func (t *tiff) uint(e ifdEntry) (uint32, bool) {
+	switch {
+	case e.typ == 3 && len(e.value) >= 2:
+		return uint32(t.order.Uint16(e.value)), true
+	case e.typ == 4 && len(e.value) >= 4:
+		return t.order.Uint32(e.value), true
+	}
+	return 0, false
+}
+
+func (t *tiff) ascii(e ifdEntry) string {
+	if e.typ != 2 {
+		return ""
+	}
+	return strings.TrimRight(string(e.value), "\x00 ")
+}
+
+// degrees reads GPS degrees, minutes and seconds as three RATIONALs
+func (t *tiff) degrees(e ifdEntry) (float64, bool) {
+	if e.typ != 5 || len(e.value) < 24 {
+		return 0, false
+	}
+	var parts [3]float64
+	for i := range parts {
+		num := t.order.Uint32(e.value[i*8:])
+		den := t.order.Uint32(e.value[i*8+4:])
+		if den == 0 {
+			if num != 0 {
+				return 0, false
+			}
+			continue
+		}
+		parts[i] = float64(num) / float64(den)
+	}
+	return parts[0] + parts[1]/60 + parts[2]/3600, true
+}
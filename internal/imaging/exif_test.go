@@ -0,0 +1,236 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"math"
+	"testing"
+	"time"
+)
+
+// tag is an IFD entry to write into a test TIFF block
+type tag struct {
+	id, typ uint16
+	count   uint32
+	value   []byte
+}
+
+type tiffWriter struct {
+	order binary.AppendByteOrder
+}
+
+func (w tiffWriter) short(id, v uint16) tag {
+	return tag{id, 3, 1, w.order.AppendUint16(nil, v)}
+}
+
+// build lays out IFD0, then the Exif and GPS IFDs when given, then the
+// values that don't fit in their entries
+func (w tiffWriter) build(ifd0, exif, gps []tag) []byte {
+	ifd0 = append([]tag(nil), ifd0...)
+	ifds := [][]tag{nil}
+	pointers := map[int]int{} // index in ifd0 -> index in ifds
+	for _, sub := range []struct {
+		id   uint16
+		tags []tag
+	}{{tagExifIFD, exif}, {tagGPSIFD, gps}} {
+		if sub.tags != nil {
+			pointers[len(ifd0)] = len(ifds)
+			ifd0 = append(ifd0, tag{sub.id, 4, 1, nil})
+			ifds = append(ifds, sub.tags)
+		}
+	}
+	ifds[0] = ifd0
+
+	offsets := make([]uint32, len(ifds))
+	next := uint32(8)
+	for i, ifd := range ifds {
+		offsets[i] = next
+		next += 2 + 12*uint32(len(ifd)) + 4
+	}
+	for entry, ifd := range pointers {
+		ifd0[entry].value = w.order.AppendUint32(nil, offsets[ifd])
+	}
+
+	out := []byte("II")
+	if w.order == binary.BigEndian {
+		out = []byte("MM")
+	}
+	out = w.order.AppendUint16(out, 42)
+	out = w.order.AppendUint32(out, 8)
+	var data []byte
+	for _, ifd := range ifds {
+		out = w.order.AppendUint16(out, uint16(len(ifd)))
+		for _, t := range ifd {
+			out = w.order.AppendUint16(out, t.id)
+			out = w.order.AppendUint16(out, t.typ)
+			out = w.order.AppendUint32(out, t.count)
+			if len(t.value) <= 4 {
+				out = append(out, append(t.value, make([]byte, 4-len(t.value))...)...)
+			} else {
+				out = w.order.AppendUint32(out, next+uint32(len(data)))
+				data = append(data, t.value...)
+			}
+		}
+		out = w.order.AppendUint32(out, 0) // no next IFD
+	}
+	return append(out, data...)
+}
+
+// jpegWithExif wraps a TIFF block in the segments a camera JPEG starts with
+func jpegWithExif(tiff []byte) []byte {
+	segment := func(marker byte, payload []byte) []byte {
+		s := []byte{0xFF, marker}
+		s = binary.BigEndian.AppendUint16(s, uint16(len(payload)+2))
+		return append(s, payload...)
+	}
+	out := []byte{0xFF, 0xD8}
+	out = append(out, segment(0xE0, []byte("JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00"))...)
+	out = append(out, segment(0xE1, append([]byte("Exif\x00\x00"), tiff...))...)
+	out = append(out, segment(0xDA, []byte{0, 0, 0})...)
+	return append(out, 0xFF, 0xD9)
+}
+
+func TestReadExifOrientation(t *testing.T) {
+	for _, order := range []binary.AppendByteOrder{binary.LittleEndian, binary.BigEndian} {
+		w := tiffWriter{order}
+		e, err := ReadExif(bytes.NewReader(jpegWithExif(w.build([]tag{w.short(tagOrientation, 6)}, nil, nil))))
+		if err != nil {
+			t.Fatalf("%v: %v", order, err)
+		}
+		if e.Orientation != 6 {
+			t.Errorf("%v: got orientation %d, want 6", order, e.Orientation)
+		}
+	}
+
+	// A LONG works too, and a missing tag reads as 0
+	w := tiffWriter{binary.LittleEndian}
+	e, err := ReadExif(bytes.NewReader(jpegWithExif(w.build([]tag{{tagOrientation, 4, 1, w.order.AppendUint32(nil, 8)}}, nil, nil))))
+	if err != nil || e.Orientation != 8 {
+		t.Errorf("got %+v, %v", e, err)
+	}
+	e, err = ReadExif(bytes.NewReader(jpegWithExif(w.build(nil, nil, nil))))
+	if err != nil || e.Orientation != 0 {
+		t.Errorf("got %+v, %v", e, err)
+	}
+}
+
+func TestReadExifWithoutExif(t *testing.T) {
+	var plain bytes.Buffer
+	if err := jpeg.Encode(&plain, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	w := tiffWriter{binary.LittleEndian}
+	valid := jpegWithExif(w.build([]tag{w.short(tagOrientation, 3)}, nil, nil))
+	badMagic := bytes.Replace(append([]byte(nil), valid...), []byte("II*\x00"), []byte("II+\x00"), 1)
+
+	tests := map[string][]byte{
+		"JPEG without EXIF": plain.Bytes(),
+		"PNG":               []byte("\x89PNG\r\n\x1a\n"),
+		"empty":             nil,
+		"truncated":         valid[:30],
+		"not TIFF":          badMagic,
+	}
+	for name, data := range tests {
+		if _, err := ReadExif(bytes.NewReader(data)); !errors.Is(err, ErrNoExif) {
+			t.Errorf("%s: got %v, want ErrNoExif", name, err)
+		}
+	}
+}
+
+func TestReadExifSkipsBrokenEntries(t *testing.T) {
+	w := tiffWriter{binary.BigEndian}
+	tiff := w.build([]tag{
+		{0x010F, 2, 100, nil}, // Make, pointing past the end
+		{0x0110, 99, 1, nil},  // unknown type
+		w.short(tagOrientation, 3),
+	}, nil, nil)
+	// Point Make's value far outside the block
+	binary.BigEndian.PutUint32(tiff[10+8:], 1<<30)
+
+	e, err := ReadExif(bytes.NewReader(jpegWithExif(tiff)))
+	if err != nil || e.Orientation != 3 {
+		t.Errorf("got %+v, %v", e, err)
+	}
+}
+
+func (w tiffWriter) ascii(id uint16, s string) tag {
+	return tag{id, 2, uint32(len(s) + 1), append([]byte(s), 0)}
+}
+
+// rationals writes numerator, denominator pairs
+func (w tiffWriter) rationals(id uint16, v ...uint32) tag {
+	var value []byte
+	for _, n := range v {
+		value = w.order.AppendUint32(value, n)
+	}
+	return tag{id, 5, uint32(len(v) / 2), value}
+}
+
+func TestReadExifDateAndGPS(t *testing.T) {
+	for _, order := range []binary.AppendByteOrder{binary.LittleEndian, binary.BigEndian} {
+		w := tiffWriter{order}
+		// Belém Tower: 38°41'28.8"N 9°12'57.6"W
+		tiff := w.build(
+			[]tag{w.short(tagOrientation, 1)},
+			[]tag{w.ascii(tagDateTimeOriginal, "2024:05:01 14:03:22"), w.ascii(tagOffsetTimeOriginal, "+01:00")},
+			[]tag{
+				w.ascii(tagGPSLatitudeRef, "N"), w.rationals(tagGPSLatitude, 38, 1, 41, 1, 288, 10),
+				w.ascii(tagGPSLongitudeRef, "W"), w.rationals(tagGPSLongitude, 9, 1, 12, 1, 576, 10),
+			},
+		)
+		e, err := ReadExif(bytes.NewReader(jpegWithExif(tiff)))
+		if err != nil {
+			t.Fatalf("%v: %v", order, err)
+		}
+		if e.DateTime != "2024:05:01 14:03:22" || e.Offset != "+01:00" || e.Orientation != 1 {
+			t.Errorf("%v: got %+v", order, e)
+		}
+		if !e.HasGPS || math.Abs(e.Latitude-38.6913333) > 1e-6 || math.Abs(e.Longitude+9.216) > 1e-6 {
+			t.Errorf("%v: got position %v, %v", order, e.Latitude, e.Longitude)
+		}
+	}
+}
+
+func TestReadExifIgnoresBadGPS(t *testing.T) {
+	w := tiffWriter{binary.LittleEndian}
+	tests := map[string][]tag{
+		"null island":        {w.rationals(tagGPSLatitude, 0, 1, 0, 1, 0, 1), w.rationals(tagGPSLongitude, 0, 1, 0, 1, 0, 1)},
+		"no longitude":       {w.rationals(tagGPSLatitude, 38, 1, 0, 1, 0, 1)},
+		"zero denominator":   {w.rationals(tagGPSLatitude, 38, 0, 0, 1, 0, 1), w.rationals(tagGPSLongitude, 9, 1, 0, 1, 0, 1)},
+		"latitude over 90°":  {w.rationals(tagGPSLatitude, 91, 1, 0, 1, 0, 1), w.rationals(tagGPSLongitude, 9, 1, 0, 1, 0, 1)},
+		"ASCII for rational": {w.ascii(tagGPSLatitude, "38.69"), w.rationals(tagGPSLongitude, 9, 1, 0, 1, 0, 1)},
+	}
+	for name, gps := range tests {
+		e, err := ReadExif(bytes.NewReader(jpegWithExif(w.build(nil, nil, gps))))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if e.HasGPS {
+			t.Errorf("%s: got position %v, %v", name, e.Latitude, e.Longitude)
+		}
+	}
+}
+
+func TestTakenAt(t *testing.T) {
+	e := Exif{DateTime: "2024:05:01 14:03:22", Offset: "+09:00"}
+	local, ok := e.TakenLocal()
+	if !ok || !local.Equal(time.Date(2024, 5, 1, 14, 3, 22, 0, time.UTC)) {
+		t.Errorf("got wall clock %v, %v", local, ok)
+	}
+	at, ok := e.TakenAt()
+	if !ok || !at.Equal(time.Date(2024, 5, 1, 5, 3, 22, 0, time.UTC)) {
+		t.Errorf("got %v, %v", at, ok)
+	}
+
+	if _, ok := (&Exif{DateTime: "2024:05:01 14:03:22"}).TakenAt(); ok {
+		t.Error("got an exact time without an offset")
+	}
+	for _, broken := range []string{"", "    :  :     :  :  ", "2024-05-01 14:03:22"} {
+		if _, ok := (&Exif{DateTime: broken}).TakenLocal(); ok {
+			t.Errorf("parsed %q", broken)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// letters draws rows of labelled pixels, one gray level per letter
+func letters(rows ...string) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x, c := range row {
+			img.SetGray(x, y, color.Gray{Y: uint8(c)})
+		}
+	}
+	return img
+}
+
+// readLetters is the inverse of letters
+func readLetters(img image.Image) string {
+	b := img.Bounds()
+	var rows []string
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		var row strings.Builder
+		for x := b.Min.X; x < b.Max.X; x++ {
+			row.WriteByte(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+		}
+		rows = append(rows, row.String())
+	}
+	return strings.Join(rows, "/")
+}
+
+func TestOrient(t *testing.T) {
+	// How the sensor stored a photo that reads ABC/DEF upright, for each
+	// orientation
+	stored := map[int][]string{
+		1: {"ABC", "DEF"},
+		2: {"CBA", "FED"},
+		3: {"FED", "CBA"},
+		4: {"DEF", "ABC"},
+		5: {"AD", "BE", "CF"},
+		6: {"CF", "BE", "AD"},
+		7: {"FC", "EB", "DA"},
+		8: {"DA", "EB", "FC"},
+	}
+	for orientation, rows := range stored {
+		if got := readLetters(Orient(letters(rows...), orientation)); got != "ABC/DEF" {
+			t.Errorf("orientation %d: got %s, want ABC/DEF", orientation, got)
+		}
+	}
+
+	img := letters("AB")
+	for _, orientation := range []int{0, 1, 9} {
+		if Orient(img, orientation) != image.Image(img) {
+			t.Errorf("orientation %d changed the image", orientation)
+		}
+	}
+}
+
+func TestOrientSubImage(t *testing.T) {
+	img := letters("xxxx", "xABx", "xCDx").SubImage(image.Rect(1, 1, 3, 3))
+	if got := readLetters(Orient(img, 6)); got != "CA/DB" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		w, h, size   int
+		wantW, wantH int
+		ok           bool
+	}{
+		{4000, 3000, 1600, 1600, 1200, true},
+		{3000, 4000, 1600, 1200, 1600, true},
+		{5000, 2, 400, 400, 1, true},
+		{1600, 1200, 1600, 0, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := Fit(image.NewGray(image.Rect(0, 0, tt.w, tt.h)), tt.size)
+		if ok != tt.ok {
+			t.Errorf("%dx%d in %d: got ok %v", tt.w, tt.h, tt.size, ok)
+			continue
+		}
+		if ok && (got.Bounds().Dx() != tt.wantW || got.Bounds().Dy() != tt.wantH) {
+			t.Errorf("%dx%d in %d: got %v", tt.w, tt.h, tt.size, got.Bounds())
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, letters("AB", "CD")); err != nil {
+		t.Fatal(err)
+	}
+	img, err := Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readLetters(img); got != "AB/CD" {
+		t.Errorf("got %s", got)
+	}
+	if _, err := Decode(strings.NewReader("not an image")); err == nil {
+		t.Error("decoded garbage")
+	}
+}
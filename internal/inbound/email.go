@@ -0,0 +1,162 @@
+// Package inbound reads forwarded booking confirmation emails and extracts
+// the reservations described by their schema.org markup.
+package inbound
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// maxParts bounds how many MIME parts are walked, so a hostile message
+// can't make us recurse forever
+const maxParts = 100
+
+// Email is the part of an RFC 822 message we care about
+type Email struct {
+	From       string
+	Recipients []string // To, Cc and delivery headers, lower-cased addresses
+	Subject    string
+	Date       *time.Time
+	HTML       []string
+	Text       []string
+}
+
+var headerDecoder = &mime.WordDecoder{
+	// UTF-8 and Latin-1 cover confirmation emails; other charsets leave the header undecoded
+	CharsetReader: func(charset string, input io.Reader) (io.Reader, error) {
+		switch strings.ToLower(charset) {
+		case "utf-8", "us-ascii":
+			return input, nil
+		case "iso-8859-1", "latin1":
+			raw, err := io.ReadAll(input)
+			if err != nil {
+				return nil, err
+			}
+			runes := make([]rune, len(raw))
+			for i, b := range raw {
+				runes[i] = rune(b)
+			}
+			return strings.NewReader(string(runes)), nil
+		}
+		return nil, fmt.Errorf("unsupported charset %q", charset)
+	},
+}
+
+// ParseEmail reads a raw message and collects its HTML and plain text bodies
+func ParseEmail(r io.Reader) (*Email, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("not an RFC 822 message: %w", err)
+	}
+
+	e := &Email{
+		From:    decodeHeader(msg.Header.Get("From")),
+		Subject: decodeHeader(msg.Header.Get("Subject")),
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		e.Date = &date
+	}
+	for _, key := range []string{"X-Original-To", "Delivered-To", "To", "Cc"} {
+		for _, value := range msg.Header[key] {
+			addrs, err := mail.ParseAddressList(value)
+			if err != nil {
+				continue
+			}
+			for _, a := range addrs {
+				e.Recipients = append(e.Recipients, strings.ToLower(a.Address))
+			}
+		}
+	}
+
+	parts := 0
+	err = e.walk(msg.Header, msg.Body, &parts)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.HTML) == 0 && len(e.Text) == 0 {
+		return nil, errors.New("message has no text or HTML body")
+	}
+	return e, nil
+}
+
+func decodeHeader(value string) string {
+	decoded, err := headerDecoder.DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+// header is satisfied by both mail.Header and the headers of MIME parts
+type header interface {
+	Get(key string) string
+}
+
+func (e *Email) walk(h header, body io.Reader, parts *int) error {
+	*parts++
+	if *parts > maxParts {
+		return errors.New("message has too many parts")
+	}
+
+	contentType := h.Get("Content-Type")
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		// Unparseable parts are skipped rather than failing the whole message
+		return nil
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("reading MIME part: %w", err)
+			}
+			if err := e.walk(p.Header, p, parts); err != nil {
+				return err
+			}
+		}
+	}
+
+	if mediaType != "text/html" && mediaType != "text/plain" {
+		return nil
+	}
+	if disposition, _, _ := mime.ParseMediaType(h.Get("Content-Disposition")); disposition == "attachment" {
+		return nil
+	}
+
+	content, err := io.ReadAll(decodeTransfer(h.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return fmt.Errorf("decoding %s body: %w", mediaType, err)
+	}
+	if mediaType == "text/html" {
+		e.HTML = append(e.HTML, string(content))
+	} else {
+		e.Text = append(e.Text, string(content))
+	}
+	return nil
+}
+
+func decodeTransfer(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body) // line breaks are ignored
+	}
+	return body
+}
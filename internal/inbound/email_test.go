@@ -0,0 +1,97 @@
+package inbound
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseEmail(t *testing.T) {
+	raw := strings.ReplaceAll(`From: =?UTF-8?Q?Caf=C3=A9_Airlines?= <noreply@example.com>
+To: Trips <Trips+abc@inbound.example.com>
+Cc: friend@example.com
+Delivered-To: trips+abc@inbound.example.com
+Subject: =?ISO-8859-1?Q?Votre_r=E9servation?=
+Date: Wed, 01 May 2024 10:00:00 +0200
+MIME-Version: 1.0
+Content-Type: multipart/mixed; boundary="outer"
+
+--outer
+Content-Type: multipart/alternative; boundary="inner"
+
+--inner
+Content-Type: text/plain; charset=utf-8
+
+Your booking
+--inner
+Content-Type: text/html; charset=utf-8
+Content-Transfer-Encoding: quoted-printable
+
+<p class=3D"x">Your booking</p>
+--inner--
+--outer
+Content-Type: text/html
+Content-Disposition: attachment; filename="invoice.html"
+
+<p>attached</p>
+--outer
+Content-Type: application/pdf
+Content-Transfer-Encoding: base64
+
+JVBERi0=
+--outer--
+`, "\n", "\r\n")
+
+	e, err := ParseEmail(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.From != "Café Airlines <noreply@example.com>" || e.Subject != "Votre réservation" {
+		t.Errorf("got from %q, subject %q", e.From, e.Subject)
+	}
+	if e.Date == nil || e.Date.UTC().Hour() != 8 {
+		t.Errorf("got date %v", e.Date)
+	}
+	wantRecipients := []string{"trips+abc@inbound.example.com", "trips+abc@inbound.example.com", "friend@example.com"}
+	if !reflect.DeepEqual(e.Recipients, wantRecipients) {
+		t.Errorf("got recipients %v, want %v", e.Recipients, wantRecipients)
+	}
+	if !reflect.DeepEqual(e.Text, []string{"Your booking"}) || !reflect.DeepEqual(e.HTML, []string{`<p class="x">Your booking</p>`}) {
+		t.Errorf("got text %q, html %q", e.Text, e.HTML)
+	}
+}
+
+func TestParseEmailBase64Body(t *testing.T) {
+	raw := "From: a@example.com\r\nContent-Type: text/html\r\nContent-Transfer-Encoding: base64\r\n\r\nPHA+aGk8\r\nL3A+\r\n"
+	e, err := ParseEmail(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(e.HTML, []string{"<p>hi</p>"}) {
+		t.Errorf("got html %q", e.HTML)
+	}
+}
+
+func TestParseEmailErrors(t *testing.T) {
+	tests := map[string]string{
+		"not a message": "just some text without headers",
+		"no body part":  "From: a@example.com\r\nContent-Type: image/png\r\n\r\nxxx",
+	}
+	for name, raw := range tests {
+		if _, err := ParseEmail(strings.NewReader(raw)); err == nil {
+			t.Errorf("%s: parsed, want an error", name)
+		}
+	}
+}
+
+func TestParseEmailLimitsParts(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("Content-Type: multipart/mixed; boundary=b\r\n\r\n")
+	for i := 0; i <= maxParts; i++ {
+		sb.WriteString("--b\r\nContent-Type: text/plain\r\n\r\nx\r\n")
+	}
+	sb.WriteString("--b--\r\n")
+	if _, err := ParseEmail(strings.NewReader(sb.String())); err == nil {
+		t.Error("parsed a message with too many parts")
+	}
+}
@@ -0,0 +1,440 @@
+package inbound
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"golang.org/x/net/html"
+)
+
+// Fingerprint identifies a booking across repeated forwards of the same email
+func Fingerprint(b domain.ParsedBooking) string {
+	start := ""
+	if b.StartsAt != nil {
+		start = b.StartsAt.UTC().Format(time.RFC3339)
+	}
+	return strings.Join([]string{b.Kind, strings.ToUpper(b.ConfirmationNumber), start, strings.ToLower(b.Name)}, "|")
+}
+
+// ExtractBookings finds flight, hotel and car rental reservations in the
+// schema.org JSON-LD and microdata of an HTML email body
+func ExtractBookings(body string) []domain.ParsedBooking {
+	doc, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		return nil
+	}
+
+	var items []any
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if n.Data == "script" && strings.EqualFold(strings.TrimSpace(attr(n, "type")), "application/ld+json") {
+				var v any
+				if err := json.Unmarshal([]byte(textContent(n)), &v); err == nil {
+					items = append(items, v)
+				}
+				return
+			}
+			if hasAttr(n, "itemscope") && attr(n, "itemtype") != "" && !hasAttr(n, "itemprop") {
+				items = append(items, readItem(n))
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(doc)
+
+	var bookings []domain.ParsedBooking
+	seen := make(map[string]bool)
+	var collect func(v any)
+	collect = func(v any) {
+		switch v := v.(type) {
+		case []any:
+			for _, x := range v {
+				collect(x)
+			}
+		case map[string]any:
+			if graph, ok := v["@graph"]; ok {
+				collect(graph)
+			}
+			b, ok := toBooking(v)
+			if !ok {
+				return
+			}
+			if key := Fingerprint(b); !seen[key] {
+				seen[key] = true
+				bookings = append(bookings, b)
+			}
+		}
+	}
+	for _, item := range items {
+		collect(item)
+	}
+	return bookings
+}
+
+func toBooking(res map[string]any) (domain.ParsedBooking, bool) {
+	var b domain.ParsedBooking
+	switch schemaType(res) {
+	case "FlightReservation":
+		b = flightBooking(res)
+	case "LodgingReservation":
+		b = hotelBooking(res)
+	case "RentalCarReservation":
+		b = carBooking(res)
+	default:
+		return b, false
+	}
+
+	b.ConfirmationNumber = text(res, "reservationNumber")
+	if b.ConfirmationNumber == "" {
+		b.ConfirmationNumber = text(res, "confirmationNumber")
+	}
+	if b.Provider == "" {
+		b.Provider = firstText(text(res, "provider"), text(res, "broker"))
+	}
+	b.Cost, b.Currency = price(res)
+	return b, b.StartsAt != nil
+}
+
+func flightBooking(res map[string]any) domain.ParsedBooking {
+	flight := field(res, "reservationFor")
+	airline := text(flight, "airline", "iataCode")
+	number := strings.ReplaceAll(text(flight, "flightNumber"), " ", "")
+	if airline != "" && number != "" && !strings.HasPrefix(strings.ToUpper(number), strings.ToUpper(airline)) {
+		number = airline + number
+	}
+
+	details := &domain.FlightDetails{
+		FlightNumber:      number,
+		DepartureAirport:  text(flight, "departureAirport", "iataCode"),
+		ArrivalAirport:    text(flight, "arrivalAirport", "iataCode"),
+		DepartureTerminal: optional(text(flight, "departureTerminal")),
+		ArrivalTerminal:   optional(text(flight, "arrivalTerminal")),
+		Gate:              optional(text(flight, "departureGate")),
+		Seat:              optional(firstText(text(res, "airplaneSeat"), text(res, "reservedTicket", "ticketedSeat", "seatNumber"))),
+		Cabin:             cabin(firstText(text(res, "airplaneSeatClass"), text(res, "reservedTicket", "ticketedSeat", "seatingType"))),
+	}
+	if boarding, _ := parseTime(firstText(text(flight, "boardingTime"), text(res, "boardingTime"))); boarding != nil {
+		details.BoardingTime = boarding
+	}
+
+	b := domain.ParsedBooking{
+		Kind:     domain.ReservationFlight,
+		Name:     strings.TrimSpace(fmt.Sprintf("Flight %s %s → %s", number, details.DepartureAirport, details.ArrivalAirport)),
+		Provider: text(flight, "airline"),
+		Location: firstText(text(flight, "departureAirport"), details.DepartureAirport),
+		Details:  domain.ReservationDetails{Flight: details},
+	}
+	b.StartsAt, _ = parseTime(text(flight, "departureTime"))
+	b.EndsAt, _ = parseTime(text(flight, "arrivalTime"))
+	return b
+}
+
+func hotelBooking(res map[string]any) domain.ParsedBooking {
+	lodging := field(res, "reservationFor")
+	name := text(lodging, "name")
+	address := postalAddress(field(lodging, "address"))
+
+	details := &domain.HotelDetails{
+		Address:  optional(address),
+		RoomType: optional(firstText(text(res, "lodgingUnitDescription"), text(res, "lodgingUnitType"))),
+	}
+	if adults, err := strconv.Atoi(text(res, "numAdults")); err == nil && adults > 0 {
+		details.Guests = adults
+	}
+
+	b := domain.ParsedBooking{
+		Kind:     domain.ReservationHotel,
+		Name:     firstText(name, "Hotel stay"),
+		Provider: firstText(text(lodging, "brand"), name),
+		Location: firstText(address, name),
+		Details:  domain.ReservationDetails{Hotel: details},
+	}
+	b.StartsAt, b.AllDay = parseTime(firstText(text(res, "checkinTime"), text(res, "checkinDate")))
+	b.EndsAt, _ = parseTime(firstText(text(res, "checkoutTime"), text(res, "checkoutDate")))
+	return b
+}
+
+func carBooking(res map[string]any) domain.ParsedBooking {
+	car := field(res, "reservationFor")
+	company := firstText(text(car, "rentalCompany"), text(res, "provider"))
+	pickup := field(res, "pickupLocation")
+	dropoff := field(res, "dropoffLocation")
+	pickupPlace := firstText(joinNonEmpty(text(pickup, "name"), postalAddress(field(pickup, "address"))), text(pickup))
+	dropoffPlace := firstText(joinNonEmpty(text(dropoff, "name"), postalAddress(field(dropoff, "address"))), text(dropoff))
+
+	details := &domain.CarDetails{
+		PickupLocation: pickupPlace,
+		CarClass:       optional(firstText(text(car, "name"), text(car, "model"))),
+	}
+	if dropoffPlace != "" && dropoffPlace != pickupPlace {
+		details.DropoffLocation = &dropoffPlace
+	}
+
+	b := domain.ParsedBooking{
+		Kind:     domain.ReservationCar,
+		Name:     strings.TrimSpace("Car rental " + company),
+		Provider: company,
+		Location: pickupPlace,
+		Details:  domain.ReservationDetails{Car: details},
+	}
+	b.StartsAt, b.AllDay = parseTime(text(res, "pickupTime"))
+	b.EndsAt, _ = parseTime(text(res, "dropoffTime"))
+	return b
+}
+
+// price reads totalPrice/price, which may be a number or a PriceSpecification
+func price(res map[string]any) (string, string) {
+	for _, key := range []string{"totalPrice", "price"} {
+		v, ok := res[key]
+		if !ok {
+			continue
+		}
+		if spec, ok := v.(map[string]any); ok {
+			return firstText(text(spec, "price"), text(spec, "value")), firstText(text(spec, "priceCurrency"), text(res, "priceCurrency"))
+		}
+		return text(res, key), text(res, "priceCurrency")
+	}
+	return "", ""
+}
+
+func cabin(class string) *string {
+	class = strings.ToLower(class)
+	var c string
+	switch {
+	case class == "":
+		return nil
+	case strings.Contains(class, "premium"):
+		c = "premium_economy"
+	case strings.Contains(class, "business"):
+		c = "business"
+	case strings.Contains(class, "first"):
+		c = "first"
+	case strings.Contains(class, "economy"), strings.Contains(class, "coach"):
+		c = "economy"
+	default:
+		return nil
+	}
+	return &c
+}
+
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// parseTime reads a schema.org DateTime or Date. Times without an offset
+// are kept as wall-clock UTC. allDay reports a bare date.
+func parseTime(s string) (t *time.Time, allDay bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, false
+	}
+	for _, layout := range timeLayouts {
+		if parsed, err := time.Parse(layout, s); err == nil {
+			return &parsed, false
+		}
+	}
+	if parsed, err := time.Parse("2006-01-02", s); err == nil {
+		return &parsed, true
+	}
+	return nil, false
+}
+
+func postalAddress(v any) string {
+	switch a := v.(type) {
+	case string:
+		return strings.TrimSpace(a)
+	case map[string]any:
+		return joinNonEmpty(
+			text(a, "streetAddress"),
+			joinWords(text(a, "postalCode"), text(a, "addressLocality")),
+			text(a, "addressRegion"),
+			text(a, "addressCountry"),
+		)
+	}
+	return ""
+}
+
+// schemaType returns the first schema.org type of an item, without its URL prefix
+func schemaType(item map[string]any) string {
+	var t string
+	switch v := item["@type"].(type) {
+	case string:
+		t = v
+	case []any:
+		if len(v) > 0 {
+			t, _ = v[0].(string)
+		}
+	}
+	t = strings.TrimPrefix(t, "http://schema.org/")
+	t = strings.TrimPrefix(t, "https://schema.org/")
+	return t
+}
+
+// field follows path through nested objects, taking the first element of arrays
+func field(v any, path ...string) any {
+	for _, key := range path {
+		if arr, ok := v.([]any); ok {
+			if len(arr) == 0 {
+				return nil
+			}
+			v = arr[0]
+		}
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	if arr, ok := v.([]any); ok && len(arr) > 0 {
+		return arr[0]
+	}
+	return v
+}
+
+// text returns the value at path as a string. Objects yield their name.
+func text(v any, path ...string) string {
+	switch v := field(v, path...).(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case map[string]any:
+		if name, ok := v["name"].(string); ok {
+			return strings.TrimSpace(name)
+		}
+	}
+	return ""
+}
+
+func firstText(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func joinNonEmpty(values ...string) string {
+	var kept []string
+	for _, v := range values {
+		if v != "" {
+			kept = append(kept, v)
+		}
+	}
+	return strings.Join(kept, ", ")
+}
+
+func joinWords(values ...string) string {
+	return strings.TrimSpace(strings.Join(values, " "))
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// readItem turns a microdata itemscope into the same shape as JSON-LD.
+// Nested items may leave out itemtype and get no @type.
+func readItem(n *html.Node) map[string]any {
+	item := map[string]any{}
+	if types := strings.Fields(attr(n, "itemtype")); len(types) > 0 {
+		item["@type"] = types[0]
+	}
+
+	var visit func(c *html.Node)
+	visit = func(c *html.Node) {
+		for ; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			props := strings.Fields(attr(c, "itemprop"))
+			if len(props) > 0 {
+				var value any
+				if hasAttr(c, "itemscope") {
+					value = readItem(c)
+				} else {
+					value = propValue(c)
+				}
+				for _, p := range props {
+					if _, exists := item[p]; !exists {
+						item[p] = value
+					}
+				}
+				if hasAttr(c, "itemscope") {
+					continue
+				}
+			}
+			visit(c.FirstChild)
+		}
+	}
+	visit(n.FirstChild)
+	return item
+}
+
+func propValue(n *html.Node) string {
+	if hasAttr(n, "content") {
+		return attr(n, "content")
+	}
+	switch n.Data {
+	case "a", "link", "area":
+		return attr(n, "href")
+	case "img", "audio", "video", "source", "iframe", "embed":
+		return attr(n, "src")
+	case "time":
+		if hasAttr(n, "datetime") {
+			return attr(n, "datetime")
+		}
+	case "data", "meter":
+		return attr(n, "value")
+	}
+	return textContent(n)
+}
+
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	var visit func(c *html.Node)
+	visit = func(c *html.Node) {
+		if c.Type == html.TextNode {
+			sb.WriteString(c.Data)
+		}
+		for child := c.FirstChild; child != nil; child = child.NextSibling {
+			visit(child)
+		}
+	}
+	visit(n)
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,178 @@
+package inbound
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+)
+
+func TestExtractBookingsMicrodataWithoutItemtype(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{
+			name: "nested item without itemtype",
+			body: `<div itemscope itemtype="http://schema.org/FlightReservation">
+				<meta itemprop="reservationNumber" content="ABC123">
+				<div itemprop="reservationFor" itemscope>
+					<meta itemprop="flightNumber" content="123">
+					<div itemprop="airline" itemscope itemtype="http://schema.org/Airline">
+						<meta itemprop="iataCode" content="LH">
+					</div>
+					<meta itemprop="departureTime" content="2024-05-01T09:30:00+02:00">
+				</div>
+			</div>`,
+		},
+		{
+			name: "nested item with blank itemtype",
+			body: `<div itemscope itemtype="http://schema.org/FlightReservation">
+				<meta itemprop="reservationNumber" content="ABC123">
+				<div itemprop="reservationFor" itemscope itemtype="  ">
+					<meta itemprop="flightNumber" content="123">
+					<div itemprop="airline" itemscope itemtype="http://schema.org/Airline">
+						<meta itemprop="iataCode" content="LH">
+					</div>
+					<meta itemprop="departureTime" content="2024-05-01T09:30:00+02:00">
+				</div>
+			</div>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bookings := ExtractBookings(tt.body)
+			if len(bookings) != 1 {
+				t.Fatalf("got %d bookings, want 1", len(bookings))
+			}
+			b := bookings[0]
+			if b.Kind != domain.ReservationFlight || b.ConfirmationNumber != "ABC123" {
+				t.Errorf("got kind %q, confirmation %q", b.Kind, b.ConfirmationNumber)
+			}
+			if b.Details.Flight == nil || b.Details.Flight.FlightNumber != "LH123" {
+				t.Errorf("got flight details %+v, want flight number LH123", b.Details.Flight)
+			}
+			want := time.Date(2024, 5, 1, 7, 30, 0, 0, time.UTC)
+			if b.StartsAt == nil || !b.StartsAt.Equal(want) {
+				t.Errorf("got start %v, want %v", b.StartsAt, want)
+			}
+		})
+	}
+}
+
+func TestExtractBookingsBlankTopLevelItemtype(t *testing.T) {
+	body := `<div itemscope itemtype=" "><meta itemprop="name" content="x"></div>`
+	if bookings := ExtractBookings(body); len(bookings) != 0 {
+		t.Errorf("got %d bookings, want none", len(bookings))
+	}
+}
+
+func TestExtractBookingsJSONLD(t *testing.T) {
+	body := `<html><head>
+<script type="application/ld+json">
+{
+  "@context": "http://schema.org",
+  "@graph": [
+    {
+      "@type": "FlightReservation",
+      "reservationNumber": "RXJ34P",
+      "airplaneSeat": "14C",
+      "airplaneSeatClass": "Premium Economy",
+      "reservationFor": {
+        "@type": "Flight",
+        "flightNumber": "117",
+        "airline": {"@type": "Airline", "name": "British Airways", "iataCode": "BA"},
+        "departureAirport": {"@type": "Airport", "name": "London Heathrow", "iataCode": "LHR"},
+        "departureTime": "2024-05-01T08:25:00+01:00",
+        "arrivalAirport": {"@type": "Airport", "name": "John F. Kennedy", "iataCode": "JFK"},
+        "arrivalTime": "2024-05-01T11:05:00-04:00"
+      },
+      "totalPrice": "549.30",
+      "priceCurrency": "GBP"
+    },
+    {
+      "@type": "LodgingReservation",
+      "reservationNumber": "H-9981",
+      "checkinDate": "2024-05-01",
+      "checkoutDate": "2024-05-04",
+      "numAdults": 2,
+      "reservationFor": {
+        "@type": "LodgingBusiness",
+        "name": "The Standard",
+        "address": {"streetAddress": "848 Washington St", "postalCode": "10014", "addressLocality": "New York", "addressCountry": "US"}
+      },
+      "totalPrice": {"@type": "PriceSpecification", "price": 812.5, "priceCurrency": "USD"}
+    }
+  ]
+}
+</script>
+<script type="application/ld+json">
+[{"@type": ["https://schema.org/RentalCarReservation"], "reservationNumber": "CAR1",
+  "reservationFor": {"rentalCompany": {"name": "Hertz"}, "name": "Compact"},
+  "pickupLocation": {"name": "JFK Airport"}, "pickupTime": "2024-05-04T10:00:00",
+  "dropoffLocation": {"name": "JFK Airport"}, "dropoffTime": "2024-05-06T10:00:00"},
+ {"@type": "EventReservation", "reservationNumber": "IGNORED", "startDate": "2024-05-02"}]
+</script>
+<script type="application/ld+json">{ not json </script>
+</head><body>Your trip</body></html>`
+
+	bookings := ExtractBookings(body)
+	if len(bookings) != 3 {
+		t.Fatalf("got %d bookings, want 3: %+v", len(bookings), bookings)
+	}
+
+	flight := bookings[0]
+	if flight.Kind != domain.ReservationFlight || flight.Name != "Flight BA117 LHR → JFK" || flight.Provider != "British Airways" ||
+		flight.ConfirmationNumber != "RXJ34P" || flight.Cost != "549.30" || flight.Currency != "GBP" || flight.Location != "London Heathrow" {
+		t.Errorf("got flight %+v", flight)
+	}
+	if d := flight.Details.Flight; d == nil || d.FlightNumber != "BA117" || *d.Seat != "14C" || *d.Cabin != "premium_economy" {
+		t.Errorf("got flight details %+v", flight.Details.Flight)
+	}
+	if !flight.StartsAt.Equal(time.Date(2024, 5, 1, 7, 25, 0, 0, time.UTC)) || !flight.EndsAt.Equal(time.Date(2024, 5, 1, 15, 5, 0, 0, time.UTC)) {
+		t.Errorf("got flight times %v to %v", flight.StartsAt, flight.EndsAt)
+	}
+
+	hotel := bookings[1]
+	if hotel.Kind != domain.ReservationHotel || hotel.Name != "The Standard" || !hotel.AllDay || hotel.Cost != "812.5" || hotel.Currency != "USD" {
+		t.Errorf("got hotel %+v", hotel)
+	}
+	if d := hotel.Details.Hotel; d == nil || *d.Address != "848 Washington St, 10014 New York, US" || d.Guests != 2 {
+		t.Errorf("got hotel details %+v", hotel.Details.Hotel)
+	}
+
+	car := bookings[2]
+	if car.Kind != domain.ReservationCar || car.Provider != "Hertz" || car.Location != "JFK Airport" || car.AllDay {
+		t.Errorf("got car %+v", car)
+	}
+	if d := car.Details.Car; d == nil || d.DropoffLocation != nil || *d.CarClass != "Compact" {
+		t.Errorf("got car details %+v", car.Details.Car)
+	}
+}
+
+func TestExtractBookingsSkipsDuplicatesAndUndated(t *testing.T) {
+	reservation := `{"@type": "FlightReservation", "reservationNumber": "abc123",
+		"reservationFor": {"flightNumber": "LH 400", "departureTime": "2024-05-01T10:00:00Z"}}`
+	undated := `{"@type": "FlightReservation", "reservationNumber": "X", "reservationFor": {"flightNumber": "LH1"}}`
+	body := `<script type="application/ld+json">` + reservation + `</script>` +
+		`<script type="application/ld+json">` + reservation + `</script>` +
+		`<script type="application/ld+json">` + undated + `</script>`
+
+	bookings := ExtractBookings(body)
+	if len(bookings) != 1 {
+		t.Fatalf("got %d bookings, want 1", len(bookings))
+	}
+	if got := bookings[0].Details.Flight.FlightNumber; got != "LH400" {
+		t.Errorf("got flight number %q, want LH400", got)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	a := domain.ParsedBooking{Kind: domain.ReservationFlight, ConfirmationNumber: "abc123", Name: "Flight LH400", StartsAt: &start}
+	utc := start.UTC()
+	b := domain.ParsedBooking{Kind: domain.ReservationFlight, ConfirmationNumber: "ABC123", Name: "flight lh400", StartsAt: &utc}
+	if Fingerprint(a) != Fingerprint(b) {
+		t.Errorf("%q and %q differ", Fingerprint(a), Fingerprint(b))
+	}
+}
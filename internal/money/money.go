@@ -0,0 +1,129 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Amounts are stored as integers in the currency's minor unit (e.g. cents)
+// so that sums and splits never go through floating point.
+
+// minorUnits lists currencies whose minor unit is not 2 decimal places.
+var minorUnits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0,
+	"XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// MinorUnits returns the number of decimal places used by a currency
+func MinorUnits(currency string) int {
+	if d, ok := minorUnits[strings.ToUpper(currency)]; ok {
+		return d
+	}
+	return 2
+}
+
+// ValidCurrency reports whether code looks like an ISO 4217 code
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Parse converts a decimal string such as "12.50" into minor units
+func Parse(s, currency string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, errors.New("amount is required")
+	}
+
+	negative := false
+	if s[0] == '-' || s[0] == '+' {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" {
+		whole = "0"
+	}
+	digits := MinorUnits(currency)
+	if len(frac) > digits {
+		return 0, fmt.Errorf("amount %q has more than %d decimal places", s, digits)
+	}
+	frac += strings.Repeat("0", digits-len(frac))
+
+	for _, r := range whole + frac {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("invalid amount %q", s)
+		}
+	}
+
+	v, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if negative {
+		v = -v
+	}
+	return v, nil
+}
+
+// Format renders minor units as a decimal string, e.g. 1250 -> "12.50"
+func Format(minor int64, currency string) string {
+	digits := MinorUnits(currency)
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	s := strconv.FormatInt(minor, 10)
+	if digits == 0 {
+		return sign + s
+	}
+	if len(s) <= digits {
+		s = strings.Repeat("0", digits-len(s)+1) + s
+	}
+	return sign + s[:len(s)-digits] + "." + s[len(s)-digits:]
+}
+
+// Allocate splits total proportionally to weights. Remainders are handed out
+// one minor unit at a time, in order, so the parts always add up to total.
+func Allocate(total int64, weights []int64) ([]int64, error) {
+	var sum int64
+	for _, w := range weights {
+		if w <= 0 {
+			return nil, errors.New("weights must be positive")
+		}
+		sum += w
+	}
+	if sum == 0 {
+		return nil, errors.New("nothing to allocate to")
+	}
+
+	parts := make([]int64, len(weights))
+	var allocated int64
+	for i, w := range weights {
+		parts[i] = total * w / sum
+		allocated += parts[i]
+	}
+
+	step := int64(1)
+	if total < 0 {
+		step = -1
+	}
+	for i := 0; allocated != total; i = (i + 1) % len(parts) {
+		parts[i] += step
+		allocated += step
+	}
+	return parts, nil
+}
@@ -0,0 +1,114 @@
+package money
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in       string
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{"12.50", "EUR", 1250, false},
+		{"12.5", "EUR", 1250, false},
+		{"12", "EUR", 1200, false},
+		{".99", "USD", 99, false},
+		{" -3.07 ", "USD", -307, false},
+		{"+1", "USD", 100, false},
+		{"1500", "JPY", 1500, false},
+		{"1.5", "JPY", 0, true},
+		{"1.234", "KWD", 1234, false},
+		{"1.001", "EUR", 0, true},
+		{"", "EUR", 0, true},
+		{"1,50", "EUR", 0, true},
+		{"abc", "EUR", 0, true},
+		{"1.-5", "EUR", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in, tt.currency)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q, %s) = %d, want an error", tt.in, tt.currency, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q, %s) = %d, %v; want %d", tt.in, tt.currency, got, err, tt.want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		minor    int64
+		currency string
+		want     string
+	}{
+		{1250, "EUR", "12.50"},
+		{5, "EUR", "0.05"},
+		{0, "EUR", "0.00"},
+		{-307, "USD", "-3.07"},
+		{1500, "JPY", "1500"},
+		{1234, "KWD", "1.234"},
+		{7, "kwd", "0.007"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.minor, tt.currency); got != tt.want {
+			t.Errorf("Format(%d, %s) = %q, want %q", tt.minor, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int64
+		weights []int64
+		want    []int64
+	}{
+		{"even", 900, []int64{1, 1, 1}, []int64{300, 300, 300}},
+		{"remainder goes to the first parts", 1000, []int64{1, 1, 1}, []int64{334, 333, 333}},
+		{"weighted", 1000, []int64{2, 1, 1}, []int64{500, 250, 250}},
+		{"weighted with remainder", 100, []int64{1, 2}, []int64{34, 66}},
+		{"negative total", -1000, []int64{1, 1, 1}, []int64{-334, -333, -333}},
+		{"zero total", 0, []int64{1, 3}, []int64{0, 0}},
+		{"fewer units than parts", 2, []int64{1, 1, 1}, []int64{1, 1, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Allocate(tt.total, tt.weights)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			var sum int64
+			for _, p := range got {
+				sum += p
+			}
+			if sum != tt.total {
+				t.Errorf("parts add up to %d, want %d", sum, tt.total)
+			}
+		})
+	}
+}
+
+func TestAllocateRejectsBadWeights(t *testing.T) {
+	for _, weights := range [][]int64{nil, {}, {1, 0}, {2, -1}} {
+		if _, err := Allocate(100, weights); err == nil {
+			t.Errorf("Allocate(100, %v) succeeded, want an error", weights)
+		}
+	}
+}
+
+func TestValidCurrency(t *testing.T) {
+	for code, want := range map[string]bool{"EUR": true, "usd": false, "EU": false, "EURO": false, "E1R": false} {
+		if got := ValidCurrency(code); got != want {
+			t.Errorf("ValidCurrency(%q) = %v, want %v", code, got, want)
+		}
+	}
+}
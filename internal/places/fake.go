@@ -0,0 +1,145 @@
+package places
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/NoahFola/travel_app_backend/internal/geo"
+)
+
+//go:embed fixtures/places.json
+var defaultFixtures []byte
+
+// maxReverseGeocodeKm is how far a fixture may be from the coordinate
+const maxReverseGeocodeKm = 5
+
+// Fake answers from a fixed list of places, without network access
+type Fake struct {
+	Places []Place
+}
+
+// NewFake returns a provider with the built-in fixtures: landmarks in
+// Paris, London, Lisbon, Tokyo and New York
+func NewFake() *Fake {
+	f, err := parseFixtures(defaultFixtures)
+	if err != nil {
+		panic("places: invalid built-in fixtures: " + err.Error())
+	}
+	return f
+}
+
+// LoadFake reads fixtures from a JSON file holding an array of places
+func LoadFake(path string) (*Fake, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseFixtures(data)
+}
+
+func parseFixtures(data []byte) (*Fake, error) {
+	var list []Place
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	return &Fake{Places: list}, nil
+}
+
+// Search returns places whose name or address contains every word of the
+// query and that have the requested type. With a location, results are
+// ordered by distance and limited to the radius.
+func (f *Fake) Search(ctx context.Context, req SearchRequest) ([]Place, error) {
+	words := strings.Fields(strings.ToLower(req.Query))
+	results := []Place{}
+	for _, p := range f.Places {
+		text := strings.ToLower(p.Name + " " + p.FormattedAddress)
+		match := len(words) > 0 || req.Type != ""
+		for _, w := range words {
+			if !strings.Contains(text, w) {
+				match = false
+				break
+			}
+		}
+		if req.Type != "" && !hasType(p, req.Type) {
+			match = false
+		}
+		if match && req.Location != nil && req.RadiusM > 0 && distanceKm(*req.Location, p)*1000 > float64(req.RadiusM) {
+			match = false
+		}
+		if match {
+			results = append(results, p)
+		}
+	}
+	if req.Location != nil {
+		at := *req.Location
+		sort.SliceStable(results, func(i, j int) bool { return distanceKm(at, results[i]) < distanceKm(at, results[j]) })
+	}
+	return results, nil
+}
+
+func hasType(p Place, placeType string) bool {
+	for _, t := range p.Types {
+		if t == placeType {
+			return true
+		}
+	}
+	return false
+}
+
+func distanceKm(at LatLng, p Place) float64 {
+	return geo.Haversine(geo.Point{Lat: at.Lat, Lng: at.Lng}, geo.Point{Lat: p.Geometry.Location.Lat, Lng: p.Geometry.Location.Lng})
+}
+
+func (f *Fake) Details(ctx context.Context, placeID string) (*Place, error) {
+	for _, p := range f.Places {
+		if p.PlaceID == placeID {
+			p := p
+			return &p, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Autocomplete suggests places with a word of their name starting with input
+func (f *Fake) Autocomplete(ctx context.Context, input string) ([]Prediction, error) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	predictions := []Prediction{}
+	if input == "" {
+		return predictions, nil
+	}
+	for _, p := range f.Places {
+		name := strings.ToLower(p.Name)
+		match := strings.HasPrefix(name, input)
+		for _, w := range strings.Fields(name) {
+			match = match || strings.HasPrefix(w, input)
+		}
+		if match {
+			predictions = append(predictions, Prediction{
+				PlaceID:       p.PlaceID,
+				Description:   p.Name + ", " + p.FormattedAddress,
+				MainText:      p.Name,
+				SecondaryText: p.FormattedAddress,
+			})
+		}
+	}
+	return predictions, nil
+}
+
+// ReverseGeocode returns the fixtures within a few km, closest first
+func (f *Fake) ReverseGeocode(ctx context.Context, lat, lng float64) ([]Place, error) {
+	at := LatLng{Lat: lat, Lng: lng}
+	distance := func(p Place) float64 { return distanceKm(at, p) }
+
+	results := []Place{}
+	for _, p := range f.Places {
+		if distance(p) <= maxReverseGeocodeKm {
+			results = append(results, p)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return distance(results[i]) < distance(results[j]) })
+	return results, nil
+}
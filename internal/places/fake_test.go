@@ -0,0 +1,145 @@
+package places
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func place(id, name, address string, lat, lng float64, types ...string) Place {
+	return Place{PlaceID: id, Name: name, FormattedAddress: address, Geometry: Geometry{Location: LatLng{Lat: lat, Lng: lng}}, Types: types}
+}
+
+func testFake() *Fake {
+	return &Fake{Places: []Place{
+		place("eiffel", "Eiffel Tower", "75007 Paris, France", 48.8584, 2.2945, "tourist_attraction"),
+		place("louvre", "Louvre Museum", "75001 Paris, France", 48.8606, 2.3376, "museum", "tourist_attraction"),
+		place("flore", "Café de Flore", "75006 Paris, France", 48.8542, 2.3326, "cafe"),
+		place("british", "British Museum", "London WC1B 3DG, United Kingdom", 51.5194, -0.127, "museum"),
+	}}
+}
+
+func placeIDs(places []Place) string {
+	ids := make([]string, len(places))
+	for i, p := range places {
+		ids[i] = p.PlaceID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestFakeSearch(t *testing.T) {
+	saintGermain := &LatLng{Lat: 48.8539, Lng: 2.3338}
+	tests := []struct {
+		name string
+		req  SearchRequest
+		want string
+	}{
+		{"every word must match", SearchRequest{Query: "museum PARIS"}, "louvre"},
+		{"matches the address", SearchRequest{Query: "london"}, "british"},
+		{"by type", SearchRequest{Type: "museum"}, "louvre,british"},
+		{"query and type", SearchRequest{Query: "paris", Type: "tourist_attraction"}, "eiffel,louvre"},
+		{"nothing asked", SearchRequest{}, ""},
+		{"closest first", SearchRequest{Query: "paris", Location: saintGermain}, "flore,louvre,eiffel"},
+		{"within the radius", SearchRequest{Query: "paris", Location: saintGermain, RadiusM: 2000}, "flore,louvre"},
+	}
+	f := testFake()
+	for _, tt := range tests {
+		got, err := f.Search(context.Background(), tt.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := placeIDs(got); ids != tt.want {
+			t.Errorf("%s: got [%s], want [%s]", tt.name, ids, tt.want)
+		}
+	}
+}
+
+func TestFakeDetails(t *testing.T) {
+	f := testFake()
+	p, err := f.Details(context.Background(), "louvre")
+	if err != nil || p.Name != "Louvre Museum" {
+		t.Errorf("got %+v, %v", p, err)
+	}
+	p.Name = "changed"
+	if f.Places[1].Name != "Louvre Museum" {
+		t.Error("Details handed out a fixture the caller can change")
+	}
+	if _, err := f.Details(context.Background(), "unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+}
+
+func TestFakeAutocomplete(t *testing.T) {
+	f := testFake()
+	for input, want := range map[string]string{
+		"mus":           "louvre,british",
+		" Eiffel T":     "eiffel",
+		"café":          "flore",
+		"useum":         "",
+		"":              "",
+		"british museu": "british",
+	} {
+		predictions, err := f.Autocomplete(context.Background(), input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make([]string, len(predictions))
+		for i, p := range predictions {
+			ids[i] = p.PlaceID
+		}
+		if got := strings.Join(ids, ","); got != want {
+			t.Errorf("Autocomplete(%q) = [%s], want [%s]", input, got, want)
+		}
+	}
+
+	predictions, _ := f.Autocomplete(context.Background(), "flore")
+	if p := predictions[0]; p.MainText != "Café de Flore" || p.SecondaryText != "75006 Paris, France" || p.Description != "Café de Flore, 75006 Paris, France" {
+		t.Errorf("got %+v", p)
+	}
+}
+
+func TestFakeReverseGeocode(t *testing.T) {
+	f := testFake()
+	got, err := f.ReverseGeocode(context.Background(), 48.8606, 2.3370)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := placeIDs(got); ids != "louvre,flore,eiffel" {
+		t.Errorf("got [%s]", ids)
+	}
+	if got, _ := f.ReverseGeocode(context.Background(), 0, 0); len(got) != 0 {
+		t.Errorf("got [%s] in the Gulf of Guinea", placeIDs(got))
+	}
+}
+
+func TestLoadFake(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "places.json")
+	if err := os.WriteFile(path, []byte(`[{"place_id": "x", "name": "X", "geometry": {"location": {"lat": 1, "lng": 2}}}]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := LoadFake(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Places) != 1 || f.Places[0].Geometry.Location.Lng != 2 {
+		t.Errorf("got %+v", f.Places)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"place_id": "x"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFake(path); err == nil {
+		t.Error("accepted a fixture file that isn't an array")
+	}
+}
+
+func TestNewFake(t *testing.T) {
+	f := NewFake()
+	got, _ := f.Search(context.Background(), SearchRequest{Query: "eiffel"})
+	if len(got) != 1 || got[0].PlaceID != "fake-eiffel-tower" {
+		t.Errorf("got %+v", got)
+	}
+}
@@ -0,0 +1,202 @@
+package places
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultGoogleBaseURL is the root of the Google Maps web services
+const DefaultGoogleBaseURL = "https://maps.googleapis.com/maps/api"
+
+// Google queries the Google Places and Geocoding web services
+type Google struct {
+	APIKey  string
+	BaseURL string       // DefaultGoogleBaseURL when empty, e.g. a test server otherwise
+	Client  *http.Client // a client with a 10s timeout when nil
+}
+
+var defaultClient = &http.Client{Timeout: 10 * time.Second}
+
+// NewGoogle returns a provider for the production Google endpoints
+func NewGoogle(apiKey string) *Google {
+	return &Google{APIKey: apiKey, BaseURL: DefaultGoogleBaseURL, Client: defaultClient}
+}
+
+// googleResult is a Places or Geocoding result; geocoding results have no
+// name, only address components
+type googleResult struct {
+	PlaceID           string   `json:"place_id"`
+	Name              string   `json:"name"`
+	FormattedAddress  string   `json:"formatted_address"`
+	Geometry          Geometry `json:"geometry"`
+	Types             []string `json:"types"`
+	AddressComponents []struct {
+		LongName string `json:"long_name"`
+	} `json:"address_components"`
+}
+
+func (r googleResult) place() Place {
+	p := Place{
+		PlaceID:          r.PlaceID,
+		Name:             r.Name,
+		FormattedAddress: r.FormattedAddress,
+		Geometry:         r.Geometry,
+		Types:            r.Types,
+	}
+	if p.Name == "" && len(r.AddressComponents) > 0 {
+		p.Name = r.AddressComponents[0].LongName
+	}
+	return p
+}
+
+type googleResponse struct {
+	Status       string         `json:"status"`
+	ErrorMessage string         `json:"error_message"`
+	Results      []googleResult `json:"results"`
+	Result       *googleResult  `json:"result"`
+	Predictions  []struct {
+		PlaceID              string `json:"place_id"`
+		Description          string `json:"description"`
+		StructuredFormatting struct {
+			MainText      string `json:"main_text"`
+			SecondaryText string `json:"secondary_text"`
+		} `json:"structured_formatting"`
+	} `json:"predictions"`
+}
+
+// Search runs a text search. Google treats the radius as a preference, so
+// results can lie outside it.
+func (g *Google) Search(ctx context.Context, req SearchRequest) ([]Place, error) {
+	q := url.Values{}
+	q.Set("query", req.Query)
+	if req.Language != "" {
+		q.Set("language", req.Language)
+	}
+	if req.Location != nil {
+		q.Set("location", strconv.FormatFloat(req.Location.Lat, 'f', -1, 64)+","+strconv.FormatFloat(req.Location.Lng, 'f', -1, 64))
+		if req.RadiusM > 0 {
+			q.Set("radius", strconv.Itoa(req.RadiusM))
+		}
+	}
+	if req.Type != "" {
+		q.Set("type", req.Type)
+	}
+
+	resp, err := g.get(ctx, "/place/textsearch/json", q)
+	if err != nil {
+		return nil, err
+	}
+	return toPlaces(resp.Results), nil
+}
+
+// Details looks a place up by ID
+func (g *Google) Details(ctx context.Context, placeID string) (*Place, error) {
+	q := url.Values{}
+	q.Set("place_id", placeID)
+	q.Set("fields", "place_id,name,formatted_address,geometry,types")
+
+	resp, err := g.get(ctx, "/place/details/json", q)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Result == nil {
+		return nil, ErrNotFound
+	}
+	p := resp.Result.place()
+	return &p, nil
+}
+
+// Autocomplete suggests places for partially typed input
+func (g *Google) Autocomplete(ctx context.Context, input string) ([]Prediction, error) {
+	q := url.Values{}
+	q.Set("input", input)
+
+	resp, err := g.get(ctx, "/place/autocomplete/json", q)
+	if err != nil {
+		return nil, err
+	}
+	predictions := make([]Prediction, 0, len(resp.Predictions))
+	for _, p := range resp.Predictions {
+		predictions = append(predictions, Prediction{
+			PlaceID:       p.PlaceID,
+			Description:   p.Description,
+			MainText:      p.StructuredFormatting.MainText,
+			SecondaryText: p.StructuredFormatting.SecondaryText,
+		})
+	}
+	return predictions, nil
+}
+
+// ReverseGeocode returns the addresses at a coordinate, most specific first
+func (g *Google) ReverseGeocode(ctx context.Context, lat, lng float64) ([]Place, error) {
+	q := url.Values{}
+	q.Set("latlng", strconv.FormatFloat(lat, 'f', -1, 64)+","+strconv.FormatFloat(lng, 'f', -1, 64))
+
+	resp, err := g.get(ctx, "/geocode/json", q)
+	if err != nil {
+		return nil, err
+	}
+	return toPlaces(resp.Results), nil
+}
+
+// get calls an endpoint and checks both the HTTP and the API status
+func (g *Google) get(ctx context.Context, path string, q url.Values) (*googleResponse, error) {
+	if g.APIKey == "" {
+		return nil, errors.New("GOOGLE_MAPS_API_KEY is not set")
+	}
+	base := g.BaseURL
+	if base == "" {
+		base = DefaultGoogleBaseURL
+	}
+	client := g.Client
+	if client == nil {
+		client = defaultClient
+	}
+
+	q.Set("key", g.APIKey)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(base, "/")+path+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("google places api returned status: %d", resp.StatusCode)
+	}
+
+	var body googleResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	switch body.Status {
+	case "OK", "ZERO_RESULTS":
+		return &body, nil
+	case "NOT_FOUND", "INVALID_REQUEST":
+		if path == "/place/details/json" {
+			return nil, ErrNotFound
+		}
+	}
+	if body.ErrorMessage != "" {
+		return nil, fmt.Errorf("google places api error: %s: %s", body.Status, body.ErrorMessage)
+	}
+	return nil, fmt.Errorf("google places api error: %s", body.Status)
+}
+
+func toPlaces(results []googleResult) []Place {
+	out := make([]Place, 0, len(results))
+	for _, r := range results {
+		out = append(out, r.place())
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+// Package places talks to place search providers: Google Places, or a fake
+// backed by fixtures for local development and tests.
+package places
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotFound is returned by Details for unknown place IDs
+var ErrNotFound = errors.New("place not found")
+
+// Place is a search, details or reverse geocoding result. The JSON shape
+// follows Google's so clients can pass results straight back to us.
+type Place struct {
+	PlaceID          string   `json:"place_id"`
+	Name             string   `json:"name"`
+	FormattedAddress string   `json:"formatted_address"`
+	Geometry         Geometry `json:"geometry"`
+	Types            []string `json:"types,omitempty"`
+}
+
+type Geometry struct {
+	Location LatLng `json:"location"`
+}
+
+type LatLng struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// Prediction is an autocomplete suggestion. Its PlaceID can be looked up
+// with Details.
+type Prediction struct {
+	PlaceID       string `json:"place_id"`
+	Description   string `json:"description"`
+	MainText      string `json:"main_text"`
+	SecondaryText string `json:"secondary_text"`
+}
+
+// SearchRequest is a place search by text, type or both, optionally biased
+// towards a point
+type SearchRequest struct {
+	Query    string
+	Language string  // BCP 47 tag for result names, e.g. "fr" or "pt-BR"
+	Location *LatLng // prefer results near this point
+	RadiusM  int     // with Location, only return results this close
+	Type     string  // a place type such as "restaurant"
+}
+
+// CacheKey identifies equivalent searches: the query is compared
+// case-insensitively with whitespace collapsed, and bias points within
+// about 100m of each other share results
+func (r SearchRequest) CacheKey() string {
+	parts := []string{
+		strings.Join(strings.Fields(strings.ToLower(r.Query)), " "),
+		strings.ToLower(r.Language),
+	}
+	if r.Location != nil || r.Type != "" {
+		bias := ""
+		if r.Location != nil {
+			bias = fmt.Sprintf("%.3f,%.3f,%d", r.Location.Lat, r.Location.Lng, r.RadiusM)
+		}
+		parts = append(parts, bias, strings.ToLower(r.Type))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
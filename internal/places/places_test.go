@@ -0,0 +1,60 @@
+package places
+
+import "testing"
+
+func TestCacheKey(t *testing.T) {
+	base := SearchRequest{Query: "cafe"}
+	tests := []struct {
+		name string
+		req  SearchRequest
+		same bool
+	}{
+		{"case and whitespace", SearchRequest{Query: "  CAFE "}, true},
+		{"different query", SearchRequest{Query: "café"}, false},
+		{"different language", SearchRequest{Query: "cafe", Language: "fr"}, false},
+	}
+	for _, tt := range tests {
+		if same := tt.req.CacheKey() == base.CacheKey(); same != tt.same {
+			t.Errorf("%s: same key is %v, want %v", tt.name, same, tt.same)
+		}
+	}
+
+	// Multi-word queries keep their word boundaries
+	if (SearchRequest{Query: "new  york"}).CacheKey() != (SearchRequest{Query: "New York"}).CacheKey() {
+		t.Error("collapsed whitespace gives a different key")
+	}
+	if (SearchRequest{Query: "a b"}).CacheKey() == (SearchRequest{Query: "ab"}).CacheKey() {
+		t.Error("joined words share a key")
+	}
+	if (SearchRequest{Query: "cafe", Language: "pt-BR"}).CacheKey() != (SearchRequest{Query: "cafe", Language: "pt-br"}).CacheKey() {
+		t.Error("language tags are compared case-sensitively")
+	}
+}
+
+func TestCacheKeyBias(t *testing.T) {
+	paris := &LatLng{Lat: 48.8566, Lng: 2.3522}
+	nearby := &LatLng{Lat: 48.85655, Lng: 2.35218}
+	london := &LatLng{Lat: 51.5074, Lng: -0.1278}
+
+	base := SearchRequest{Query: "cafe", Location: paris, RadiusM: 1000}
+	tests := []struct {
+		name string
+		req  SearchRequest
+		same bool
+	}{
+		{"bias point a few meters away", SearchRequest{Query: "cafe", Location: nearby, RadiusM: 1000}, true},
+		{"different bias point", SearchRequest{Query: "cafe", Location: london, RadiusM: 1000}, false},
+		{"different radius", SearchRequest{Query: "cafe", Location: paris, RadiusM: 500}, false},
+		{"with a type", SearchRequest{Query: "cafe", Location: paris, RadiusM: 1000, Type: "cafe"}, false},
+		{"without a bias point", SearchRequest{Query: "cafe"}, false},
+	}
+	for _, tt := range tests {
+		if same := tt.req.CacheKey() == base.CacheKey(); same != tt.same {
+			t.Errorf("%s: same key is %v, want %v", tt.name, same, tt.same)
+		}
+	}
+
+	if (SearchRequest{Type: "museum"}).CacheKey() == (SearchRequest{}).CacheKey() {
+		t.Error("a type-only search shares the empty search's key")
+	}
+}
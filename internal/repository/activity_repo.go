@@ -3,12 +3,25 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/NoahFola/travel_app_backend/internal/domain"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// positionGap is the spacing between activities after a renumber, leaving
+// room to insert between neighbours without touching other rows
+const positionGap = 1024
+
+// minPositionGap is how close two positions may get before a renumber
+const minPositionGap = 1e-6
+
+var ErrInvalidOrder = errors.New("ids must list every activity of the itinerary exactly once")
+
+// ErrStatusChanged is returned when the status was changed by someone else mid-update
+var ErrStatusChanged = errors.New("activity status was changed concurrently, reload and retry")
+
 type ActivityRepository struct {
 	DB *pgxpool.Pool
 }
@@ -17,23 +30,103 @@ func NewActivityRepository(db *pgxpool.Pool) *ActivityRepository {
 	return &ActivityRepository{DB: db}
 }
 
-func (r *ActivityRepository) Create(ctx context.Context, activity *domain.Activity) error {
+// activitySelect loads activities together with their structured location
+const activitySelect = `
+		SELECT a.id, a.trip_id, a.itinerary_id, a.name, a.description, a.location, a.location_id, a.start_time, a.end_time, a.end_date, a.recurrence, a.type, a.status, a.position, a.created_at, a.updated_at,
+			l.name, l.address, l.latitude, l.longitude, l.google_place_id, l.timezone
+		FROM activities a
+		LEFT JOIN locations l ON l.id = a.location_id`
+
+func scanActivity(row pgx.Row, a *domain.Activity, extra ...any) error {
+	var name, address, placeID, timezone *string
+	var lat, lng *float64
+	dest := []any{
+		&a.ID,
+		&a.TripID,
+		&a.ItineraryID,
+		&a.Name,
+		&a.Description,
+		&a.Location,
+		&a.LocationID,
+		&a.StartTime,
+		&a.EndTime,
+		&a.EndDate,
+		&a.Recurrence,
+		&a.Type,
+		&a.Status,
+		&a.Position,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+		&name,
+		&address,
+		&lat,
+		&lng,
+		&placeID,
+		&timezone,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+
+	a.Place = nil
+	if a.LocationID != nil && name != nil && lat != nil && lng != nil {
+		a.Place = &domain.Location{
+			ID:            *a.LocationID,
+			Name:          *name,
+			Latitude:      *lat,
+			Longitude:     *lng,
+			GooglePlaceID: placeID,
+			Timezone:      locationTimezone(timezone, *lat, *lng),
+		}
+		if address != nil {
+			a.Place.Address = *address
+		}
+	}
+	a.Localize()
+	return nil
+}
+
+// Create inserts the activity at the end of its itinerary (or of the trip's
+// unscheduled backlog) and starts its status history. An empty actorID is
+// recorded as NULL.
+func (r *ActivityRepository) Create(ctx context.Context, activity *domain.Activity, actorID string) error {
+	return insertActivity(ctx, r.DB, activity, actorID)
+}
+
+// queryRower is a pool or a transaction
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func insertActivity(ctx context.Context, db queryRower, activity *domain.Activity, actorID string) error {
 	query := `
-		INSERT INTO activities (trip_id, itinerary_id, name, description, location, start_time, end_time, type, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW())
-		RETURNING id, created_at, updated_at`
+		WITH inserted AS (
+			INSERT INTO activities (trip_id, itinerary_id, name, description, location, location_id, start_time, end_time, end_date, recurrence, type, status, position, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $12, $13, $9, $10,
+				(SELECT COALESCE(MAX(position), 0) + 1024 FROM activities WHERE trip_id = $1 AND itinerary_id IS NOT DISTINCT FROM $2),
+				NOW(), NOW())
+			RETURNING id, status, position, created_at, updated_at
+		), history AS (
+			INSERT INTO activity_status_history (activity_id, from_status, to_status, actor_id, changed_at)
+			SELECT id, NULL, status, NULLIF($11, '')::uuid, created_at FROM inserted
+		)
+		SELECT id, position, created_at, updated_at FROM inserted`
 
-	err := r.DB.QueryRow(ctx, query,
+	err := db.QueryRow(ctx, query,
 		activity.TripID,
 		activity.ItineraryID,
 		activity.Name,
 		activity.Description,
 		activity.Location,
+		activity.LocationID,
 		activity.StartTime,
 		activity.EndTime,
 		activity.Type,
 		activity.Status,
-	).Scan(&activity.ID, &activity.CreatedAt, &activity.UpdatedAt)
+		actorID,
+		activity.EndDate,
+		activity.Recurrence,
+	).Scan(&activity.ID, &activity.Position, &activity.CreatedAt, &activity.UpdatedAt)
 
 	if err != nil {
 		return err
@@ -42,26 +135,11 @@ func (r *ActivityRepository) Create(ctx context.Context, activity *domain.Activi
 }
 
 func (r *ActivityRepository) GetByID(ctx context.Context, id string) (*domain.Activity, error) {
-	query := `
-		SELECT id, trip_id, itinerary_id, name, description, location, start_time, end_time, type, status, created_at, updated_at
-		FROM activities
-		WHERE id = $1`
+	query := activitySelect + `
+		WHERE a.id = $1`
 
 	var a domain.Activity
-	err := r.DB.QueryRow(ctx, query, id).Scan(
-		&a.ID,
-		&a.TripID,
-		&a.ItineraryID,
-		&a.Name,
-		&a.Description,
-		&a.Location,
-		&a.StartTime,
-		&a.EndTime,
-		&a.Type,
-		&a.Status,
-		&a.CreatedAt,
-		&a.UpdatedAt,
-	)
+	err := scanActivity(r.DB.QueryRow(ctx, query, id), &a)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -72,12 +150,11 @@ func (r *ActivityRepository) GetByID(ctx context.Context, id string) (*domain.Ac
 	return &a, nil
 }
 
+// GetByItineraryID lists a day's activities in their user-defined order
 func (r *ActivityRepository) GetByItineraryID(ctx context.Context, itineraryID string) ([]domain.Activity, error) {
-	query := `
-		SELECT id, trip_id, itinerary_id, name, description, location, start_time, end_time, type, status, created_at, updated_at
-		FROM activities
-		WHERE itinerary_id = $1
-		ORDER BY start_time ASC`
+	query := activitySelect + `
+		WHERE a.itinerary_id = $1
+		ORDER BY a.position ASC, a.start_time ASC NULLS LAST, a.created_at ASC`
 
 	rows, err := r.DB.Query(ctx, query, itineraryID)
 	if err != nil {
@@ -88,21 +165,7 @@ func (r *ActivityRepository) GetByItineraryID(ctx context.Context, itineraryID s
 	var activities []domain.Activity
 	for rows.Next() {
 		var a domain.Activity
-		err := rows.Scan(
-			&a.ID,
-			&a.TripID,
-			&a.ItineraryID,
-			&a.Name,
-			&a.Description,
-			&a.Location,
-			&a.StartTime,
-			&a.EndTime,
-			&a.Type,
-			&a.Status,
-			&a.CreatedAt,
-			&a.UpdatedAt,
-		)
-		if err != nil {
+		if err := scanActivity(rows, &a); err != nil {
 			return nil, err
 		}
 		activities = append(activities, a)
@@ -115,14 +178,69 @@ func (r *ActivityRepository) GetByItineraryID(ctx context.Context, itineraryID s
 	return activities, nil
 }
 
-func (r *ActivityRepository) Update(ctx context.Context, activity *domain.Activity) error {
+// GetByTripID lists a trip's activities by day and position. With
+// unscheduled, only the backlog of activities without a day is returned.
+func (r *ActivityRepository) GetByTripID(ctx context.Context, tripID string, unscheduled bool) ([]domain.Activity, error) {
+	query := activitySelect + `
+		LEFT JOIN itineraries i ON i.id = a.itinerary_id
+		WHERE a.trip_id = $1 AND (NOT $2 OR a.itinerary_id IS NULL)
+		ORDER BY i.date ASC NULLS LAST, a.itinerary_id, a.position ASC, a.created_at ASC`
+
+	rows, err := r.DB.Query(ctx, query, tripID, unscheduled)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	activities := []domain.Activity{}
+	for rows.Next() {
+		var a domain.Activity
+		if err := scanActivity(rows, &a); err != nil {
+			return nil, err
+		}
+		activities = append(activities, a)
+	}
+	return activities, rows.Err()
+}
+
+// Update saves the activity. Moving it to another itinerary via
+// itinerary_id appends it to the end of that day, or of the backlog when
+// itinerary_id is cleared. fromStatus is the status
+// the caller validated the change against; if the stored status no longer
+// matches, nothing is saved and ErrStatusChanged is returned. A status
+// change is recorded in the history with actorID.
+func (r *ActivityRepository) Update(ctx context.Context, activity *domain.Activity, fromStatus, actorID string) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var current string
+	err = tx.QueryRow(ctx, `SELECT status FROM activities WHERE id = $1 FOR UPDATE`, activity.ID).Scan(&current)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errors.New("activity not found")
+		}
+		return err
+	}
+	if current != fromStatus {
+		return ErrStatusChanged
+	}
+
 	query := `
 		UPDATE activities
-		SET itinerary_id = $1, name = $2, description = $3, location = $4, start_time = $5, end_time = $6, type = $7, status = $8, updated_at = NOW()
+		SET position = CASE
+				WHEN itinerary_id IS DISTINCT FROM $1
+				THEN (SELECT COALESCE(MAX(b.position), 0) + 1024 FROM activities b
+					WHERE b.trip_id = activities.trip_id AND b.itinerary_id IS NOT DISTINCT FROM $1)
+				ELSE position
+			END,
+			itinerary_id = $1, name = $2, description = $3, location = $4, start_time = $5, end_time = $6, type = $7, status = $8, location_id = $10, end_date = $11, recurrence = $12, updated_at = NOW()
 		WHERE id = $9
-		RETURNING updated_at`
+		RETURNING position, updated_at`
 
-	err := r.DB.QueryRow(ctx, query,
+	err = tx.QueryRow(ctx, query,
 		activity.ItineraryID,
 		activity.Name,
 		activity.Description,
@@ -132,15 +250,49 @@ func (r *ActivityRepository) Update(ctx context.Context, activity *domain.Activi
 		activity.Type,
 		activity.Status,
 		activity.ID,
-	).Scan(&activity.UpdatedAt)
+		activity.LocationID,
+		activity.EndDate,
+		activity.Recurrence,
+	).Scan(&activity.Position, &activity.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if activity.Status != current {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO activity_status_history (activity_id, from_status, to_status, actor_id, changed_at)
+			VALUES ($1, $2, $3, NULLIF($4, '')::uuid, $5)`,
+			activity.ID, current, activity.Status, actorID, activity.UpdatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
 
+// GetStatusHistory returns an activity's status transitions, oldest first
+func (r *ActivityRepository) GetStatusHistory(ctx context.Context, activityID string) ([]domain.ActivityStatusChange, error) {
+	query := `
+		SELECT id, activity_id, from_status, to_status, actor_id, changed_at
+		FROM activity_status_history
+		WHERE activity_id = $1
+		ORDER BY changed_at ASC, id ASC`
+
+	rows, err := r.DB.Query(ctx, query, activityID)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return errors.New("activity not found")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []domain.ActivityStatusChange
+	for rows.Next() {
+		var h domain.ActivityStatusChange
+		if err := rows.Scan(&h.ID, &h.ActivityID, &h.FromStatus, &h.ToStatus, &h.ActorID, &h.ChangedAt); err != nil {
+			return nil, err
 		}
-		return err
+		history = append(history, h)
 	}
-	return nil
+	return history, rows.Err()
 }
 
 func (r *ActivityRepository) Delete(ctx context.Context, id string) error {
@@ -154,3 +306,284 @@ func (r *ActivityRepository) Delete(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+// lockItinerary locks a day's activities and returns their IDs in order
+func lockItinerary(ctx context.Context, tx pgx.Tx, itineraryID string) ([]string, []float64, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT id, position
+		FROM activities
+		WHERE itinerary_id = $1
+		ORDER BY position ASC, start_time ASC NULLS LAST, created_at ASC
+		FOR UPDATE`, itineraryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	var positions []float64
+	for rows.Next() {
+		var id string
+		var pos float64
+		if err := rows.Scan(&id, &pos); err != nil {
+			return nil, nil, err
+		}
+		ids = append(ids, id)
+		positions = append(positions, pos)
+	}
+	return ids, positions, rows.Err()
+}
+
+// positionAt returns the position of an activity inserted at index among
+// the sorted positions: halfway between its neighbours, or a gap past the
+// last one
+func positionAt(positions []float64, index int) float64 {
+	switch {
+	case len(positions) == 0:
+		return positionGap
+	case index == 0:
+		return positions[0] / 2
+	case index == len(positions):
+		return positions[index-1] + positionGap
+	default:
+		return (positions[index-1] + positions[index]) / 2
+	}
+}
+
+func renumber(ctx context.Context, tx pgx.Tx, ids []string) error {
+	for i, id := range ids {
+		_, err := tx.Exec(ctx,
+			`UPDATE activities SET position = $1, updated_at = NOW() WHERE id = $2`,
+			float64((i+1)*positionGap), id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reorder sets a day's order to ids, which must contain every activity of
+// the itinerary exactly once.
+func (r *ActivityRepository) Reorder(ctx context.Context, itineraryID string, ids []string) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	current, _, err := lockItinerary(ctx, tx, itineraryID)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool, len(current))
+	for _, id := range current {
+		existing[id] = true
+	}
+	if len(ids) != len(current) {
+		return ErrInvalidOrder
+	}
+	for _, id := range ids {
+		if !existing[id] {
+			return ErrInvalidOrder
+		}
+		delete(existing, id)
+	}
+
+	if err := renumber(ctx, tx, ids); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Move puts the activity into itineraryID at index (0-based; past the end
+// or negative appends). It takes the midpoint of its new neighbours'
+// positions and only renumbers the day when they are too close together.
+func (r *ActivityRepository) Move(ctx context.Context, activityID, itineraryID string, index int) (*domain.Activity, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := moveActivity(ctx, tx, activityID, itineraryID, index); err != nil {
+		return nil, err
+	}
+
+	var a domain.Activity
+	err = scanActivity(tx.QueryRow(ctx, activitySelect+` WHERE a.id = $1`, activityID), &a)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func moveActivity(ctx context.Context, tx pgx.Tx, activityID, itineraryID string, index int) error {
+	ids, positions, err := lockItinerary(ctx, tx, itineraryID)
+	if err != nil {
+		return err
+	}
+
+	// The activity may already be in this itinerary; place it among the others
+	var otherIDs []string
+	var otherPositions []float64
+	for i, id := range ids {
+		if id != activityID {
+			otherIDs = append(otherIDs, id)
+			otherPositions = append(otherPositions, positions[i])
+		}
+	}
+	if index < 0 || index > len(otherIDs) {
+		index = len(otherIDs)
+	}
+
+	position := positionAt(otherPositions, index)
+
+	// A multi-day activity keeps its length: end_date shifts with the start day
+	ct, err := tx.Exec(ctx, `
+		UPDATE activities
+		SET end_date = COALESCE(end_date + (
+				(SELECT date FROM itineraries WHERE id = $1) -
+				(SELECT date FROM itineraries WHERE id = activities.itinerary_id)
+			), end_date),
+			itinerary_id = $1, position = $2, updated_at = NOW()
+		WHERE id = $3`, itineraryID, position, activityID)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return errors.New("activity not found")
+	}
+
+	tooClose := index > 0 && position-otherPositions[index-1] < minPositionGap ||
+		index < len(otherIDs) && otherPositions[index]-position < minPositionGap
+	if tooClose {
+		ordered := append([]string{}, otherIDs[:index]...)
+		ordered = append(ordered, activityID)
+		ordered = append(ordered, otherIDs[index:]...)
+		return renumber(ctx, tx, ordered)
+	}
+	return nil
+}
+
+const overrideColumns = `activity_id, occurrence_date, cancelled, name, description, location, start_time, end_time, created_at, updated_at`
+
+func scanOverride(row pgx.Row, o *domain.ActivityOverride) error {
+	return row.Scan(
+		&o.ActivityID,
+		&o.Date,
+		&o.Cancelled,
+		&o.Name,
+		&o.Description,
+		&o.Location,
+		&o.StartTime,
+		&o.EndTime,
+		&o.CreatedAt,
+		&o.UpdatedAt,
+	)
+}
+
+// SaveOverride creates or replaces the override of one occurrence
+func (r *ActivityRepository) SaveOverride(ctx context.Context, o *domain.ActivityOverride) error {
+	query := `
+		INSERT INTO activity_occurrence_overrides (activity_id, occurrence_date, cancelled, name, description, location, start_time, end_time, created_at, updated_at)
+		VALUES ($1, $2::date, $3, $4, $5, $6, $7, $8, NOW(), NOW())
+		ON CONFLICT (activity_id, occurrence_date) DO UPDATE
+		SET cancelled = EXCLUDED.cancelled, name = EXCLUDED.name, description = EXCLUDED.description,
+			location = EXCLUDED.location, start_time = EXCLUDED.start_time, end_time = EXCLUDED.end_time, updated_at = NOW()
+		RETURNING created_at, updated_at`
+
+	return r.DB.QueryRow(ctx, query,
+		o.ActivityID,
+		o.Date.Format("2006-01-02"),
+		o.Cancelled,
+		o.Name,
+		o.Description,
+		o.Location,
+		o.StartTime,
+		o.EndTime,
+	).Scan(&o.CreatedAt, &o.UpdatedAt)
+}
+
+func (r *ActivityRepository) DeleteOverride(ctx context.Context, activityID string, date time.Time) error {
+	ct, err := r.DB.Exec(ctx,
+		`DELETE FROM activity_occurrence_overrides WHERE activity_id = $1 AND occurrence_date = $2::date`,
+		activityID, date.Format("2006-01-02"))
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return errors.New("override not found")
+	}
+	return nil
+}
+
+// GetOverrides returns an activity's overrides by date
+func (r *ActivityRepository) GetOverrides(ctx context.Context, activityID string) ([]domain.ActivityOverride, error) {
+	query := `SELECT ` + overrideColumns + `
+		FROM activity_occurrence_overrides
+		WHERE activity_id = $1
+		ORDER BY occurrence_date ASC`
+	return r.queryOverrides(ctx, query, activityID)
+}
+
+// GetOverridesByTripID returns the overrides of every activity of the trip
+func (r *ActivityRepository) GetOverridesByTripID(ctx context.Context, tripID string) ([]domain.ActivityOverride, error) {
+	query := `SELECT ` + overrideColumns + `
+		FROM activity_occurrence_overrides
+		WHERE activity_id IN (SELECT id FROM activities WHERE trip_id = $1)
+		ORDER BY activity_id, occurrence_date ASC`
+	return r.queryOverrides(ctx, query, tripID)
+}
+
+func (r *ActivityRepository) queryOverrides(ctx context.Context, query string, arg string) ([]domain.ActivityOverride, error) {
+	rows, err := r.DB.Query(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var overrides []domain.ActivityOverride
+	for rows.Next() {
+		var o domain.ActivityOverride
+		if err := scanOverride(rows, &o); err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, rows.Err()
+}
+
+// ScheduledActivity is an activity with the date of the day it belongs to
+type ScheduledActivity struct {
+	domain.Activity
+	ItineraryDate *time.Time
+}
+
+// GetScheduleByTripID returns every activity of the trip with its day's date
+func (r *ActivityRepository) GetScheduleByTripID(ctx context.Context, tripID string) ([]ScheduledActivity, error) {
+	query := activitySelect + `
+		LEFT JOIN itineraries i ON i.id = a.itinerary_id
+		WHERE a.trip_id = $1
+		ORDER BY i.date ASC NULLS LAST, a.position ASC`
+
+	rows, err := r.DB.Query(ctx, query, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activities []ScheduledActivity
+	for rows.Next() {
+		var s ScheduledActivity
+		if err := scanActivity(rows, &s.Activity, &s.ItineraryDate); err != nil {
+			return nil, err
+		}
+		activities = append(activities, s)
+	}
+	return activities, rows.Err()
+}
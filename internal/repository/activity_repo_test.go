@@ -0,0 +1,23 @@
+package repository
+
+import "testing"
+
+func TestPositionAt(t *testing.T) {
+	tests := []struct {
+		name      string
+		positions []float64
+		index     int
+		want      float64
+	}{
+		{"empty day", nil, 0, 1024},
+		{"first", []float64{1024, 2048}, 0, 512},
+		{"between", []float64{1024, 2048}, 1, 1536},
+		{"last", []float64{1024, 2048}, 2, 3072},
+		{"between close neighbours", []float64{1, 1.5}, 1, 1.25},
+	}
+	for _, tt := range tests {
+		if got := positionAt(tt.positions, tt.index); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ChecklistRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewChecklistRepository(db *pgxpool.Pool) *ChecklistRepository {
+	return &ChecklistRepository{DB: db}
+}
+
+// Create inserts the checklist along with any items it already carries
+func (r *ChecklistRepository) Create(ctx context.Context, checklist *domain.Checklist) error {
+	return r.CreateMany(ctx, []*domain.Checklist{checklist})
+}
+
+// CreateMany inserts several checklists and their items in one transaction,
+// so either all of them are created or none
+func (r *ChecklistRepository) CreateMany(ctx context.Context, checklists []*domain.Checklist) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO checklists (trip_id, title, kind, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		RETURNING id, created_at, updated_at`
+
+	for _, checklist := range checklists {
+		err = tx.QueryRow(ctx, query, checklist.TripID, checklist.Title, checklist.Kind).
+			Scan(&checklist.ID, &checklist.CreatedAt, &checklist.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		for i := range checklist.Items {
+			item := &checklist.Items[i]
+			item.ChecklistID = checklist.ID
+			item.Position = i + 1
+			if err := insertItem(ctx, tx, item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func insertItem(ctx context.Context, q interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}, item *domain.ChecklistItem) error {
+	query := `
+		INSERT INTO checklist_items (checklist_id, title, quantity, assignee_id, due_date, done, position, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+		RETURNING id, created_at, updated_at`
+
+	return q.QueryRow(ctx, query,
+		item.ChecklistID,
+		item.Title,
+		item.Quantity,
+		item.AssigneeID,
+		item.DueDate,
+		item.Done,
+		item.Position,
+	).Scan(&item.ID, &item.CreatedAt, &item.UpdatedAt)
+}
+
+func (r *ChecklistRepository) GetByID(ctx context.Context, id string) (*domain.Checklist, error) {
+	query := `
+		SELECT id, trip_id, title, kind, created_at, updated_at
+		FROM checklists
+		WHERE id = $1`
+
+	var c domain.Checklist
+	err := r.DB.QueryRow(ctx, query, id).Scan(&c.ID, &c.TripID, &c.Title, &c.Kind, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("checklist not found")
+		}
+		return nil, err
+	}
+
+	items, err := r.getItems(ctx, `WHERE i.checklist_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	c.Items = items[c.ID]
+	return &c, nil
+}
+
+func (r *ChecklistRepository) GetByTripID(ctx context.Context, tripID string) ([]domain.Checklist, error) {
+	query := `
+		SELECT id, trip_id, title, kind, created_at, updated_at
+		FROM checklists
+		WHERE trip_id = $1
+		ORDER BY created_at ASC`
+
+	rows, err := r.DB.Query(ctx, query, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checklists []domain.Checklist
+	for rows.Next() {
+		var c domain.Checklist
+		if err := rows.Scan(&c.ID, &c.TripID, &c.Title, &c.Kind, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		checklists = append(checklists, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	items, err := r.getItems(ctx, `JOIN checklists c ON c.id = i.checklist_id WHERE c.trip_id = $1`, tripID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range checklists {
+		checklists[i].Items = items[checklists[i].ID]
+	}
+
+	return checklists, nil
+}
+
+// getItems loads items grouped by checklist ID, in display order
+func (r *ChecklistRepository) getItems(ctx context.Context, where string, arg string) (map[string][]domain.ChecklistItem, error) {
+	query := `
+		SELECT i.id, i.checklist_id, i.title, i.quantity, i.assignee_id, i.due_date, i.done, i.position, i.created_at, i.updated_at
+		FROM checklist_items i ` + where + `
+		ORDER BY i.position ASC, i.created_at ASC`
+
+	rows, err := r.DB.Query(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make(map[string][]domain.ChecklistItem)
+	for rows.Next() {
+		var i domain.ChecklistItem
+		err := rows.Scan(
+			&i.ID,
+			&i.ChecklistID,
+			&i.Title,
+			&i.Quantity,
+			&i.AssigneeID,
+			&i.DueDate,
+			&i.Done,
+			&i.Position,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items[i.ChecklistID] = append(items[i.ChecklistID], i)
+	}
+	return items, rows.Err()
+}
+
+func (r *ChecklistRepository) Update(ctx context.Context, checklist *domain.Checklist) error {
+	query := `
+		UPDATE checklists
+		SET title = $1, kind = $2, updated_at = NOW()
+		WHERE id = $3
+		RETURNING updated_at`
+
+	err := r.DB.QueryRow(ctx, query, checklist.Title, checklist.Kind, checklist.ID).Scan(&checklist.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errors.New("checklist not found")
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *ChecklistRepository) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM checklists WHERE id = $1`
+	ct, err := r.DB.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return errors.New("checklist not found")
+	}
+	return nil
+}
+
+// CreateItem appends an item to the end of its checklist
+func (r *ChecklistRepository) CreateItem(ctx context.Context, item *domain.ChecklistItem) error {
+	err := r.DB.QueryRow(ctx,
+		`SELECT COALESCE(MAX(position), 0) + 1 FROM checklist_items WHERE checklist_id = $1`,
+		item.ChecklistID,
+	).Scan(&item.Position)
+	if err != nil {
+		return err
+	}
+	return insertItem(ctx, r.DB, item)
+}
+
+func (r *ChecklistRepository) GetItem(ctx context.Context, id string) (*domain.ChecklistItem, error) {
+	query := `
+		SELECT id, checklist_id, title, quantity, assignee_id, due_date, done, position, created_at, updated_at
+		FROM checklist_items
+		WHERE id = $1`
+
+	var i domain.ChecklistItem
+	err := r.DB.QueryRow(ctx, query, id).Scan(
+		&i.ID,
+		&i.ChecklistID,
+		&i.Title,
+		&i.Quantity,
+		&i.AssigneeID,
+		&i.DueDate,
+		&i.Done,
+		&i.Position,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("checklist item not found")
+		}
+		return nil, err
+	}
+	return &i, nil
+}
+
+func (r *ChecklistRepository) UpdateItem(ctx context.Context, item *domain.ChecklistItem) error {
+	query := `
+		UPDATE checklist_items
+		SET title = $1, quantity = $2, assignee_id = $3, due_date = $4, done = $5, updated_at = NOW()
+		WHERE id = $6
+		RETURNING updated_at`
+
+	err := r.DB.QueryRow(ctx, query,
+		item.Title,
+		item.Quantity,
+		item.AssigneeID,
+		item.DueDate,
+		item.Done,
+		item.ID,
+	).Scan(&item.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errors.New("checklist item not found")
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *ChecklistRepository) DeleteItem(ctx context.Context, id string) error {
+	query := `DELETE FROM checklist_items WHERE id = $1`
+	ct, err := r.DB.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return errors.New("checklist item not found")
+	}
+	return nil
+}
+
+// ReorderItems rewrites item positions to match itemIDs in one transaction
+func (r *ChecklistRepository) ReorderItems(ctx context.Context, checklistID string, itemIDs []string) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for i, id := range itemIDs {
+		ct, err := tx.Exec(ctx,
+			`UPDATE checklist_items SET position = $1, updated_at = NOW() WHERE id = $2 AND checklist_id = $3`,
+			i+1, id, checklistID)
+		if err != nil {
+			return err
+		}
+		if ct.RowsAffected() == 0 {
+			return errors.New("checklist item not found")
+		}
+	}
+
+	return tx.Commit(ctx)
+}
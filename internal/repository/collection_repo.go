@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CollectionRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewCollectionRepository(db *pgxpool.Pool) *CollectionRepository {
+	return &CollectionRepository{DB: db}
+}
+
+// collectionSelect loads collections with the number of places they hold
+const collectionSelect = `
+		SELECT c.id, c.user_id, c.name, c.description, c.share_token, c.created_at, c.updated_at,
+			(SELECT COUNT(*) FROM saved_places sp WHERE sp.collection_id = c.id)
+		FROM place_collections c`
+
+func scanCollection(row pgx.Row, c *domain.PlaceCollection) error {
+	return row.Scan(
+		&c.ID,
+		&c.UserID,
+		&c.Name,
+		&c.Description,
+		&c.ShareToken,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+		&c.PlaceCount,
+	)
+}
+
+func (r *CollectionRepository) Create(ctx context.Context, c *domain.PlaceCollection) error {
+	query := `
+		INSERT INTO place_collections (user_id, name, description, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		RETURNING id, created_at, updated_at`
+
+	return r.DB.QueryRow(ctx, query, c.UserID, c.Name, c.Description).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+}
+
+func (r *CollectionRepository) GetByID(ctx context.Context, id string) (*domain.PlaceCollection, error) {
+	var c domain.PlaceCollection
+	if err := scanCollection(r.DB.QueryRow(ctx, collectionSelect+` WHERE c.id = $1`, id), &c); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("collection not found")
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetByShareToken finds a shared collection by its public token
+func (r *CollectionRepository) GetByShareToken(ctx context.Context, token string) (*domain.PlaceCollection, error) {
+	var c domain.PlaceCollection
+	if err := scanCollection(r.DB.QueryRow(ctx, collectionSelect+` WHERE c.share_token = $1`, token), &c); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("collection not found")
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetByUserID lists a user's collections by name
+func (r *CollectionRepository) GetByUserID(ctx context.Context, userID string) ([]domain.PlaceCollection, error) {
+	rows, err := r.DB.Query(ctx, collectionSelect+` WHERE c.user_id = $1 ORDER BY c.name ASC, c.created_at ASC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := []domain.PlaceCollection{}
+	for rows.Next() {
+		var c domain.PlaceCollection
+		if err := scanCollection(rows, &c); err != nil {
+			return nil, err
+		}
+		collections = append(collections, c)
+	}
+	return collections, rows.Err()
+}
+
+func (r *CollectionRepository) Update(ctx context.Context, c *domain.PlaceCollection) error {
+	query := `
+		UPDATE place_collections
+		SET name = $1, description = $2, share_token = $3, updated_at = NOW()
+		WHERE id = $4
+		RETURNING updated_at`
+
+	err := r.DB.QueryRow(ctx, query, c.Name, c.Description, c.ShareToken, c.ID).Scan(&c.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errors.New("collection not found")
+	}
+	return err
+}
+
+func (r *CollectionRepository) Delete(ctx context.Context, id string) error {
+	ct, err := r.DB.Exec(ctx, `DELETE FROM place_collections WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return errors.New("collection not found")
+	}
+	return nil
+}
+
+// savedPlaceSelect loads saved places together with their location
+const savedPlaceSelect = `
+		SELECT sp.id, sp.collection_id, sp.location_id, sp.note, sp.tags, sp.created_at, sp.updated_at,
+			l.name, COALESCE(l.address, ''), l.latitude, l.longitude, l.google_place_id, l.types, l.timezone
+		FROM saved_places sp
+		JOIN locations l ON l.id = sp.location_id`
+
+func scanSavedPlace(row pgx.Row, p *domain.SavedPlace) error {
+	loc := domain.Location{}
+	var timezone *string
+	err := row.Scan(
+		&p.ID,
+		&p.CollectionID,
+		&p.LocationID,
+		&p.Note,
+		&p.Tags,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+		&loc.Name,
+		&loc.Address,
+		&loc.Latitude,
+		&loc.Longitude,
+		&loc.GooglePlaceID,
+		&loc.Types,
+		&timezone,
+	)
+	if err != nil {
+		return err
+	}
+	loc.ID = p.LocationID
+	loc.Timezone = locationTimezone(timezone, loc.Latitude, loc.Longitude)
+	p.Place = &loc
+	return nil
+}
+
+// SavePlace bookmarks a location in a collection. Saving it again keeps the
+// existing note and tags unless new ones are given.
+func (r *CollectionRepository) SavePlace(ctx context.Context, p *domain.SavedPlace) error {
+	query := `
+		INSERT INTO saved_places (collection_id, location_id, note, tags, created_at, updated_at)
+		VALUES ($1, $2, $3, COALESCE($4, '{}'::text[]), NOW(), NOW())
+		ON CONFLICT (collection_id, location_id) DO UPDATE
+		SET note = COALESCE(EXCLUDED.note, saved_places.note),
+			tags = CASE WHEN cardinality(EXCLUDED.tags) > 0 THEN EXCLUDED.tags ELSE saved_places.tags END,
+			updated_at = NOW()
+		RETURNING id, note, tags, created_at, updated_at`
+
+	return r.DB.QueryRow(ctx, query, p.CollectionID, p.LocationID, p.Note, p.Tags).
+		Scan(&p.ID, &p.Note, &p.Tags, &p.CreatedAt, &p.UpdatedAt)
+}
+
+func (r *CollectionRepository) GetPlace(ctx context.Context, id string) (*domain.SavedPlace, error) {
+	var p domain.SavedPlace
+	if err := scanSavedPlace(r.DB.QueryRow(ctx, savedPlaceSelect+` WHERE sp.id = $1`, id), &p); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("saved place not found")
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetPlaces lists a collection's places, most recently saved first,
+// optionally only those with a tag
+func (r *CollectionRepository) GetPlaces(ctx context.Context, collectionID, tag string) ([]domain.SavedPlace, error) {
+	query := savedPlaceSelect + `
+		WHERE sp.collection_id = $1 AND ($2 = '' OR $2 = ANY(sp.tags))
+		ORDER BY sp.created_at DESC, sp.id`
+
+	rows, err := r.DB.Query(ctx, query, collectionID, tag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	saved := []domain.SavedPlace{}
+	for rows.Next() {
+		var p domain.SavedPlace
+		if err := scanSavedPlace(rows, &p); err != nil {
+			return nil, err
+		}
+		saved = append(saved, p)
+	}
+	return saved, rows.Err()
+}
+
+func (r *CollectionRepository) UpdatePlace(ctx context.Context, p *domain.SavedPlace) error {
+	query := `
+		UPDATE saved_places
+		SET note = $1, tags = COALESCE($2, '{}'::text[]), updated_at = NOW()
+		WHERE id = $3
+		RETURNING tags, updated_at`
+
+	err := r.DB.QueryRow(ctx, query, p.Note, p.Tags, p.ID).Scan(&p.Tags, &p.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errors.New("saved place not found")
+	}
+	return err
+}
+
+func (r *CollectionRepository) DeletePlace(ctx context.Context, id string) error {
+	ct, err := r.DB.Exec(ctx, `DELETE FROM saved_places WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return errors.New("saved place not found")
+	}
+	return nil
+}
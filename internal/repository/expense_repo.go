@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ExpenseRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewExpenseRepository(db *pgxpool.Pool) *ExpenseRepository {
+	return &ExpenseRepository{DB: db}
+}
+
+// Create inserts the expense and its splits in a single transaction
+func (r *ExpenseRepository) Create(ctx context.Context, e *domain.Expense) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO expenses (trip_id, activity_id, paid_by, kind, description, amount_minor, currency, split_method, incurred_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW())
+		RETURNING id, created_at, updated_at`
+
+	err = tx.QueryRow(ctx, query,
+		e.TripID,
+		e.ActivityID,
+		e.PaidBy,
+		e.Kind,
+		e.Description,
+		e.AmountMinor,
+		e.Currency,
+		e.SplitMethod,
+		e.IncurredAt,
+	).Scan(&e.ID, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range e.Splits {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO expense_splits (expense_id, participant_id, shares, amount_minor)
+			VALUES ($1, $2, $3, $4)`,
+			e.ID, s.ParticipantID, s.Shares, s.AmountMinor)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *ExpenseRepository) GetByID(ctx context.Context, id string) (*domain.Expense, error) {
+	query := `
+		SELECT id, trip_id, activity_id, paid_by, kind, description, amount_minor, currency, split_method, incurred_at, created_at, updated_at
+		FROM expenses
+		WHERE id = $1`
+
+	var e domain.Expense
+	err := r.DB.QueryRow(ctx, query, id).Scan(
+		&e.ID,
+		&e.TripID,
+		&e.ActivityID,
+		&e.PaidBy,
+		&e.Kind,
+		&e.Description,
+		&e.AmountMinor,
+		&e.Currency,
+		&e.SplitMethod,
+		&e.IncurredAt,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("expense not found")
+		}
+		return nil, err
+	}
+
+	splits, err := r.getSplits(ctx, `WHERE s.expense_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	e.Splits = splits[e.ID]
+	return &e, nil
+}
+
+func (r *ExpenseRepository) GetByTripID(ctx context.Context, tripID string) ([]domain.Expense, error) {
+	query := `
+		SELECT id, trip_id, activity_id, paid_by, kind, description, amount_minor, currency, split_method, incurred_at, created_at, updated_at
+		FROM expenses
+		WHERE trip_id = $1
+		ORDER BY incurred_at ASC, created_at ASC`
+
+	rows, err := r.DB.Query(ctx, query, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expenses []domain.Expense
+	for rows.Next() {
+		var e domain.Expense
+		err := rows.Scan(
+			&e.ID,
+			&e.TripID,
+			&e.ActivityID,
+			&e.PaidBy,
+			&e.Kind,
+			&e.Description,
+			&e.AmountMinor,
+			&e.Currency,
+			&e.SplitMethod,
+			&e.IncurredAt,
+			&e.CreatedAt,
+			&e.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		expenses = append(expenses, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	splits, err := r.getSplits(ctx, `JOIN expenses e ON e.id = s.expense_id WHERE e.trip_id = $1`, tripID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range expenses {
+		expenses[i].Splits = splits[expenses[i].ID]
+	}
+
+	return expenses, nil
+}
+
+// getSplits loads splits grouped by expense ID
+func (r *ExpenseRepository) getSplits(ctx context.Context, where string, arg string) (map[string][]domain.ExpenseSplit, error) {
+	query := `
+		SELECT s.expense_id, s.participant_id, s.shares, s.amount_minor
+		FROM expense_splits s ` + where
+
+	rows, err := r.DB.Query(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	splits := make(map[string][]domain.ExpenseSplit)
+	for rows.Next() {
+		var expenseID string
+		var s domain.ExpenseSplit
+		if err := rows.Scan(&expenseID, &s.ParticipantID, &s.Shares, &s.AmountMinor); err != nil {
+			return nil, err
+		}
+		splits[expenseID] = append(splits[expenseID], s)
+	}
+	return splits, rows.Err()
+}
+
+// Delete removes an expense of the trip and reports whether there was one
+func (r *ExpenseRepository) Delete(ctx context.Context, tripID, id string) (bool, error) {
+	query := `DELETE FROM expenses WHERE id = $1 AND trip_id = $2`
+	ct, err := r.DB.Exec(ctx, query, id, tripID)
+	if err != nil {
+		return false, err
+	}
+	return ct.RowsAffected() > 0, nil
+}
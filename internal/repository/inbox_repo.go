@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type InboxRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewInboxRepository(db *pgxpool.Pool) *InboxRepository {
+	return &InboxRepository{DB: db}
+}
+
+// GetAddress returns the user's inbound address token, or nil if they have none yet
+func (r *InboxRepository) GetAddress(ctx context.Context, userID string) (*domain.InboundAddress, error) {
+	query := `SELECT user_id, token, created_at FROM inbound_addresses WHERE user_id = $1`
+
+	var a domain.InboundAddress
+	err := r.DB.QueryRow(ctx, query, userID).Scan(&a.UserID, &a.Token, &a.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// SetAddress creates or replaces the user's inbound address token
+func (r *InboxRepository) SetAddress(ctx context.Context, userID, token string) (*domain.InboundAddress, error) {
+	query := `
+		INSERT INTO inbound_addresses (user_id, token, created_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET token = EXCLUDED.token, created_at = NOW()
+		RETURNING user_id, token, created_at`
+
+	var a domain.InboundAddress
+	if err := r.DB.QueryRow(ctx, query, userID, token).Scan(&a.UserID, &a.Token, &a.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *InboxRepository) GetUserIDByToken(ctx context.Context, token string) (string, error) {
+	var userID string
+	err := r.DB.QueryRow(ctx, `SELECT user_id FROM inbound_addresses WHERE token = $1`, token).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", errors.New("inbound address not found")
+	}
+	return userID, err
+}
+
+const inboxColumns = `id, user_id, status, subject, sender, booking, trip_id, activity_id, received_at, created_at, updated_at`
+
+func scanInboxItem(row pgx.Row, item *domain.InboxItem) error {
+	var booking []byte
+	err := row.Scan(
+		&item.ID,
+		&item.UserID,
+		&item.Status,
+		&item.Subject,
+		&item.Sender,
+		&booking,
+		&item.TripID,
+		&item.ActivityID,
+		&item.ReceivedAt,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(booking, &item.Booking)
+}
+
+// CreateItem stores a parsed booking. It reports false without error when
+// the user already has an item with the same fingerprint.
+func (r *InboxRepository) CreateItem(ctx context.Context, item *domain.InboxItem, fingerprint string) (bool, error) {
+	booking, err := json.Marshal(item.Booking)
+	if err != nil {
+		return false, err
+	}
+
+	query := `
+		INSERT INTO inbox_items (user_id, status, subject, sender, booking, fingerprint, received_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+		ON CONFLICT (user_id, fingerprint) DO NOTHING
+		RETURNING id, created_at, updated_at`
+
+	err = r.DB.QueryRow(ctx, query,
+		item.UserID,
+		item.Status,
+		item.Subject,
+		item.Sender,
+		booking,
+		fingerprint,
+		item.ReceivedAt,
+	).Scan(&item.ID, &item.CreatedAt, &item.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *InboxRepository) GetItem(ctx context.Context, id string) (*domain.InboxItem, error) {
+	query := `SELECT ` + inboxColumns + ` FROM inbox_items WHERE id = $1`
+
+	var item domain.InboxItem
+	if err := scanInboxItem(r.DB.QueryRow(ctx, query, id), &item); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("inbox item not found")
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+// ListItems returns a user's items with the given status, newest first
+func (r *InboxRepository) ListItems(ctx context.Context, userID, status string) ([]domain.InboxItem, error) {
+	query := `SELECT ` + inboxColumns + `
+		FROM inbox_items
+		WHERE user_id = $1 AND status = $2
+		ORDER BY received_at DESC, created_at DESC`
+
+	rows, err := r.DB.Query(ctx, query, userID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []domain.InboxItem
+	for rows.Next() {
+		var item domain.InboxItem
+		if err := scanInboxItem(rows, &item); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// UpdateItem saves the item's status and placement
+// Place adds the item's booking to a trip in one go: the itinerary when it
+// is new (empty ID), the activity on it and the reservation for the
+// activity. The item is then marked assigned to them.
+func (r *InboxRepository) Place(ctx context.Context, item *domain.InboxItem, itinerary *domain.Itinerary, activity *domain.Activity, res *domain.Reservation) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if itinerary.ID == "" {
+		if err := insertItinerary(ctx, tx, itinerary); err != nil {
+			return err
+		}
+	}
+	activity.ItineraryID = &itinerary.ID
+	if err := insertActivity(ctx, tx, activity, item.UserID); err != nil {
+		return err
+	}
+	res.ActivityID = activity.ID
+	if err := insertReservation(ctx, tx, res, nil); err != nil {
+		return err
+	}
+
+	var updatedAt time.Time
+	err = tx.QueryRow(ctx, `
+		UPDATE inbox_items
+		SET status = $1, trip_id = $2, activity_id = $3, updated_at = NOW()
+		WHERE id = $4
+		RETURNING updated_at`,
+		domain.InboxAssigned, activity.TripID, activity.ID, item.ID).Scan(&updatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errors.New("inbox item not found")
+	}
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	item.Status = domain.InboxAssigned
+	item.TripID = &activity.TripID
+	item.ActivityID = &activity.ID
+	item.UpdatedAt = updatedAt
+	return nil
+}
+
+func (r *InboxRepository) UpdateItem(ctx context.Context, item *domain.InboxItem) error {
+	query := `
+		UPDATE inbox_items
+		SET status = $1, trip_id = $2, activity_id = $3, updated_at = NOW()
+		WHERE id = $4
+		RETURNING updated_at`
+
+	err := r.DB.QueryRow(ctx, query, item.Status, item.TripID, item.ActivityID, item.ID).Scan(&item.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errors.New("inbox item not found")
+	}
+	return err
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/NoahFola/travel_app_backend/internal/domain"
 	"github.com/jackc/pgx/v5"
@@ -18,12 +19,16 @@ func NewItineraryRepository(db *pgxpool.Pool) *ItineraryRepository {
 }
 
 func (r *ItineraryRepository) Create(ctx context.Context, itinerary *domain.Itinerary) error {
+	return insertItinerary(ctx, r.DB, itinerary)
+}
+
+func insertItinerary(ctx context.Context, db queryRower, itinerary *domain.Itinerary) error {
 	query := `
 		INSERT INTO itineraries (trip_id, slug, title, date, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, NOW(), NOW())
 		RETURNING id, created_at, updated_at`
 
-	err := r.DB.QueryRow(ctx, query, itinerary.TripID, itinerary.Slug, itinerary.Title, itinerary.Date).
+	err := db.QueryRow(ctx, query, itinerary.TripID, itinerary.Slug, itinerary.Title, itinerary.Date).
 		Scan(&itinerary.ID, &itinerary.CreatedAt, &itinerary.UpdatedAt)
 	if err != nil {
 		return err
@@ -95,6 +100,34 @@ func (r *ItineraryRepository) GetByTripID(ctx context.Context, tripID string) ([
 	return itineraries, nil
 }
 
+// GetByTripAndDate returns the trip's first itinerary on date, or nil if there is none
+func (r *ItineraryRepository) GetByTripAndDate(ctx context.Context, tripID string, date time.Time) (*domain.Itinerary, error) {
+	query := `
+		SELECT id, trip_id, slug, title, date, created_at, updated_at
+		FROM itineraries
+		WHERE trip_id = $1 AND date = $2::date
+		ORDER BY created_at ASC
+		LIMIT 1`
+
+	var i domain.Itinerary
+	err := r.DB.QueryRow(ctx, query, tripID, date.Format("2006-01-02")).Scan(
+		&i.ID,
+		&i.TripID,
+		&i.Slug,
+		&i.Title,
+		&i.Date,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
 func (r *ItineraryRepository) Update(ctx context.Context, itinerary *domain.Itinerary) error {
 	query := `
 		UPDATE itineraries
@@ -113,14 +146,34 @@ func (r *ItineraryRepository) Update(ctx context.Context, itinerary *domain.Itin
 	return nil
 }
 
+// Delete removes the itinerary. Its activities are kept: they move, in
+// order, to the end of the trip's unscheduled backlog.
 func (r *ItineraryRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM itineraries WHERE id = $1`
-	ct, err := r.DB.Exec(ctx, query, id)
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE activities
+		SET itinerary_id = NULL,
+			position = position + (
+				SELECT COALESCE(MAX(b.position), 0) FROM activities b
+				WHERE b.trip_id = activities.trip_id AND b.itinerary_id IS NULL
+			),
+			updated_at = NOW()
+		WHERE itinerary_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	ct, err := tx.Exec(ctx, `DELETE FROM itineraries WHERE id = $1`, id)
 	if err != nil {
 		return err
 	}
 	if ct.RowsAffected() == 0 {
 		return errors.New("itinerary not found")
 	}
-	return nil
+	return tx.Commit(ctx)
 }
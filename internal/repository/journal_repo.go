@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type JournalRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewJournalRepository(db *pgxpool.Pool) *JournalRepository {
+	return &JournalRepository{DB: db}
+}
+
+const journalColumns = `id, trip_id, itinerary_id, author_id, title, body, mood, weather, location_id, entry_time, shared, created_at, updated_at`
+
+func scanJournalEntry(row pgx.Row, e *domain.JournalEntry) error {
+	return row.Scan(
+		&e.ID,
+		&e.TripID,
+		&e.ItineraryID,
+		&e.AuthorID,
+		&e.Title,
+		&e.Body,
+		&e.Mood,
+		&e.Weather,
+		&e.LocationID,
+		&e.EntryTime,
+		&e.Shared,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+	)
+}
+
+// Create inserts the entry and attaches mediaIDs in order
+func (r *JournalRepository) Create(ctx context.Context, e *domain.JournalEntry, mediaIDs []string) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO journal_entries (trip_id, itinerary_id, author_id, title, body, mood, weather, location_id, entry_time, shared, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW())
+		RETURNING id, created_at, updated_at`
+
+	err = tx.QueryRow(ctx, query,
+		e.TripID,
+		e.ItineraryID,
+		e.AuthorID,
+		e.Title,
+		e.Body,
+		e.Mood,
+		e.Weather,
+		e.LocationID,
+		e.EntryTime,
+		e.Shared,
+	).Scan(&e.ID, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := setJournalMedia(ctx, tx, e.ID, mediaIDs); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func setJournalMedia(ctx context.Context, tx pgx.Tx, entryID string, mediaIDs []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM journal_entry_media WHERE entry_id = $1`, entryID); err != nil {
+		return err
+	}
+	for i, mediaID := range mediaIDs {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO journal_entry_media (entry_id, media_id, position) VALUES ($1, $2, $3)`,
+			entryID, mediaID, i+1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *JournalRepository) GetByID(ctx context.Context, id string) (*domain.JournalEntry, error) {
+	query := `SELECT ` + journalColumns + ` FROM journal_entries WHERE id = $1`
+
+	var e domain.JournalEntry
+	if err := scanJournalEntry(r.DB.QueryRow(ctx, query, id), &e); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("journal entry not found")
+		}
+		return nil, err
+	}
+
+	media, err := r.getMedia(ctx, []string{e.ID})
+	if err != nil {
+		return nil, err
+	}
+	e.Media = media[e.ID]
+	return &e, nil
+}
+
+// GetByTripID lists a trip's entries chronologically. An itineraryID narrows
+// the list to one day, and sharedOnly keeps entries meant for share previews.
+func (r *JournalRepository) GetByTripID(ctx context.Context, tripID string, itineraryID *string, sharedOnly bool) ([]domain.JournalEntry, error) {
+	query := `SELECT ` + journalColumns + `
+		FROM journal_entries
+		WHERE trip_id = $1
+		  AND ($2::uuid IS NULL OR itinerary_id = $2)
+		  AND (NOT $3 OR shared)
+		ORDER BY entry_time ASC, created_at ASC`
+
+	rows, err := r.DB.Query(ctx, query, tripID, itineraryID, sharedOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []domain.JournalEntry
+	var ids []string
+	for rows.Next() {
+		var e domain.JournalEntry
+		if err := scanJournalEntry(rows, &e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+		ids = append(ids, e.ID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	media, err := r.getMedia(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		entries[i].Media = media[entries[i].ID]
+	}
+
+	return entries, nil
+}
+
+func (r *JournalRepository) getMedia(ctx context.Context, entryIDs []string) (map[string][]domain.JournalMedia, error) {
+	query := `
+		SELECT jm.entry_id, m.id, m.url, m.type
+		FROM journal_entry_media jm
+		JOIN media m ON m.id = jm.media_id
+		WHERE jm.entry_id = ANY($1)
+		ORDER BY jm.position ASC`
+
+	rows, err := r.DB.Query(ctx, query, entryIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	media := make(map[string][]domain.JournalMedia)
+	for rows.Next() {
+		var entryID string
+		var m domain.JournalMedia
+		if err := rows.Scan(&entryID, &m.ID, &m.Key, &m.Type); err != nil {
+			return nil, err
+		}
+		media[entryID] = append(media[entryID], m)
+	}
+	return media, rows.Err()
+}
+
+// Update saves the entry. A non-nil mediaIDs replaces the attached media.
+func (r *JournalRepository) Update(ctx context.Context, e *domain.JournalEntry, mediaIDs []string) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE journal_entries
+		SET itinerary_id = $1, title = $2, body = $3, mood = $4, weather = $5, location_id = $6, entry_time = $7, shared = $8, updated_at = NOW()
+		WHERE id = $9
+		RETURNING updated_at`
+
+	err = tx.QueryRow(ctx, query,
+		e.ItineraryID,
+		e.Title,
+		e.Body,
+		e.Mood,
+		e.Weather,
+		e.LocationID,
+		e.EntryTime,
+		e.Shared,
+		e.ID,
+	).Scan(&e.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errors.New("journal entry not found")
+		}
+		return err
+	}
+
+	if mediaIDs != nil {
+		if err := setJournalMedia(ctx, tx, e.ID, mediaIDs); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *JournalRepository) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM journal_entries WHERE id = $1`
+	ct, err := r.DB.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return errors.New("journal entry not found")
+	}
+	return nil
+}
@@ -2,20 +2,14 @@ package repository
 
 import (
 	"context"
+	"math"
 
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/geo"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-type Location struct {
-	ID            string  `json:"id"`
-	Name          string  `json:"name"`
-	Address       string  `json:"address"`
-	Latitude      float64 `json:"latitude"`
-	Longitude     float64 `json:"longitude"`
-	GooglePlaceID *string `json:"google_place_id"`
-}
-
 type LocationRepository struct {
 	DB *pgxpool.Pool
 }
@@ -24,25 +18,42 @@ func NewLocationRepository(db *pgxpool.Pool) *LocationRepository {
 	return &LocationRepository{DB: db}
 }
 
-func (r *LocationRepository) Create(ctx context.Context, loc *Location) error {
+func (r *LocationRepository) Create(ctx context.Context, loc *domain.Location) error {
 	query := `
-		INSERT INTO locations (name, address, latitude, longitude, google_place_id)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO locations (name, address, latitude, longitude, google_place_id, types, timezone)
+		VALUES ($1, $2, $3, $4, $5, COALESCE($6, '{}'::text[]), $7)
 		RETURNING id
 	`
-	err := r.DB.QueryRow(ctx, query, loc.Name, loc.Address, loc.Latitude, loc.Longitude, loc.GooglePlaceID).Scan(&loc.ID)
+	loc.Timezone = geo.Timezone(geo.Point{Lat: loc.Latitude, Lng: loc.Longitude})
+	err := r.DB.QueryRow(ctx, query, loc.Name, loc.Address, loc.Latitude, loc.Longitude, loc.GooglePlaceID, loc.Types, loc.Timezone).Scan(&loc.ID)
 	return err
 }
 
-func (r *LocationRepository) GetByPlaceID(ctx context.Context, placeID string) (*Location, error) {
+// UpsertByPlaceID saves a provider's place, refreshing the stored details
+// if we already have it, and sets loc.ID
+func (r *LocationRepository) UpsertByPlaceID(ctx context.Context, loc *domain.Location) error {
+	query := `
+		INSERT INTO locations (name, address, latitude, longitude, google_place_id, types, timezone)
+		VALUES ($1, $2, $3, $4, $5, COALESCE($6, '{}'::text[]), $7)
+		ON CONFLICT (google_place_id) DO UPDATE
+		SET name = EXCLUDED.name, address = EXCLUDED.address, latitude = EXCLUDED.latitude, longitude = EXCLUDED.longitude,
+			types = EXCLUDED.types, timezone = EXCLUDED.timezone
+		RETURNING id
+	`
+	loc.Timezone = geo.Timezone(geo.Point{Lat: loc.Latitude, Lng: loc.Longitude})
+	return r.DB.QueryRow(ctx, query, loc.Name, loc.Address, loc.Latitude, loc.Longitude, loc.GooglePlaceID, loc.Types, loc.Timezone).Scan(&loc.ID)
+}
+
+func (r *LocationRepository) GetByPlaceID(ctx context.Context, placeID string) (*domain.Location, error) {
 	query := `
-		SELECT id, name, address, latitude, longitude, google_place_id
+		SELECT id, name, COALESCE(address, ''), latitude, longitude, google_place_id, types, timezone
 		FROM locations
 		WHERE google_place_id = $1
 	`
-	var loc Location
+	var loc domain.Location
+	var timezone *string
 	err := r.DB.QueryRow(ctx, query, placeID).Scan(
-		&loc.ID, &loc.Name, &loc.Address, &loc.Latitude, &loc.Longitude, &loc.GooglePlaceID,
+		&loc.ID, &loc.Name, &loc.Address, &loc.Latitude, &loc.Longitude, &loc.GooglePlaceID, &loc.Types, &timezone,
 	)
 	if err == pgx.ErrNoRows {
 		return nil, nil
@@ -50,18 +61,20 @@ func (r *LocationRepository) GetByPlaceID(ctx context.Context, placeID string) (
 	if err != nil {
 		return nil, err
 	}
+	loc.Timezone = locationTimezone(timezone, loc.Latitude, loc.Longitude)
 	return &loc, nil
 }
 
-func (r *LocationRepository) GetByID(ctx context.Context, id string) (*Location, error) {
+func (r *LocationRepository) GetByID(ctx context.Context, id string) (*domain.Location, error) {
 	query := `
-		SELECT id, name, address, latitude, longitude, google_place_id
+		SELECT id, name, COALESCE(address, ''), latitude, longitude, google_place_id, types, timezone
 		FROM locations
 		WHERE id = $1
 	`
-	var loc Location
+	var loc domain.Location
+	var timezone *string
 	err := r.DB.QueryRow(ctx, query, id).Scan(
-		&loc.ID, &loc.Name, &loc.Address, &loc.Latitude, &loc.Longitude, &loc.GooglePlaceID,
+		&loc.ID, &loc.Name, &loc.Address, &loc.Latitude, &loc.Longitude, &loc.GooglePlaceID, &loc.Types, &timezone,
 	)
 	if err == pgx.ErrNoRows {
 		return nil, nil // Return nil if not found
@@ -69,5 +82,59 @@ func (r *LocationRepository) GetByID(ctx context.Context, id string) (*Location,
 	if err != nil {
 		return nil, err
 	}
+	loc.Timezone = locationTimezone(timezone, loc.Latitude, loc.Longitude)
 	return &loc, nil
 }
+
+// Nearby returns known locations within radiusKm of a point, closest first,
+// optionally only those of a place type. The bounding box lets the
+// latitude/longitude index narrow the rows before haversine distances are
+// computed.
+func (r *LocationRepository) Nearby(ctx context.Context, at geo.Point, radiusKm float64, placeType string, limit int) ([]domain.NearbyLocation, error) {
+	min, max := geo.BoundingBox(at, radiusKm)
+	query := `
+		SELECT id, name, address, latitude, longitude, google_place_id, types, timezone, distance_km
+		FROM (
+			SELECT id, name, COALESCE(address, '') AS address, latitude, longitude, google_place_id, types, timezone,
+				2 * $3::float8 * asin(least(1, sqrt(
+					power(sin(radians(latitude - $1) / 2), 2) +
+					cos(radians($1)) * cos(radians(latitude)) * power(sin(radians(longitude - $2) / 2), 2)
+				))) AS distance_km
+			FROM locations
+			WHERE latitude BETWEEN $4 AND $5
+				AND longitude BETWEEN $6 AND $7
+				AND ($8 = '' OR $8 = ANY(types))
+		) l
+		WHERE distance_km <= $9
+		ORDER BY distance_km, name
+		LIMIT $10
+	`
+	rows, err := r.DB.Query(ctx, query, at.Lat, at.Lng, geo.EarthRadiusKm, min.Lat, max.Lat, min.Lng, max.Lng, placeType, radiusKm, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	nearby := []domain.NearbyLocation{}
+	for rows.Next() {
+		var n domain.NearbyLocation
+		var timezone *string
+		var distanceKm float64
+		if err := rows.Scan(&n.ID, &n.Name, &n.Address, &n.Latitude, &n.Longitude, &n.GooglePlaceID, &n.Types, &timezone, &distanceKm); err != nil {
+			return nil, err
+		}
+		n.Timezone = locationTimezone(timezone, n.Latitude, n.Longitude)
+		n.DistanceM = math.Round(distanceKm * 1000)
+		nearby = append(nearby, n)
+	}
+	return nearby, rows.Err()
+}
+
+// locationTimezone returns the stored timezone, resolving it from the
+// coordinates for locations saved before timezones were recorded
+func locationTimezone(stored *string, lat, lng float64) string {
+	if stored != nil && *stored != "" {
+		return *stored
+	}
+	return geo.Timezone(geo.Point{Lat: lat, Lng: lng})
+}
@@ -2,15 +2,79 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Media struct {
-	ID         string `json:"id"`
-	URL        string `json:"url"`
-	Type       string `json:"type"`
-	ActivityID string `json:"activity_id"`
+	ID           string  `json:"id"`
+	Key          string  `json:"-"`    // blob store key, saved in the url column
+	URL          string  `json:"url"`  // download URL from the blob store
+	Type         string  `json:"type"` // image, video or document
+	TripID       string  `json:"trip_id"`
+	ActivityID   *string `json:"activity_id"`  // nil until the photo is placed
+	ItineraryID  *string `json:"itinerary_id"` // the activity's day, or the day alone when no activity matched
+	OriginalName string  `json:"original_name"`
+	Size         int64   `json:"size"`      // bytes
+	MimeType     string  `json:"mime_type"` // sniffed from the content
+	SHA256       string  `json:"sha256"`
+
+	VariantKeys    map[string]string `json:"-"`        // blob store keys by longest side in px
+	Variants       map[string]string `json:"variants"` // download URLs by longest side in px
+	VariantsStatus string            `json:"-"`        // pending, processing, done, failed or ""
+
+	// From the EXIF data
+	TakenAt    *time.Time `json:"taken_at"`    // exact, when the UTC offset is known
+	TakenLocal *string    `json:"taken_local"` // camera wall clock, TakenLocalLayout
+	Latitude   *float64   `json:"latitude"`
+	Longitude  *float64   `json:"longitude"`
+}
+
+// TakenLocalLayout formats Media.TakenLocal
+const TakenLocalLayout = "2006-01-02T15:04:05"
+
+// Variant generation states
+const (
+	VariantsPending    = "pending"
+	VariantsProcessing = "processing"
+	VariantsDone       = "done"
+	VariantsFailed     = "failed"
+)
+
+// mediaColumns are scanned by scanMedia
+const mediaColumns = `m.id, m.url, m.type, COALESCE(m.trip_id::text, ''), m.activity_id, m.itinerary_id,
+		COALESCE(m.original_name, ''), COALESCE(m.size_bytes, 0), COALESCE(m.mime_type, ''), COALESCE(m.sha256, ''),
+		m.variants, COALESCE(m.variants_status, ''),
+		m.taken_at, to_char(m.taken_local, 'YYYY-MM-DD"T"HH24:MI:SS'), m.latitude, m.longitude`
+
+func scanMedia(row pgx.Row, m *Media) error {
+	return row.Scan(
+		&m.ID, &m.Key, &m.Type, &m.TripID, &m.ActivityID, &m.ItineraryID,
+		&m.OriginalName, &m.Size, &m.MimeType, &m.SHA256,
+		&m.VariantKeys, &m.VariantsStatus,
+		&m.TakenAt, &m.TakenLocal, &m.Latitude, &m.Longitude,
+	)
+}
+
+func (r *MediaRepository) queryMedia(ctx context.Context, query string, args ...any) ([]Media, error) {
+	rows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	medias := []Media{}
+	for rows.Next() {
+		var m Media
+		if err := scanMedia(rows, &m); err != nil {
+			return nil, err
+		}
+		medias = append(medias, m)
+	}
+	return medias, rows.Err()
 }
 
 type MediaRepository struct {
@@ -23,33 +87,105 @@ func NewMediaRepository(db *pgxpool.Pool) *MediaRepository {
 
 func (r *MediaRepository) Create(ctx context.Context, media *Media) error {
 	query := `
-		INSERT INTO media (url, type, activity_id)
-		VALUES ($1, $2, $3)
+		INSERT INTO media (url, type, trip_id, activity_id, itinerary_id, original_name, size_bytes, mime_type, sha256,
+			variants_status, taken_at, taken_local, latitude, longitude)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11, $12::timestamp, $13, $14)
 		RETURNING id
 	`
-	err := r.DB.QueryRow(ctx, query, media.URL, media.Type, media.ActivityID).Scan(&media.ID)
+	err := r.DB.QueryRow(ctx, query, media.Key, media.Type, media.TripID, media.ActivityID, media.ItineraryID,
+		media.OriginalName, media.Size, media.MimeType, media.SHA256, media.VariantsStatus,
+		media.TakenAt, media.TakenLocal, media.Latitude, media.Longitude).Scan(&media.ID)
 	return err
 }
 
+func (r *MediaRepository) GetByID(ctx context.Context, id string) (*Media, error) {
+	query := `SELECT ` + mediaColumns + ` FROM media m WHERE m.id = $1`
+
+	var m Media
+	if err := scanMedia(r.DB.QueryRow(ctx, query, id), &m); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("media not found")
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
 func (r *MediaRepository) ListByActivityID(ctx context.Context, activityID string) ([]Media, error) {
 	query := `
-		SELECT id, url, type, activity_id
-		FROM media
-		WHERE activity_id = $1
+		SELECT ` + mediaColumns + `
+		FROM media m
+		WHERE m.activity_id = $1
+		ORDER BY m.taken_local NULLS LAST, m.created_at
 	`
-	rows, err := r.DB.Query(ctx, query, activityID)
+	return r.queryMedia(ctx, query, activityID)
+}
+
+// ListByTripID lists a trip's media in the order they were taken, with
+// unassigned only those not placed on an activity yet
+func (r *MediaRepository) ListByTripID(ctx context.Context, tripID string, unassigned bool) ([]Media, error) {
+	query := `
+		SELECT ` + mediaColumns + `
+		FROM media m
+		WHERE m.trip_id = $1 AND (NOT $2 OR m.activity_id IS NULL)
+		ORDER BY m.taken_local NULLS LAST, m.created_at
+	`
+	return r.queryMedia(ctx, query, tripID, unassigned)
+}
+
+// Assign places a media file on an activity and its day, or on a day only
+func (r *MediaRepository) Assign(ctx context.Context, id string, activityID, itineraryID *string) error {
+	ct, err := r.DB.Exec(ctx, `UPDATE media SET activity_id = $1, itinerary_id = $2 WHERE id = $3`, activityID, itineraryID, id)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
+	if ct.RowsAffected() == 0 {
+		return errors.New("media not found")
+	}
+	return nil
+}
 
-	var medias []Media
-	for rows.Next() {
-		var m Media
-		if err := rows.Scan(&m.ID, &m.URL, &m.Type, &m.ActivityID); err != nil {
-			return nil, err
-		}
-		medias = append(medias, m)
+// CountInTrip returns how many of ids belong to the trip
+func (r *MediaRepository) CountInTrip(ctx context.Context, tripID string, ids []string) (int, error) {
+	query := `
+		SELECT COUNT(DISTINCT m.id)
+		FROM media m
+		WHERE m.id = ANY($1) AND m.trip_id = $2
+	`
+	var count int
+	err := r.DB.QueryRow(ctx, query, ids, tripID).Scan(&count)
+	return count, err
+}
+
+// ClaimPendingVariants marks up to limit media waiting for variants as being
+// processed and returns them. Rows left processing for 10 minutes, by a
+// worker that died, are claimed again. Concurrent workers skip each other's
+// rows.
+func (r *MediaRepository) ClaimPendingVariants(ctx context.Context, limit int) ([]Media, error) {
+	query := `
+		UPDATE media m
+		SET variants_status = 'processing', variants_updated_at = NOW()
+		WHERE m.id IN (
+			SELECT id FROM media
+			WHERE variants_status = 'pending'
+				OR (variants_status = 'processing' AND variants_updated_at < NOW() - INTERVAL '10 minutes')
+			ORDER BY created_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + mediaColumns
+	return r.queryMedia(ctx, query, limit)
+}
+
+// SaveVariants records the generated variants and the final status
+func (r *MediaRepository) SaveVariants(ctx context.Context, id string, keys map[string]string, status string) error {
+	if keys == nil {
+		keys = map[string]string{}
 	}
-	return medias, nil
+	query := `
+		UPDATE media
+		SET variants = $1, variants_status = $2, variants_updated_at = NOW()
+		WHERE id = $3`
+	_, err := r.DB.Exec(ctx, query, keys, status, id)
+	return err
 }
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ParticipantRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewParticipantRepository(db *pgxpool.Pool) *ParticipantRepository {
+	return &ParticipantRepository{DB: db}
+}
+
+func (r *ParticipantRepository) Create(ctx context.Context, p *domain.Participant) error {
+	query := `
+		INSERT INTO trip_participants (trip_id, user_id, display_name, created_at)
+		VALUES ($1, $2, $3, NOW())
+		RETURNING id, created_at`
+
+	return r.DB.QueryRow(ctx, query, p.TripID, p.UserID, p.DisplayName).Scan(&p.ID, &p.CreatedAt)
+}
+
+func (r *ParticipantRepository) GetByID(ctx context.Context, id string) (*domain.Participant, error) {
+	query := `
+		SELECT id, trip_id, user_id, display_name, created_at
+		FROM trip_participants
+		WHERE id = $1`
+
+	var p domain.Participant
+	err := r.DB.QueryRow(ctx, query, id).Scan(&p.ID, &p.TripID, &p.UserID, &p.DisplayName, &p.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("participant not found")
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetByTripAndUser returns nil if the user is not a participant of the trip
+func (r *ParticipantRepository) GetByTripAndUser(ctx context.Context, tripID, userID string) (*domain.Participant, error) {
+	query := `
+		SELECT id, trip_id, user_id, display_name, created_at
+		FROM trip_participants
+		WHERE trip_id = $1 AND user_id = $2`
+
+	var p domain.Participant
+	err := r.DB.QueryRow(ctx, query, tripID, userID).Scan(&p.ID, &p.TripID, &p.UserID, &p.DisplayName, &p.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *ParticipantRepository) GetByTripID(ctx context.Context, tripID string) ([]domain.Participant, error) {
+	query := `
+		SELECT id, trip_id, user_id, display_name, created_at
+		FROM trip_participants
+		WHERE trip_id = $1
+		ORDER BY created_at ASC`
+
+	rows, err := r.DB.Query(ctx, query, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var participants []domain.Participant
+	for rows.Next() {
+		var p domain.Participant
+		if err := rows.Scan(&p.ID, &p.TripID, &p.UserID, &p.DisplayName, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		participants = append(participants, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return participants, nil
+}
+
+func (r *ParticipantRepository) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM trip_participants WHERE id = $1`
+	ct, err := r.DB.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return errors.New("participant not found")
+	}
+	return nil
+}
+
+// HasExpenses reports whether the participant paid or shares any expense or
+// settlement
+func (r *ParticipantRepository) HasExpenses(ctx context.Context, id string) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM expenses WHERE paid_by = $1)
+		    OR EXISTS (SELECT 1 FROM expense_splits WHERE participant_id = $1)`
+
+	var has bool
+	err := r.DB.QueryRow(ctx, query, id).Scan(&has)
+	return has, err
+}
+
+// AddOwner registers the trip owner as a participant, named after their profile
+func (r *ParticipantRepository) AddOwner(ctx context.Context, tripID, userID string) error {
+	query := `
+		INSERT INTO trip_participants (trip_id, user_id, display_name, created_at)
+		SELECT $1, u.id, COALESCE(u.full_name, u.email, 'Owner'), NOW()
+		FROM users u
+		WHERE u.id = $2
+		ON CONFLICT (trip_id, user_id) DO NOTHING`
+
+	_, err := r.DB.Exec(ctx, query, tripID, userID)
+	return err
+}
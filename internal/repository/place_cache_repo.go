@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PlaceCacheRepository stores place search results until they expire
+type PlaceCacheRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewPlaceCacheRepository(db *pgxpool.Pool) *PlaceCacheRepository {
+	return &PlaceCacheRepository{DB: db}
+}
+
+// Get returns the cached results for key, or nil if there are none or they expired
+func (r *PlaceCacheRepository) Get(ctx context.Context, key string) (json.RawMessage, error) {
+	var results json.RawMessage
+	err := r.DB.QueryRow(ctx,
+		`SELECT results FROM place_search_cache WHERE key = $1 AND expires_at > NOW()`, key,
+	).Scan(&results)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return results, err
+}
+
+// Put caches results for ttl and drops entries that have expired
+func (r *PlaceCacheRepository) Put(ctx context.Context, key, query string, results json.RawMessage, ttl time.Duration) error {
+	_, err := r.DB.Exec(ctx, `
+		INSERT INTO place_search_cache (key, query, results, created_at, expires_at)
+		VALUES ($1, $2, $3, NOW(), $4)
+		ON CONFLICT (key) DO UPDATE
+		SET query = EXCLUDED.query, results = EXCLUDED.results, created_at = NOW(), expires_at = EXCLUDED.expires_at`,
+		key, query, results, time.Now().Add(ttl))
+	if err != nil {
+		return err
+	}
+	_, err = r.DB.Exec(ctx, `DELETE FROM place_search_cache WHERE expires_at <= NOW()`)
+	return err
+}
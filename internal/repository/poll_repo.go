@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PollRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewPollRepository(db *pgxpool.Pool) *PollRepository {
+	return &PollRepository{DB: db}
+}
+
+const pollColumns = `id, trip_id, created_by, title, description, mode, closes_at, closed_at, promoted_activity_id, created_at, updated_at`
+
+func scanPoll(row pgx.Row, p *domain.Poll) error {
+	return row.Scan(
+		&p.ID,
+		&p.TripID,
+		&p.CreatedBy,
+		&p.Title,
+		&p.Description,
+		&p.Mode,
+		&p.ClosesAt,
+		&p.ClosedAt,
+		&p.PromotedActivityID,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+}
+
+func (r *PollRepository) Create(ctx context.Context, p *domain.Poll) error {
+	query := `
+		INSERT INTO polls (trip_id, created_by, title, description, mode, closes_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+		RETURNING id, created_at, updated_at`
+
+	return r.DB.QueryRow(ctx, query,
+		p.TripID,
+		p.CreatedBy,
+		p.Title,
+		p.Description,
+		p.Mode,
+		p.ClosesAt,
+	).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+}
+
+func (r *PollRepository) GetByID(ctx context.Context, id string) (*domain.Poll, error) {
+	query := `SELECT ` + pollColumns + ` FROM polls WHERE id = $1`
+
+	var p domain.Poll
+	if err := scanPoll(r.DB.QueryRow(ctx, query, id), &p); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("poll not found")
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetByTripID lists a trip's polls, newest first
+func (r *PollRepository) GetByTripID(ctx context.Context, tripID string) ([]domain.Poll, error) {
+	query := `SELECT ` + pollColumns + ` FROM polls WHERE trip_id = $1 ORDER BY created_at DESC`
+
+	rows, err := r.DB.Query(ctx, query, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	polls := []domain.Poll{}
+	for rows.Next() {
+		var p domain.Poll
+		if err := scanPoll(rows, &p); err != nil {
+			return nil, err
+		}
+		polls = append(polls, p)
+	}
+	return polls, rows.Err()
+}
+
+func (r *PollRepository) Update(ctx context.Context, p *domain.Poll) error {
+	query := `
+		UPDATE polls
+		SET title = $1, description = $2, closes_at = $3, closed_at = $4, promoted_activity_id = $5, updated_at = NOW()
+		WHERE id = $6
+		RETURNING updated_at`
+
+	err := r.DB.QueryRow(ctx, query,
+		p.Title,
+		p.Description,
+		p.ClosesAt,
+		p.ClosedAt,
+		p.PromotedActivityID,
+		p.ID,
+	).Scan(&p.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errors.New("poll not found")
+	}
+	return err
+}
+
+// ErrPollPromoted is returned when promoting a poll whose option was already promoted
+var ErrPollPromoted = errors.New("poll was already promoted")
+
+// Promote moves the activity into itineraryID at index, makes it planned
+// if it was an idea and closes the poll with it as the promoted activity,
+// all in one transaction.
+func (r *PollRepository) Promote(ctx context.Context, p *domain.Poll, activityID, itineraryID string, index int, actorID string) (*domain.Activity, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var promoted *string
+	err = tx.QueryRow(ctx, `SELECT promoted_activity_id FROM polls WHERE id = $1 FOR UPDATE`, p.ID).Scan(&promoted)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.New("poll not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if promoted != nil {
+		return nil, ErrPollPromoted
+	}
+
+	if err := moveActivity(ctx, tx, activityID, itineraryID, index); err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(ctx, `
+		WITH planned AS (
+			UPDATE activities SET status = $2, updated_at = NOW()
+			WHERE id = $1 AND status = $3
+			RETURNING id, updated_at
+		)
+		INSERT INTO activity_status_history (activity_id, from_status, to_status, actor_id, changed_at)
+		SELECT id, $3, $2, NULLIF($4, '')::uuid, updated_at FROM planned`,
+		activityID, domain.ActivityPlanned, domain.ActivityIdea, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRow(ctx, `
+		UPDATE polls
+		SET closed_at = COALESCE(closed_at, NOW()), promoted_activity_id = $1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING closed_at, promoted_activity_id, updated_at`,
+		activityID, p.ID,
+	).Scan(&p.ClosedAt, &p.PromotedActivityID, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	var a domain.Activity
+	err = scanActivity(tx.QueryRow(ctx, activitySelect+` WHERE a.id = $1`, activityID), &a)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *PollRepository) Delete(ctx context.Context, id string) error {
+	ct, err := r.DB.Exec(ctx, `DELETE FROM polls WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return errors.New("poll not found")
+	}
+	return nil
+}
+
+func (r *PollRepository) AddOption(ctx context.Context, o *domain.PollOption) error {
+	query := `
+		INSERT INTO poll_options (poll_id, activity_id, proposed_by, created_at)
+		VALUES ($1, $2, $3, NOW())
+		RETURNING id, created_at`
+
+	return r.DB.QueryRow(ctx, query, o.PollID, o.ActivityID, o.ProposedBy).Scan(&o.ID, &o.CreatedAt)
+}
+
+// GetOptions lists a poll's options in the order they were proposed
+func (r *PollRepository) GetOptions(ctx context.Context, pollID string) ([]domain.PollOption, error) {
+	query := `
+		SELECT id, poll_id, activity_id, proposed_by, created_at
+		FROM poll_options
+		WHERE poll_id = $1
+		ORDER BY created_at ASC, id ASC`
+
+	rows, err := r.DB.Query(ctx, query, pollID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	options := []domain.PollOption{}
+	for rows.Next() {
+		var o domain.PollOption
+		if err := rows.Scan(&o.ID, &o.PollID, &o.ActivityID, &o.ProposedBy, &o.CreatedAt); err != nil {
+			return nil, err
+		}
+		options = append(options, o)
+	}
+	return options, rows.Err()
+}
+
+func (r *PollRepository) DeleteOption(ctx context.Context, pollID, optionID string) error {
+	ct, err := r.DB.Exec(ctx, `DELETE FROM poll_options WHERE id = $1 AND poll_id = $2`, optionID, pollID)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return errors.New("option not found")
+	}
+	return nil
+}
+
+// SetBallot replaces the participant's votes in the poll with votes
+func (r *PollRepository) SetBallot(ctx context.Context, pollID, participantID string, votes []domain.PollVote) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `DELETE FROM poll_votes WHERE poll_id = $1 AND participant_id = $2`, pollID, participantID)
+	if err != nil {
+		return err
+	}
+	for i := range votes {
+		err := tx.QueryRow(ctx, `
+			INSERT INTO poll_votes (poll_id, option_id, participant_id, value, updated_at)
+			VALUES ($1, $2, $3, $4, NOW())
+			RETURNING updated_at`,
+			pollID, votes[i].OptionID, participantID, votes[i].Value,
+		).Scan(&votes[i].UpdatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// GetVotes returns every vote cast in the poll
+func (r *PollRepository) GetVotes(ctx context.Context, pollID string) ([]domain.PollVote, error) {
+	query := `
+		SELECT option_id, participant_id, value, updated_at
+		FROM poll_votes
+		WHERE poll_id = $1
+		ORDER BY participant_id, value`
+
+	rows, err := r.DB.Query(ctx, query, pollID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var votes []domain.PollVote
+	for rows.Next() {
+		var v domain.PollVote
+		if err := rows.Scan(&v.OptionID, &v.ParticipantID, &v.Value, &v.UpdatedAt); err != nil {
+			return nil, err
+		}
+		votes = append(votes, v)
+	}
+	return votes, rows.Err()
+}
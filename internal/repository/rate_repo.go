@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/currency"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RateRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewRateRepository(db *pgxpool.Pool) *RateRepository {
+	return &RateRepository{DB: db}
+}
+
+// Upsert stores rates, replacing any existing rate for the same day
+func (r *RateRepository) Upsert(ctx context.Context, rates []currency.Rate) error {
+	batch := &pgx.Batch{}
+	for _, rate := range rates {
+		batch.Queue(`
+			INSERT INTO exchange_rates (date, base, quote, rate)
+			VALUES ($1, $2, $3, $4::numeric)
+			ON CONFLICT (base, quote, date) DO UPDATE SET rate = EXCLUDED.rate`,
+			rate.Date, rate.Base, rate.Quote, rate.Value.FloatString(10))
+	}
+	return r.DB.SendBatch(ctx, batch).Close()
+}
+
+// GetLatest returns, for each quote currency, the most recent rate against
+// base published on or before date. Missing currencies are left out.
+func (r *RateRepository) GetLatest(ctx context.Context, base string, quotes []string, date time.Time) (map[string]currency.Rate, error) {
+	query := `
+		SELECT DISTINCT ON (quote) date, base, quote, rate::text
+		FROM exchange_rates
+		WHERE base = $1 AND quote = ANY($2) AND date <= $3
+		ORDER BY quote, date DESC`
+
+	rows, err := r.DB.Query(ctx, query, base, quotes, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := make(map[string]currency.Rate)
+	for rows.Next() {
+		var rate currency.Rate
+		var value string
+		if err := rows.Scan(&rate.Date, &rate.Base, &rate.Quote, &value); err != nil {
+			return nil, err
+		}
+		if rate.Value, err = currency.ParseRate(value); err != nil {
+			return nil, err
+		}
+		rates[rate.Quote] = rate
+	}
+	return rates, rows.Err()
+}
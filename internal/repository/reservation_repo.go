@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ReservationRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewReservationRepository(db *pgxpool.Pool) *ReservationRepository {
+	return &ReservationRepository{DB: db}
+}
+
+const reservationColumns = `id, activity_id, trip_id, type, provider, confirmation_number, starts_at, ends_at, cost_minor, currency, notes, details, created_at, updated_at`
+
+func scanReservation(row pgx.Row, r *domain.Reservation) error {
+	var details []byte
+	err := row.Scan(
+		&r.ID,
+		&r.ActivityID,
+		&r.TripID,
+		&r.Type,
+		&r.Provider,
+		&r.ConfirmationNumber,
+		&r.StartsAt,
+		&r.EndsAt,
+		&r.CostMinor,
+		&r.Currency,
+		&r.Notes,
+		&details,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(details, &r.Details)
+}
+
+// Create inserts the reservation and attaches mediaIDs in order
+func (r *ReservationRepository) Create(ctx context.Context, res *domain.Reservation, mediaIDs []string) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := insertReservation(ctx, tx, res, mediaIDs); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func insertReservation(ctx context.Context, tx pgx.Tx, res *domain.Reservation, mediaIDs []string) error {
+	details, err := json.Marshal(res.Details)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO reservations (activity_id, trip_id, type, provider, confirmation_number, starts_at, ends_at, cost_minor, currency, notes, details, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW(), NOW())
+		RETURNING id, created_at, updated_at`
+
+	err = tx.QueryRow(ctx, query,
+		res.ActivityID,
+		res.TripID,
+		res.Type,
+		res.Provider,
+		res.ConfirmationNumber,
+		res.StartsAt,
+		res.EndsAt,
+		res.CostMinor,
+		res.Currency,
+		res.Notes,
+		details,
+	).Scan(&res.ID, &res.CreatedAt, &res.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return setReservationAttachments(ctx, tx, res.ID, mediaIDs)
+}
+
+func setReservationAttachments(ctx context.Context, tx pgx.Tx, reservationID string, mediaIDs []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM reservation_attachments WHERE reservation_id = $1`, reservationID); err != nil {
+		return err
+	}
+	for i, mediaID := range mediaIDs {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO reservation_attachments (reservation_id, media_id, position) VALUES ($1, $2, $3)`,
+			reservationID, mediaID, i+1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ReservationRepository) GetByID(ctx context.Context, id string) (*domain.Reservation, error) {
+	query := `SELECT ` + reservationColumns + ` FROM reservations WHERE id = $1`
+
+	var res domain.Reservation
+	if err := scanReservation(r.DB.QueryRow(ctx, query, id), &res); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("reservation not found")
+		}
+		return nil, err
+	}
+
+	attachments, err := r.getAttachments(ctx, []string{res.ID})
+	if err != nil {
+		return nil, err
+	}
+	res.Attachments = attachments[res.ID]
+	return &res, nil
+}
+
+// GetByActivityID lists an activity's reservations by start time
+func (r *ReservationRepository) GetByActivityID(ctx context.Context, activityID string) ([]domain.Reservation, error) {
+	query := `SELECT ` + reservationColumns + `
+		FROM reservations
+		WHERE activity_id = $1
+		ORDER BY starts_at ASC NULLS LAST, created_at ASC`
+	return r.list(ctx, query, activityID)
+}
+
+// GetByTripID lists every reservation of a trip by start time
+func (r *ReservationRepository) GetByTripID(ctx context.Context, tripID string) ([]domain.Reservation, error) {
+	query := `SELECT ` + reservationColumns + `
+		FROM reservations
+		WHERE trip_id = $1
+		ORDER BY starts_at ASC NULLS LAST, created_at ASC`
+	return r.list(ctx, query, tripID)
+}
+
+func (r *ReservationRepository) list(ctx context.Context, query string, args ...any) ([]domain.Reservation, error) {
+	rows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reservations []domain.Reservation
+	var ids []string
+	for rows.Next() {
+		var res domain.Reservation
+		if err := scanReservation(rows, &res); err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, res)
+		ids = append(ids, res.ID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	attachments, err := r.getAttachments(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range reservations {
+		reservations[i].Attachments = attachments[reservations[i].ID]
+	}
+
+	return reservations, nil
+}
+
+func (r *ReservationRepository) getAttachments(ctx context.Context, reservationIDs []string) (map[string][]domain.ReservationAttachment, error) {
+	query := `
+		SELECT ra.reservation_id, m.id, m.url, m.type
+		FROM reservation_attachments ra
+		JOIN media m ON m.id = ra.media_id
+		WHERE ra.reservation_id = ANY($1)
+		ORDER BY ra.position ASC`
+
+	rows, err := r.DB.Query(ctx, query, reservationIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := make(map[string][]domain.ReservationAttachment)
+	for rows.Next() {
+		var reservationID string
+		var a domain.ReservationAttachment
+		if err := rows.Scan(&reservationID, &a.ID, &a.Key, &a.Type); err != nil {
+			return nil, err
+		}
+		attachments[reservationID] = append(attachments[reservationID], a)
+	}
+	return attachments, rows.Err()
+}
+
+// Update saves the reservation. A non-nil mediaIDs replaces the attachments.
+func (r *ReservationRepository) Update(ctx context.Context, res *domain.Reservation, mediaIDs []string) error {
+	details, err := json.Marshal(res.Details)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE reservations
+		SET type = $1, provider = $2, confirmation_number = $3, starts_at = $4, ends_at = $5, cost_minor = $6, currency = $7, notes = $8, details = $9, updated_at = NOW()
+		WHERE id = $10
+		RETURNING updated_at`
+
+	err = tx.QueryRow(ctx, query,
+		res.Type,
+		res.Provider,
+		res.ConfirmationNumber,
+		res.StartsAt,
+		res.EndsAt,
+		res.CostMinor,
+		res.Currency,
+		res.Notes,
+		details,
+		res.ID,
+	).Scan(&res.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errors.New("reservation not found")
+		}
+		return err
+	}
+
+	if mediaIDs != nil {
+		if err := setReservationAttachments(ctx, tx, res.ID, mediaIDs); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *ReservationRepository) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM reservations WHERE id = $1`
+	ct, err := r.DB.Exec(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return errors.New("reservation not found")
+	}
+	return nil
+}
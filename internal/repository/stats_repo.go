@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ActivityFact is the slice of an activity that statistics care about
+type ActivityFact struct {
+	TripID    string
+	Type      *string
+	Day       *time.Time // start_time's date, or the itinerary date
+	StartTime *time.Time
+	Latitude  *float64
+	Longitude *float64
+	Address   *string
+}
+
+// SpendFact is a single expense amount
+type SpendFact struct {
+	Currency    string
+	AmountMinor int64
+	IncurredAt  time.Time
+}
+
+type StatsRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewStatsRepository(db *pgxpool.Pool) *StatsRepository {
+	return &StatsRepository{DB: db}
+}
+
+// GetUserTrips returns trips the user owns or takes part in. With a date
+// range, only trips overlapping [from, to] are returned.
+func (r *StatsRepository) GetUserTrips(ctx context.Context, userID string, from, to *time.Time) ([]domain.Trip, error) {
+	query := `
+		SELECT t.id, t.user_id, t.location, t.start_date, t.end_date, t.created_at, t.updated_at
+		FROM trips t
+		WHERE (t.user_id = $1 OR EXISTS (
+			SELECT 1 FROM trip_participants p WHERE p.trip_id = t.id AND p.user_id = $1
+		))
+		AND ($2::date IS NULL OR t.end_date >= $2)
+		AND ($3::date IS NULL OR t.start_date <= $3)
+		ORDER BY t.start_date ASC`
+
+	rows, err := r.DB.Query(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trips []domain.Trip
+	for rows.Next() {
+		var t domain.Trip
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Location, &t.StartDate, &t.EndDate, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		trips = append(trips, t)
+	}
+	return trips, rows.Err()
+}
+
+// GetActivityFacts returns activities of the trips in visiting order
+func (r *StatsRepository) GetActivityFacts(ctx context.Context, tripIDs []string) ([]ActivityFact, error) {
+	query := `
+		SELECT a.trip_id, a.type, COALESCE(a.start_time::date, i.date), a.start_time, l.latitude, l.longitude, l.address
+		FROM activities a
+		LEFT JOIN itineraries i ON i.id = a.itinerary_id
+		LEFT JOIN locations l ON l.id = a.location_id
+		WHERE a.trip_id = ANY($1)
+		ORDER BY a.trip_id, COALESCE(a.start_time, i.date::timestamptz) ASC NULLS LAST, a.created_at ASC`
+
+	rows, err := r.DB.Query(ctx, query, tripIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var facts []ActivityFact
+	for rows.Next() {
+		var f ActivityFact
+		if err := rows.Scan(&f.TripID, &f.Type, &f.Day, &f.StartTime, &f.Latitude, &f.Longitude, &f.Address); err != nil {
+			return nil, err
+		}
+		facts = append(facts, f)
+	}
+	return facts, rows.Err()
+}
+
+// CountPhotos counts images uploaded to the trips. With a year, only photos
+// taken that year count, or uploaded that year when their capture time is
+// unknown.
+func (r *StatsRepository) CountPhotos(ctx context.Context, tripIDs []string, year *int) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM media m
+		WHERE m.trip_id = ANY($1) AND m.type = 'image'
+		AND ($2::int IS NULL OR EXTRACT(YEAR FROM COALESCE(m.taken_at, m.created_at) AT TIME ZONE 'UTC') = $2)`
+
+	var count int
+	err := r.DB.QueryRow(ctx, query, tripIDs, year).Scan(&count)
+	return count, err
+}
+
+// GetSpend returns every expense (settlements excluded) of the trips
+func (r *StatsRepository) GetSpend(ctx context.Context, tripIDs []string) ([]SpendFact, error) {
+	query := `
+		SELECT currency, amount_minor, incurred_at
+		FROM expenses
+		WHERE trip_id = ANY($1) AND kind = 'expense'`
+
+	rows, err := r.DB.Query(ctx, query, tripIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var facts []SpendFact
+	for rows.Next() {
+		var f SpendFact
+		if err := rows.Scan(&f.Currency, &f.AmountMinor, &f.IncurredAt); err != nil {
+			return nil, err
+		}
+		facts = append(facts, f)
+	}
+	return facts, rows.Err()
+}
@@ -112,15 +112,25 @@ func (r *TripRepository) Update(ctx context.Context, trip *domain.Trip) error {
 	return nil
 }
 
+// Delete removes the trip with everything in it. Expenses go first: they
+// keep their participants from being deleted, so the cascade from trips
+// could otherwise reach a participant before the expenses it paid.
 func (r *TripRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM trips WHERE id = $1`
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
 
-	commandTag, err := r.DB.Exec(ctx, query, id)
+	if _, err := tx.Exec(ctx, `DELETE FROM expenses WHERE trip_id = $1`, id); err != nil {
+		return err
+	}
+	commandTag, err := tx.Exec(ctx, `DELETE FROM trips WHERE id = $1`, id)
 	if err != nil {
 		return err
 	}
 	if commandTag.RowsAffected() == 0 {
 		return errors.New("trip not found")
 	}
-	return nil
+	return tx.Commit(ctx)
 }
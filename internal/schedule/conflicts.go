@@ -0,0 +1,222 @@
+package schedule
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/geo"
+)
+
+const (
+	ConflictOverlap          = "overlap"
+	ConflictInvalidRange     = "invalid_time_range"
+	ConflictOutsideItinerary = "outside_itinerary_date"
+	ConflictOutsideTrip      = "outside_trip_dates"
+	ConflictTravelTime       = "travel_time"
+)
+
+// Dates are stored without a timezone, so an activity only counts as outside
+// a date when it is outside that date in every timezone (UTC-12 to UTC+14).
+const (
+	earliestOffset = 14 * time.Hour
+	latestOffset   = 12 * time.Hour
+)
+
+// Item is an activity as seen by the conflict checker
+type Item struct {
+	ID            string
+	Name          string
+	ItineraryID   *string
+	ItineraryDate *time.Time
+	Start         *time.Time
+	End           *time.Time
+	Point         *geo.Point
+}
+
+// Options configures a check. Zero TripStart/TripEnd skip the trip range check.
+type Options struct {
+	TripStart time.Time
+	TripEnd   time.Time
+	// SpeedKmh is the assumed door-to-door travel speed between activities
+	SpeedKmh float64
+}
+
+type Conflict struct {
+	Type            string   `json:"type"`
+	ActivityIDs     []string `json:"activity_ids"`
+	Message         string   `json:"message"`
+	DistanceKm      *float64 `json:"distance_km,omitempty"`
+	GapMinutes      *float64 `json:"gap_minutes,omitempty"`
+	RequiredMinutes *float64 `json:"required_minutes,omitempty"`
+}
+
+// Involves reports whether the conflict concerns the given activity
+func (c Conflict) Involves(id string) bool {
+	for _, a := range c.ActivityIDs {
+		if a == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Detect returns every problem found in items. Overlaps and travel times are
+// checked between activities of the same itinerary. An activity covering
+// several days is passed as one item per day; a problem it has on more than
+// one of them is reported once.
+func Detect(items []Item, opts Options) []Conflict {
+	conflicts := []Conflict{}
+
+	for _, it := range items {
+		if it.Start != nil && it.End != nil && it.End.Before(*it.Start) {
+			conflicts = append(conflicts, Conflict{
+				Type:        ConflictInvalidRange,
+				ActivityIDs: []string{it.ID},
+				Message:     fmt.Sprintf("%q ends before it starts", it.Name),
+			})
+		}
+		if it.Start != nil && it.ItineraryDate != nil && !withinDates(it, *it.ItineraryDate, *it.ItineraryDate) {
+			conflicts = append(conflicts, Conflict{
+				Type:        ConflictOutsideItinerary,
+				ActivityIDs: []string{it.ID},
+				Message:     fmt.Sprintf("%q is not on its itinerary's date %s", it.Name, it.ItineraryDate.Format("2006-01-02")),
+			})
+		}
+		if it.Start != nil && !opts.TripStart.IsZero() && !withinDates(it, opts.TripStart, opts.TripEnd) {
+			conflicts = append(conflicts, Conflict{
+				Type:        ConflictOutsideTrip,
+				ActivityIDs: []string{it.ID},
+				Message:     fmt.Sprintf("%q is outside the trip's dates", it.Name),
+			})
+		}
+	}
+
+	for _, day := range byItinerary(items) {
+		conflicts = append(conflicts, overlaps(day)...)
+		if opts.SpeedKmh > 0 {
+			conflicts = append(conflicts, travelTimes(day, opts.SpeedKmh)...)
+		}
+	}
+
+	return unique(conflicts)
+}
+
+// unique drops repeats of a conflict type between the same activities
+func unique(conflicts []Conflict) []Conflict {
+	seen := make(map[string]bool, len(conflicts))
+	kept := conflicts[:0]
+	for _, c := range conflicts {
+		ids := append([]string(nil), c.ActivityIDs...)
+		sort.Strings(ids)
+		key := c.Type + "/" + strings.Join(ids, "/")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, c)
+	}
+	return kept
+}
+
+// withinDates checks that the activity touches [from, to] in some timezone
+func withinDates(it Item, from, to time.Time) bool {
+	earliest := from.Add(-earliestOffset)
+	latest := to.AddDate(0, 0, 1).Add(latestOffset)
+
+	end := *it.Start
+	if it.End != nil && it.End.After(end) {
+		end = *it.End
+	}
+	return !it.Start.Before(earliest) && end.Before(latest)
+}
+
+// byItinerary groups timed items per itinerary, sorted by start time
+func byItinerary(items []Item) [][]Item {
+	groups := make(map[string][]Item)
+	var keys []string
+	for _, it := range items {
+		if it.ItineraryID == nil || it.Start == nil {
+			continue
+		}
+		if _, ok := groups[*it.ItineraryID]; !ok {
+			keys = append(keys, *it.ItineraryID)
+		}
+		groups[*it.ItineraryID] = append(groups[*it.ItineraryID], it)
+	}
+
+	var days [][]Item
+	for _, k := range keys {
+		day := groups[k]
+		sort.SliceStable(day, func(i, j int) bool { return day[i].Start.Before(*day[j].Start) })
+		days = append(days, day)
+	}
+	return days
+}
+
+func endOf(it Item) time.Time {
+	if it.End != nil && it.End.After(*it.Start) {
+		return *it.End
+	}
+	return *it.Start
+}
+
+func overlaps(day []Item) []Conflict {
+	var conflicts []Conflict
+	for i := 0; i < len(day); i++ {
+		for j := i + 1; j < len(day); j++ {
+			// Sorted by start: once j starts after i ends, later ones do too
+			if !day[j].Start.Before(endOf(day[i])) {
+				break
+			}
+			if day[i].ID == day[j].ID {
+				continue
+			}
+			conflicts = append(conflicts, Conflict{
+				Type:        ConflictOverlap,
+				ActivityIDs: []string{day[i].ID, day[j].ID},
+				Message:     fmt.Sprintf("%q overlaps with %q", day[i].Name, day[j].Name),
+			})
+		}
+	}
+	return conflicts
+}
+
+// travelTimes flags back-to-back located activities whose gap is shorter
+// than the time needed to cover the distance between them
+func travelTimes(day []Item, speedKmh float64) []Conflict {
+	var conflicts []Conflict
+	for i := 0; i+1 < len(day); i++ {
+		prev, next := day[i], day[i+1]
+		if prev.Point == nil || next.Point == nil || prev.ID == next.ID {
+			continue
+		}
+		gap := next.Start.Sub(endOf(prev)).Minutes()
+		if gap < 0 {
+			continue // already reported as an overlap
+		}
+		distance := geo.Haversine(*prev.Point, *next.Point)
+		required := distance / speedKmh * 60
+		if gap >= required {
+			continue
+		}
+		distance = round1(distance)
+		gap = round1(gap)
+		required = round1(required)
+		conflicts = append(conflicts, Conflict{
+			Type:            ConflictTravelTime,
+			ActivityIDs:     []string{prev.ID, next.ID},
+			Message:         fmt.Sprintf("%.1f km from %q to %q needs about %.0f min but only %.0f min are planned", distance, prev.Name, next.Name, required, gap),
+			DistanceKm:      &distance,
+			GapMinutes:      &gap,
+			RequiredMinutes: &required,
+		})
+	}
+	return conflicts
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,142 @@
+package schedule
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/geo"
+)
+
+func at(hour, min int) *time.Time {
+	t := time.Date(2024, 5, 1, hour, min, 0, 0, time.UTC)
+	return &t
+}
+
+type found struct {
+	typ string
+	ids []string
+}
+
+func conflictsOf(conflicts []Conflict) []found {
+	got := []found{}
+	for _, c := range conflicts {
+		got = append(got, found{c.Type, c.ActivityIDs})
+	}
+	return got
+}
+
+func TestDetect(t *testing.T) {
+	day1, day2 := "day-1", "day-2"
+	may1 := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	louvre, versailles := &geo.Point{Lat: 48.8606, Lng: 2.3376}, &geo.Point{Lat: 48.8049, Lng: 2.1204}
+
+	tests := []struct {
+		name  string
+		items []Item
+		opts  Options
+		want  []found
+	}{
+		{
+			name: "back to back",
+			items: []Item{
+				{ID: "a", ItineraryID: &day1, Start: at(9, 0), End: at(10, 0)},
+				{ID: "b", ItineraryID: &day1, Start: at(10, 0), End: at(11, 0)},
+			},
+			want: []found{},
+		},
+		{
+			name: "overlap",
+			items: []Item{
+				{ID: "b", ItineraryID: &day1, Start: at(9, 30), End: at(11, 0)},
+				{ID: "a", ItineraryID: &day1, Start: at(9, 0), End: at(10, 0)},
+				{ID: "c", ItineraryID: &day1, Start: at(10, 30)},
+			},
+			want: []found{{ConflictOverlap, []string{"a", "b"}}, {ConflictOverlap, []string{"b", "c"}}},
+		},
+		{
+			name: "different days don't overlap",
+			items: []Item{
+				{ID: "a", ItineraryID: &day1, Start: at(9, 0), End: at(10, 0)},
+				{ID: "b", ItineraryID: &day2, Start: at(9, 0), End: at(10, 0)},
+			},
+			want: []found{},
+		},
+		{
+			name: "repeated on several days",
+			items: []Item{
+				{ID: "hotel", ItineraryID: &day1, Start: at(9, 0), End: at(12, 0)},
+				{ID: "tour", ItineraryID: &day1, Start: at(10, 0), End: at(11, 0)},
+				{ID: "hotel", ItineraryID: &day2, Start: at(9, 0), End: at(12, 0)},
+				{ID: "tour", ItineraryID: &day2, Start: at(10, 0), End: at(11, 0)},
+				{ID: "hotel", ItineraryID: &day2, Start: at(9, 0), End: at(12, 0)},
+			},
+			want: []found{{ConflictOverlap, []string{"hotel", "tour"}}},
+		},
+		{
+			name:  "ends before it starts",
+			items: []Item{{ID: "a", Start: at(10, 0), End: at(9, 0)}},
+			want:  []found{{ConflictInvalidRange, []string{"a"}}},
+		},
+		{
+			name: "outside its itinerary's date",
+			items: []Item{
+				{ID: "late evening", ItineraryID: &day1, ItineraryDate: &may1, Start: at(23, 0)},
+				{ID: "next day", ItineraryID: &day2, ItineraryDate: &may1, Start: func() *time.Time { t := may1.AddDate(0, 0, 2); return &t }()},
+			},
+			want: []found{{ConflictOutsideItinerary, []string{"next day"}}},
+		},
+		{
+			name:  "outside the trip",
+			items: []Item{{ID: "a", Start: at(9, 0)}},
+			opts:  Options{TripStart: may1.AddDate(0, 0, 3), TripEnd: may1.AddDate(0, 0, 5)},
+			want:  []found{{ConflictOutsideTrip, []string{"a"}}},
+		},
+		{
+			name: "not enough time to get there",
+			items: []Item{
+				{ID: "louvre", ItineraryID: &day1, Start: at(9, 0), End: at(12, 0), Point: louvre},
+				{ID: "versailles", ItineraryID: &day1, Start: at(12, 10), Point: versailles},
+			},
+			opts: Options{SpeedKmh: 30},
+			want: []found{{ConflictTravelTime, []string{"louvre", "versailles"}}},
+		},
+		{
+			name: "enough time to get there",
+			items: []Item{
+				{ID: "louvre", ItineraryID: &day1, Start: at(9, 0), End: at(12, 0), Point: louvre},
+				{ID: "versailles", ItineraryID: &day1, Start: at(13, 0), Point: versailles},
+			},
+			opts: Options{SpeedKmh: 30},
+			want: []found{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := conflictsOf(Detect(tt.items, tt.opts))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectTravelTimeFigures(t *testing.T) {
+	day := "day-1"
+	items := []Item{
+		{ID: "a", ItineraryID: &day, Start: at(9, 0), End: at(10, 0), Point: &geo.Point{Lat: 0, Lng: 0}},
+		{ID: "b", ItineraryID: &day, Start: at(10, 15), Point: &geo.Point{Lat: 0, Lng: 0.1}},
+	}
+	conflicts := Detect(items, Options{SpeedKmh: 30})
+	if len(conflicts) != 1 {
+		t.Fatalf("got %d conflicts, want 1", len(conflicts))
+	}
+	c := conflicts[0]
+	// 0.1° along the equator is 11.1 km, 22.2 min at 30 km/h
+	if *c.DistanceKm != 11.1 || *c.GapMinutes != 15 || *c.RequiredMinutes != 22.2 {
+		t.Errorf("got %.1f km, %.1f min planned, %.1f min needed", *c.DistanceKm, *c.GapMinutes, *c.RequiredMinutes)
+	}
+	if !c.Involves("a") || !c.Involves("b") || c.Involves("c") {
+		t.Errorf("Involves is wrong for %v", c.ActivityIDs)
+	}
+}
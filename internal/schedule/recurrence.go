@@ -0,0 +1,198 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxOccurrences bounds how many dates a rule expands to
+const MaxOccurrences = 366
+
+// Rule is the subset of an RFC 5545 RRULE we support: FREQ=DAILY or WEEKLY
+// with INTERVAL, COUNT, UNTIL and BYDAY.
+type Rule struct {
+	Freq     string // DAILY or WEEKLY
+	Interval int
+	Count    int        // 0 means unbounded
+	Until    *time.Time // last allowed date, inclusive
+	ByDay    []time.Weekday
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// ParseRule reads an RRULE such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE".
+// The "RRULE:" prefix is optional.
+func ParseRule(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "RRULE:")
+	if s == "" {
+		return nil, errors.New("empty recurrence rule")
+	}
+
+	r := &Rule{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("malformed rule part %q", part)
+		}
+		switch key {
+		case "FREQ":
+			if value != "DAILY" && value != "WEEKLY" {
+				return nil, fmt.Errorf("unsupported FREQ %q, use DAILY or WEEKLY", value)
+			}
+			r.Freq = value
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, errors.New("INTERVAL must be a positive integer")
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, errors.New("COUNT must be a positive integer")
+			}
+			r.Count = n
+		case "UNTIL":
+			until, err := parseRuleDate(value)
+			if err != nil {
+				return nil, err
+			}
+			r.Until = &until
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				day, ok := weekdayCodes[code]
+				if !ok {
+					return nil, fmt.Errorf("unsupported BYDAY value %q", code)
+				}
+				r.ByDay = append(r.ByDay, day)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported rule part %s", key)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, errors.New("FREQ is required")
+	}
+	if r.Count > 0 && r.Until != nil {
+		return nil, errors.New("COUNT and UNTIL cannot both be set")
+	}
+	// Monday-first order makes weekly expansion a single pass per week
+	sort.Slice(r.ByDay, func(i, j int) bool { return mondayIndex(r.ByDay[i]) < mondayIndex(r.ByDay[j]) })
+	return r, nil
+}
+
+// parseRuleDate accepts UNTIL as a date or a UTC date-time
+func parseRuleDate(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102", "20060102T150405Z", "20060102T150405"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return dateOf(t), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("UNTIL %q must look like 20240131", value)
+}
+
+// String renders the rule in a normalized form
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			codes[i] = strings.ToUpper(day.String()[:2])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Expand returns the dates the rule produces from start through end
+// (inclusive), as UTC midnights. Only dates matching the rule count, so a
+// start that isn't one of BYDAY's days is not itself an occurrence.
+func (r *Rule) Expand(start, end time.Time) []time.Time {
+	start, end = dateOf(start), dateOf(end)
+	if r.Until != nil && r.Until.Before(end) {
+		end = *r.Until
+	}
+
+	var dates []time.Time
+	emit := func(d time.Time) bool {
+		if d.Before(start) {
+			return true
+		}
+		if d.After(end) || len(dates) >= MaxOccurrences || (r.Count > 0 && len(dates) >= r.Count) {
+			return false
+		}
+		dates = append(dates, d)
+		return true
+	}
+
+	switch r.Freq {
+	case "DAILY":
+		for d := start; !d.After(end); d = d.AddDate(0, 0, r.Interval) {
+			if len(r.ByDay) > 0 && !r.onDay(d.Weekday()) {
+				continue
+			}
+			if !emit(d) {
+				break
+			}
+		}
+	case "WEEKLY":
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []time.Weekday{start.Weekday()}
+		}
+		weekStart := start.AddDate(0, 0, -mondayIndex(start.Weekday()))
+		for week := weekStart; !week.After(end); week = week.AddDate(0, 0, 7*r.Interval) {
+			for _, day := range days {
+				if !emit(week.AddDate(0, 0, mondayIndex(day))) {
+					return dates
+				}
+			}
+		}
+	}
+	return dates
+}
+
+// Matches reports whether date is one of the rule's occurrences from start
+func (r *Rule) Matches(start, date time.Time) bool {
+	date = dateOf(date)
+	for _, d := range r.Expand(start, date) {
+		if d.Equal(date) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) onDay(day time.Weekday) bool {
+	for _, d := range r.ByDay {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+func mondayIndex(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
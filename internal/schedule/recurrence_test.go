@@ -0,0 +1,131 @@
+package schedule
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(month time.Month, day int) time.Time {
+	return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func formatDates(dates []time.Time) string {
+	s := make([]string, len(dates))
+	for i, d := range dates {
+		s[i] = d.Format("Mon 01-02")
+	}
+	return strings.Join(s, ", ")
+}
+
+func TestParseRule(t *testing.T) {
+	until := date(time.May, 31)
+	tests := []struct {
+		in   string
+		want Rule
+		str  string
+	}{
+		{"FREQ=DAILY", Rule{Freq: "DAILY", Interval: 1}, "FREQ=DAILY"},
+		{"rrule:freq=daily;count=5", Rule{Freq: "DAILY", Interval: 1, Count: 5}, "FREQ=DAILY;COUNT=5"},
+		{
+			"FREQ=WEEKLY;INTERVAL=2;BYDAY=FR,MO",
+			Rule{Freq: "WEEKLY", Interval: 2, ByDay: []time.Weekday{time.Monday, time.Friday}},
+			"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR",
+		},
+		{"FREQ=WEEKLY;BYDAY=SU,SA", Rule{Freq: "WEEKLY", Interval: 1, ByDay: []time.Weekday{time.Saturday, time.Sunday}}, "FREQ=WEEKLY;BYDAY=SA,SU"},
+		{"FREQ=DAILY;UNTIL=20240531", Rule{Freq: "DAILY", Interval: 1, Until: &until}, "FREQ=DAILY;UNTIL=20240531"},
+		{"FREQ=DAILY;UNTIL=20240531T235959Z", Rule{Freq: "DAILY", Interval: 1, Until: &until}, "FREQ=DAILY;UNTIL=20240531"},
+	}
+	for _, tt := range tests {
+		got, err := ParseRule(tt.in)
+		if err != nil {
+			t.Errorf("ParseRule(%q): %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("ParseRule(%q) = %+v, want %+v", tt.in, *got, tt.want)
+		}
+		if s := got.String(); s != tt.str {
+			t.Errorf("ParseRule(%q).String() = %q, want %q", tt.in, s, tt.str)
+		}
+	}
+}
+
+func TestParseRuleErrors(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"RRULE:",
+		"FREQ=MONTHLY",
+		"INTERVAL=2",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=-1",
+		"FREQ=DAILY;COUNT=3;UNTIL=20240531",
+		"FREQ=DAILY;UNTIL=31/05/2024",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=DAILY;BYMONTH=5",
+		"FREQ=DAILY;COUNT",
+	} {
+		if _, err := ParseRule(in); err == nil {
+			t.Errorf("ParseRule(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	// 2024-05-01 is a Wednesday
+	tests := []struct {
+		rule       string
+		start, end time.Time
+		want       string
+	}{
+		{"FREQ=DAILY", date(time.May, 1), date(time.May, 3), "Wed 05-01, Thu 05-02, Fri 05-03"},
+		{"FREQ=DAILY;COUNT=2", date(time.May, 1), date(time.May, 31), "Wed 05-01, Thu 05-02"},
+		{"FREQ=DAILY;INTERVAL=3", date(time.May, 1), date(time.May, 10), "Wed 05-01, Sat 05-04, Tue 05-07, Fri 05-10"},
+		{"FREQ=DAILY;UNTIL=20240502", date(time.May, 1), date(time.May, 31), "Wed 05-01, Thu 05-02"},
+		{"FREQ=DAILY;BYDAY=SA,SU", date(time.May, 1), date(time.May, 12), "Sat 05-04, Sun 05-05, Sat 05-11, Sun 05-12"},
+		{"FREQ=WEEKLY", date(time.May, 1), date(time.May, 20), "Wed 05-01, Wed 05-08, Wed 05-15"},
+		{"FREQ=WEEKLY;BYDAY=MO,FR", date(time.May, 1), date(time.May, 13), "Fri 05-03, Mon 05-06, Fri 05-10, Mon 05-13"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", date(time.May, 1), date(time.May, 31), "Mon 05-13, Mon 05-27"},
+		{"FREQ=WEEKLY;BYDAY=MO,TU;COUNT=3", date(time.May, 1), date(time.May, 31), "Mon 05-06, Tue 05-07, Mon 05-13"},
+		{"FREQ=DAILY", date(time.May, 3), date(time.May, 1), ""},
+	}
+	for _, tt := range tests {
+		r, err := ParseRule(tt.rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := formatDates(r.Expand(tt.start, tt.end)); got != tt.want {
+			t.Errorf("%s from %s to %s: got [%s], want [%s]", tt.rule, tt.start.Format("01-02"), tt.end.Format("01-02"), got, tt.want)
+		}
+	}
+}
+
+func TestExpandIgnoresTimeOfDay(t *testing.T) {
+	r, _ := ParseRule("FREQ=DAILY")
+	start := time.Date(2024, 5, 1, 22, 30, 0, 0, time.UTC)
+	end := time.Date(2024, 5, 2, 8, 0, 0, 0, time.UTC)
+	if got := formatDates(r.Expand(start, end)); got != "Wed 05-01, Thu 05-02" {
+		t.Errorf("got [%s]", got)
+	}
+}
+
+func TestExpandIsBounded(t *testing.T) {
+	r, _ := ParseRule("FREQ=DAILY")
+	if got := len(r.Expand(date(time.January, 1), date(time.January, 1).AddDate(5, 0, 0))); got != MaxOccurrences {
+		t.Errorf("got %d dates, want %d", got, MaxOccurrences)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	r, _ := ParseRule("FREQ=WEEKLY;INTERVAL=2;BYDAY=MO")
+	start := date(time.May, 1)
+	for day, want := range map[int]bool{6: false, 13: true, 20: false, 27: true, 28: false} {
+		if got := r.Matches(start, date(time.May, day)); got != want {
+			t.Errorf("Matches(05-%02d) = %v, want %v", day, got, want)
+		}
+	}
+	if r.Matches(start, date(time.April, 29)) {
+		t.Error("matched a date before the start")
+	}
+}
@@ -0,0 +1,195 @@
+package schedule
+
+import (
+	"math"
+
+	"github.com/NoahFola/travel_app_backend/internal/geo"
+)
+
+// Stop is a place to visit. Fixed stops keep their relative order (they are
+// time anchors); the others may be moved anywhere. Stops without a Point
+// don't contribute to distances.
+type Stop struct {
+	ID    string
+	Point *geo.Point
+	Fixed bool
+}
+
+// Leg is the distance travelled to reach a stop from the previous located one
+type Leg struct {
+	StopID     string
+	DistanceKm float64
+}
+
+// maxImprovementRounds bounds the local search for very long days
+const maxImprovementRounds = 50
+
+// OptimizeRoute proposes a visiting order for stops that keeps fixed stops
+// in the given order and minimises the total straight-line distance,
+// optionally starting from and returning to a given point (e.g. the hotel).
+//
+// Free stops are placed by cheapest insertion, then improved by relocating
+// single stops and reversing runs of free stops (2-opt) until nothing helps.
+// Free stops without a Point can't be placed sensibly and go last.
+func OptimizeRoute(stops []Stop, start, end *geo.Point) []Stop {
+	// The start and end points are kept as fixed stops at either end of the route
+	ends := routeEnds{start: start != nil, end: end != nil}
+	route := []Stop{}
+	if ends.start {
+		route = append(route, Stop{Point: start, Fixed: true})
+	}
+	for _, s := range stops {
+		if s.Fixed {
+			route = append(route, s)
+		}
+	}
+	if ends.end {
+		route = append(route, Stop{Point: end, Fixed: true})
+	}
+
+	var unplaced []Stop
+	for _, s := range stops {
+		if s.Fixed {
+			continue
+		}
+		if s.Point == nil {
+			unplaced = append(unplaced, s)
+			continue
+		}
+		route = insertAt(route, s, ends.bestInsertion(route, s))
+	}
+
+	for round := 0; round < maxImprovementRounds; round++ {
+		if !ends.relocate(&route) && !twoOpt(&route) {
+			break
+		}
+	}
+
+	// Drop the start and end points
+	if ends.start {
+		route = route[1:]
+	}
+	if ends.end {
+		route = route[:len(route)-1]
+	}
+	result := make([]Stop, 0, len(stops))
+	result = append(result, route...)
+	return append(result, unplaced...)
+}
+
+// routeEnds tells whether a route begins with a start point and ends with
+// an end point, which no stop may be moved before or after
+type routeEnds struct {
+	start bool
+	end   bool
+}
+
+// RouteLegs returns the leg to each stop and the total distance of visiting
+// stops in order from start to end
+func RouteLegs(stops []Stop, start, end *geo.Point) ([]Leg, float64) {
+	legs := make([]Leg, 0, len(stops))
+	prev := start
+	total := 0.0
+	for _, s := range stops {
+		leg := Leg{StopID: s.ID}
+		if s.Point != nil {
+			if prev != nil {
+				leg.DistanceKm = geo.Haversine(*prev, *s.Point)
+				total += leg.DistanceKm
+			}
+			prev = s.Point
+		}
+		legs = append(legs, leg)
+	}
+	if end != nil && prev != nil {
+		total += geo.Haversine(*prev, *end)
+	}
+	return legs, total
+}
+
+// routeCost sums distances between consecutive located stops
+func routeCost(route []Stop) float64 {
+	var prev *geo.Point
+	total := 0.0
+	for _, s := range route {
+		if s.Point == nil {
+			continue
+		}
+		if prev != nil {
+			total += geo.Haversine(*prev, *s.Point)
+		}
+		prev = s.Point
+	}
+	return total
+}
+
+func insertAt(route []Stop, s Stop, i int) []Stop {
+	route = append(route, Stop{})
+	copy(route[i+1:], route[i:])
+	route[i] = s
+	return route
+}
+
+func removeAt(route []Stop, i int) []Stop {
+	return append(route[:i:i], route[i+1:]...)
+}
+
+// bestInsertion returns the index at which inserting s adds the least
+// distance, between the start and end points
+func (e routeEnds) bestInsertion(route []Stop, s Stop) int {
+	first, last := 0, len(route)
+	if e.start {
+		first = 1
+	}
+	if e.end {
+		last = len(route) - 1
+	}
+	best, bestCost := last, math.Inf(1)
+	for i := first; i <= last; i++ {
+		cost := routeCost(insertAt(append([]Stop{}, route...), s, i))
+		if cost < bestCost-1e-9 {
+			best, bestCost = i, cost
+		}
+	}
+	return best
+}
+
+// relocate moves a single free stop to a better position if one exists
+func (e routeEnds) relocate(route *[]Stop) bool {
+	current := routeCost(*route)
+	for i, s := range *route {
+		if s.Fixed {
+			continue
+		}
+		without := removeAt(append([]Stop{}, (*route)...), i)
+		j := e.bestInsertion(without, s)
+		candidate := insertAt(without, s, j)
+		if routeCost(candidate) < current-1e-9 {
+			*route = candidate
+			return true
+		}
+	}
+	return false
+}
+
+// twoOpt reverses a run of free stops if that shortens the route
+func twoOpt(route *[]Stop) bool {
+	r := *route
+	current := routeCost(r)
+	for i := 0; i < len(r); i++ {
+		if r[i].Fixed {
+			continue
+		}
+		for j := i + 1; j < len(r) && !r[j].Fixed; j++ {
+			candidate := append([]Stop{}, r...)
+			for a, b := i, j; a < b; a, b = a+1, b-1 {
+				candidate[a], candidate[b] = candidate[b], candidate[a]
+			}
+			if routeCost(candidate) < current-1e-9 {
+				*route = candidate
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package schedule
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/NoahFola/travel_app_backend/internal/geo"
+)
+
+func point(lat, lng float64) *geo.Point {
+	return &geo.Point{Lat: lat, Lng: lng}
+}
+
+func stopIDs(stops []Stop) []string {
+	ids := make([]string, len(stops))
+	for i, s := range stops {
+		ids[i] = s.ID
+	}
+	return ids
+}
+
+func reversed(ids []string) []string {
+	r := make([]string, len(ids))
+	for i, id := range ids {
+		r[len(ids)-1-i] = id
+	}
+	return r
+}
+
+func TestOptimizeRouteEnds(t *testing.T) {
+	// Along the equator, 0.1° of longitude is about 11 km
+	near := Stop{ID: "near", Point: point(0, 0.1)}
+	mid := Stop{ID: "mid", Point: point(0, 0.5)}
+	far := Stop{ID: "far", Point: point(0, 0.9)}
+
+	tests := []struct {
+		name       string
+		stops      []Stop
+		start, end *geo.Point
+		want       []string
+		reversible bool // the reverse order is as short
+	}{
+		{
+			name:       "no start or end",
+			stops:      []Stop{far, near, mid},
+			want:       []string{"near", "mid", "far"},
+			reversible: true,
+		},
+		{
+			name:  "start only",
+			stops: []Stop{far, near},
+			start: point(0, 0),
+			want:  []string{"near", "far"},
+		},
+		{
+			name:  "end only",
+			stops: []Stop{far, near},
+			end:   point(0, 1),
+			want:  []string{"near", "far"},
+		},
+		{
+			name:  "end only, back towards the start",
+			stops: []Stop{near, far},
+			end:   point(0, 0),
+			want:  []string{"far", "near"},
+		},
+		{
+			name:  "start and end",
+			stops: []Stop{far, near, mid},
+			start: point(0, 0),
+			end:   point(0, 1),
+			want:  []string{"near", "mid", "far"},
+		},
+		{
+			name:       "round trip from the hotel",
+			stops:      []Stop{far, near, mid},
+			start:      point(0, 0),
+			end:        point(0, 0),
+			want:       []string{"near", "mid", "far"},
+			reversible: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stopIDs(OptimizeRoute(tt.stops, tt.start, tt.end))
+			if reflect.DeepEqual(got, tt.want) || (tt.reversible && reflect.DeepEqual(got, reversed(tt.want))) {
+				return
+			}
+			t.Errorf("got order %v, want %v", got, tt.want)
+		})
+	}
+}
+
+func TestOptimizeRouteEndOnlyDistance(t *testing.T) {
+	stops := []Stop{{ID: "a", Point: point(0, 0.9)}, {ID: "b", Point: point(0, 0.1)}}
+	end := point(0, 1)
+	_, total := RouteLegs(OptimizeRoute(stops, nil, end), nil, end)
+	if total > 101 {
+		t.Errorf("got %.1f km, want the 100 km route", total)
+	}
+}
+
+func TestOptimizeRouteKeepsFixedOrder(t *testing.T) {
+	stops := []Stop{
+		{ID: "breakfast", Point: point(0, 0.9), Fixed: true},
+		{ID: "museum", Point: point(0, 0.5)},
+		{ID: "dinner", Point: point(0, 0.1), Fixed: true},
+		{ID: "park", Point: point(0, 0.8)},
+	}
+	got := stopIDs(OptimizeRoute(stops, nil, nil))
+	want := []string{"breakfast", "park", "museum", "dinner"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got order %v, want %v", got, want)
+	}
+}
+
+func TestOptimizeRouteUnlocatedStopsGoLast(t *testing.T) {
+	stops := []Stop{
+		{ID: "somewhere"},
+		{ID: "b", Point: point(0, 0.2)},
+		{ID: "a", Point: point(0, 0.1)},
+	}
+	got := stopIDs(OptimizeRoute(stops, point(0, 0), nil))
+	want := []string{"a", "b", "somewhere"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got order %v, want %v", got, want)
+	}
+}
+
+func TestRouteLegs(t *testing.T) {
+	stops := []Stop{{ID: "a", Point: point(0, 1)}, {ID: "unknown"}, {ID: "b", Point: point(0, 2)}}
+	legs, total := RouteLegs(stops, point(0, 0), point(0, 0))
+
+	km := geo.Haversine(geo.Point{}, geo.Point{Lng: 1})
+	wantLegs := []Leg{{StopID: "a", DistanceKm: km}, {StopID: "unknown"}, {StopID: "b", DistanceKm: km}}
+	for i := range wantLegs {
+		if legs[i].StopID != wantLegs[i].StopID || math.Abs(legs[i].DistanceKm-wantLegs[i].DistanceKm) > 1e-6 {
+			t.Errorf("leg %d: got %+v, want %+v", i, legs[i], wantLegs[i])
+		}
+	}
+	if math.Abs(total-4*km) > 1e-6 {
+		t.Errorf("got total %.3f km, want %.3f", total, 4*km)
+	}
+}
@@ -3,42 +3,246 @@ package service
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/places"
 	"github.com/NoahFola/travel_app_backend/internal/repository"
 )
 
+// ErrLocationNotFound is returned when an activity references an unknown location
+var ErrLocationNotFound = errors.New("location not found")
+
+// ErrInvalidPlace is returned for place search results missing their ID or name
+var ErrInvalidPlace = errors.New("place must have a place_id and name")
+
+// ErrItineraryNotFound is returned when an activity is put on an unknown day
+var ErrItineraryNotFound = errors.New("itinerary not found")
+
+// ErrTripNotFound is returned when a backlog activity names an unknown trip
+var ErrTripNotFound = errors.New("trip not found")
+
 type ActivityService struct {
 	Repo          *repository.ActivityRepository
 	ItineraryRepo *repository.ItineraryRepository
+	TripRepo      *repository.TripRepository
+	Conflicts     *ConflictService
+	Locations     *LocationService
 }
 
-func NewActivityService(repo *repository.ActivityRepository, itineraryRepo *repository.ItineraryRepository) *ActivityService {
+func NewActivityService(repo *repository.ActivityRepository, itineraryRepo *repository.ItineraryRepository, tripRepo *repository.TripRepository, conflicts *ConflictService, locations *LocationService) *ActivityService {
 	return &ActivityService{
 		Repo:          repo,
 		ItineraryRepo: itineraryRepo,
+		TripRepo:      tripRepo,
+		Conflicts:     conflicts,
+		Locations:     locations,
 	}
 }
 
-func (s *ActivityService) CreateActivity(ctx context.Context, activity *domain.Activity) error {
-	// Verify itinerary exists if provided (it MUST be provided for now based on handler)
-	if activity.ItineraryID == nil {
-		return errors.New("itinerary_id is required")
+// AttachPlace links the activity to a place search result, saving the
+// place as a location if we haven't seen it before
+func (s *ActivityService) AttachPlace(ctx context.Context, activity *domain.Activity, place places.Place) error {
+	if place.PlaceID == "" || place.Name == "" {
+		return ErrInvalidPlace
 	}
+	loc, err := s.Locations.GetOrCreateLocation(ctx, place)
+	if err != nil {
+		return err
+	}
+	activity.LocationID = &loc.ID
+	activity.Place = loc
+	return nil
+}
 
-	itinerary, err := s.ItineraryRepo.GetByID(ctx, *activity.ItineraryID)
+// resolveLocation checks location_id and embeds the location it points to.
+// The free-text location falls back to the place name.
+func (s *ActivityService) resolveLocation(ctx context.Context, activity *domain.Activity) error {
+	if activity.LocationID == nil {
+		activity.Place = nil
+		return nil
+	}
+	if activity.Place == nil || activity.Place.ID != *activity.LocationID {
+		loc, err := s.Locations.Repo.GetByID(ctx, *activity.LocationID)
+		if err != nil {
+			return err
+		}
+		if loc == nil {
+			return ErrLocationNotFound
+		}
+		activity.Place = loc
+	}
+	if activity.Location == nil || *activity.Location == "" {
+		name := activity.Place.Name
+		activity.Location = &name
+	}
+	return nil
+}
+
+// localTimeLayouts are the accepted wall-clock formats for local times
+var localTimeLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// SetLocalTimes sets the start and end from wall-clock times such as
+// "2024-05-01T09:00", read in timezone when given and in the timezone of
+// the activity's place otherwise. Times with an offset are taken as is.
+func (s *ActivityService) SetLocalTimes(ctx context.Context, activity *domain.Activity, start, end *string, timezone string) error {
+	if start == nil && end == nil {
+		return nil
+	}
+	if timezone == "" {
+		if err := s.resolveLocation(ctx, activity); err != nil {
+			return err
+		}
+		if activity.Place != nil {
+			timezone = activity.Place.Timezone
+		}
+	}
+	if timezone == "" {
+		return invalidSchedule("local times need a place or a timezone")
+	}
+	zone, err := time.LoadLocation(timezone)
+	if err != nil {
+		return invalidSchedule("unknown timezone %q", timezone)
+	}
+
+	for _, f := range []struct {
+		value *string
+		dest  **time.Time
+		name  string
+	}{
+		{start, &activity.StartTime, "local_start_time"},
+		{end, &activity.EndTime, "local_end_time"},
+	} {
+		if f.value == nil {
+			continue
+		}
+		t, err := parseLocalTime(*f.value, zone)
+		if err != nil {
+			return invalidSchedule("%s must look like 2024-05-01T09:00", f.name)
+		}
+		*f.dest = &t
+	}
+	return nil
+}
+
+func parseLocalTime(value string, zone *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	var err error
+	for _, layout := range localTimeLayouts {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, value, zone); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// rejectOnConflict returns a *ConflictError if the activity would conflict
+func (s *ActivityService) rejectOnConflict(ctx context.Context, activity *domain.Activity) error {
+	conflicts, err := s.Conflicts.CheckActivity(ctx, activity)
 	if err != nil {
-		return errors.New("itinerary not found")
+		return err
+	}
+	if len(conflicts) > 0 {
+		return &ConflictError{Conflicts: conflicts}
+	}
+	return nil
+}
+
+// CreateActivity saves a new activity, recording actorID as the author of
+// its initial status. Without an itinerary the activity goes to the trip's
+// unscheduled backlog, as an idea unless a status is given. With
+// rejectConflicts, the write is refused with a *ConflictError if it clashes
+// with the rest of the trip.
+func (s *ActivityService) CreateActivity(ctx context.Context, activity *domain.Activity, actorID string, rejectConflicts bool) error {
+	var start *time.Time
+	if activity.ItineraryID != nil {
+		itinerary, err := s.ItineraryRepo.GetByID(ctx, *activity.ItineraryID)
+		if err != nil {
+			return ErrItineraryNotFound
+		}
+		if activity.TripID != "" && activity.TripID != itinerary.TripID {
+			return invalidSchedule("itinerary belongs to a different trip")
+		}
+		// Set the TripID from the Itinerary
+		activity.TripID = itinerary.TripID
+		start = &itinerary.Date
+	} else {
+		if activity.TripID == "" {
+			return errors.New("trip_id or itinerary_id is required")
+		}
+		if _, err := s.TripRepo.GetByID(ctx, activity.TripID); err != nil {
+			return ErrTripNotFound
+		}
 	}
 
-	// Set the TripID from the Itinerary
-	activity.TripID = itinerary.TripID
+	if activity.Status == "" {
+		activity.Status = defaultStatus(activity)
+	}
+	if err := validActivityStatus(activity.Status); err != nil {
+		return err
+	}
+	if err := checkSchedule(activity, start); err != nil {
+		return err
+	}
 
-	return s.Repo.Create(ctx, activity)
+	if err := s.resolveLocation(ctx, activity); err != nil {
+		return err
+	}
+
+	if rejectConflicts {
+		if err := s.rejectOnConflict(ctx, activity); err != nil {
+			return err
+		}
+	}
+
+	if err := s.Repo.Create(ctx, activity, actorID); err != nil {
+		return err
+	}
+	activity.Localize()
+	return nil
 }
 
+// defaultStatus is the status of a new activity created without one:
+// backlog activities start as ideas and scheduled ones as planned
+func defaultStatus(activity *domain.Activity) string {
+	if activity.ItineraryID == nil {
+		return domain.ActivityIdea
+	}
+	return domain.ActivityPlanned
+}
+
+// GetActivity returns the activity with its status history and occurrence overrides
 func (s *ActivityService) GetActivity(ctx context.Context, id string) (*domain.Activity, error) {
-	return s.Repo.GetByID(ctx, id)
+	activity, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	activity.StatusHistory, err = s.Repo.GetStatusHistory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	activity.Overrides, err = s.Repo.GetOverrides(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
+
+// ListTripActivities returns the trip's activities in day and position
+// order, or only its unscheduled backlog
+func (s *ActivityService) ListTripActivities(ctx context.Context, tripID string, unscheduled bool) ([]domain.Activity, error) {
+	if _, err := s.TripRepo.GetByID(ctx, tripID); err != nil {
+		return nil, ErrTripNotFound
+	}
+	return s.Repo.GetByTripID(ctx, tripID, unscheduled)
 }
 
 func (s *ActivityService) ListActivities(ctx context.Context, itineraryID string) ([]domain.Activity, error) {
@@ -50,12 +254,91 @@ func (s *ActivityService) ListActivities(ctx context.Context, itineraryID string
 	return s.Repo.GetByItineraryID(ctx, itineraryID)
 }
 
-func (s *ActivityService) UpdateActivity(ctx context.Context, activity *domain.Activity) error {
-	// If itinerary ID changed, we might want to verify it exists and belongs to same trip?
-	// For now, simple update.
-	return s.Repo.Update(ctx, activity)
+// UpdateActivity saves the activity. A status change must be an allowed
+// transition from the stored status and is recorded with actorID.
+func (s *ActivityService) UpdateActivity(ctx context.Context, activity *domain.Activity, actorID string, rejectConflicts bool) error {
+	stored, err := s.Repo.GetByID(ctx, activity.ID)
+	if err != nil {
+		return err
+	}
+	if err := checkStatusTransition(stored.Status, activity.Status); err != nil {
+		return err
+	}
+
+	if err := s.checkUpdatedSchedule(ctx, stored, activity); err != nil {
+		return err
+	}
+	if err := s.resolveLocation(ctx, activity); err != nil {
+		return err
+	}
+	if rejectConflicts {
+		if err := s.rejectOnConflict(ctx, activity); err != nil {
+			return err
+		}
+	}
+	if err := s.Repo.Update(ctx, activity, stored.Status, actorID); err != nil {
+		return err
+	}
+	activity.Localize()
+
+	activity.StatusHistory, err = s.Repo.GetStatusHistory(ctx, activity.ID)
+	return err
+}
+
+// checkUpdatedSchedule validates the span and recurrence against the
+// activity's (possibly new) day, which must belong to the same trip. A
+// multi-day activity moved to another day without a new end_date keeps its
+// length.
+func (s *ActivityService) checkUpdatedSchedule(ctx context.Context, stored, activity *domain.Activity) error {
+	if activity.ItineraryID == nil {
+		return checkSchedule(activity, nil)
+	}
+	itinerary, err := s.ItineraryRepo.GetByID(ctx, *activity.ItineraryID)
+	if err != nil {
+		return ErrItineraryNotFound
+	}
+	if itinerary.TripID != activity.TripID {
+		return invalidSchedule("itinerary belongs to a different trip")
+	}
+
+	moved := stored.ItineraryID != nil && *stored.ItineraryID != *activity.ItineraryID
+	if moved && stored.EndDate != nil && activity.EndDate != nil && activity.EndDate.Equal(*stored.EndDate) {
+		previous, err := s.ItineraryRepo.GetByID(ctx, *stored.ItineraryID)
+		if err == nil {
+			end := activity.EndDate.Add(dateOnly(itinerary.Date).Sub(dateOnly(previous.Date)))
+			activity.EndDate = &end
+		}
+	}
+	return checkSchedule(activity, &itinerary.Date)
 }
 
 func (s *ActivityService) DeleteActivity(ctx context.Context, id string) error {
 	return s.Repo.Delete(ctx, id)
 }
+
+// ReorderActivities sets the order of a day's activities and returns them
+func (s *ActivityService) ReorderActivities(ctx context.Context, itineraryID string, ids []string) ([]domain.Activity, error) {
+	if _, err := s.ItineraryRepo.GetByID(ctx, itineraryID); err != nil {
+		return nil, errors.New("itinerary not found")
+	}
+	if err := s.Repo.Reorder(ctx, itineraryID, ids); err != nil {
+		return nil, err
+	}
+	return s.Repo.GetByItineraryID(ctx, itineraryID)
+}
+
+// MoveActivity moves an activity to position index of another day of the same trip
+func (s *ActivityService) MoveActivity(ctx context.Context, id, itineraryID string, index int) (*domain.Activity, error) {
+	activity, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	itinerary, err := s.ItineraryRepo.GetByID(ctx, itineraryID)
+	if err != nil {
+		return nil, errors.New("itinerary not found")
+	}
+	if itinerary.TripID != activity.TripID {
+		return nil, errors.New("itinerary belongs to a different trip")
+	}
+	return s.Repo.Move(ctx, id, itineraryID, index)
+}
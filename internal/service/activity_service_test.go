@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+)
+
+func TestDefaultStatus(t *testing.T) {
+	day := "day-1"
+	if got := defaultStatus(&domain.Activity{}); got != domain.ActivityIdea {
+		t.Errorf("backlog activity got %s, want %s", got, domain.ActivityIdea)
+	}
+	if got := defaultStatus(&domain.Activity{ItineraryID: &day}); got != domain.ActivityPlanned {
+		t.Errorf("scheduled activity got %s, want %s", got, domain.ActivityPlanned)
+	}
+}
+
+func TestCreateActivityNeedsTripOrDay(t *testing.T) {
+	err := (&ActivityService{}).CreateActivity(context.Background(), &domain.Activity{Name: "Museum"}, "user", false)
+	if err == nil {
+		t.Fatal("created an activity with neither trip_id nor itinerary_id")
+	}
+}
+
+func TestCheckScheduleInBacklog(t *testing.T) {
+	endDate := time.Date(2024, 5, 3, 15, 0, 0, 0, time.UTC)
+	daily, bad := "freq=daily", "FREQ=HOURLY"
+
+	tests := []struct {
+		name     string
+		activity domain.Activity
+		wantErr  bool
+	}{
+		{name: "plain idea", activity: domain.Activity{}},
+		{name: "end date without a day", activity: domain.Activity{EndDate: &endDate}},
+		{name: "recurrence without a day", activity: domain.Activity{Recurrence: &daily}},
+		{name: "unsupported recurrence", activity: domain.Activity{Recurrence: &bad}, wantErr: true},
+		{name: "end date and recurrence", activity: domain.Activity{EndDate: &endDate, Recurrence: &daily}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := tt.activity
+			err := checkSchedule(&a, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidSchedule) {
+				t.Errorf("got %v, want ErrInvalidSchedule", err)
+			}
+			if err == nil && a.EndDate != nil && !a.EndDate.Equal(dateOnly(endDate)) {
+				t.Errorf("end date kept its time: %v", a.EndDate)
+			}
+		})
+	}
+}
+
+func TestParseLocalTime(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC) // 09:00 in Tokyo
+
+	for _, value := range []string{"2024-05-01T09:00", "2024-05-01T09:00:00", "2024-05-01 09:00", "2024-05-01 09:00:00", "2024-05-01T02:00:00+02:00"} {
+		got, err := parseLocalTime(value, tokyo)
+		if err != nil {
+			t.Errorf("%s: %v", value, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("%s: got %v, want %v", value, got.UTC(), want)
+		}
+	}
+	for _, value := range []string{"", "09:00", "2024-05-01", "tomorrow morning"} {
+		if _, err := parseLocalTime(value, tokyo); err == nil {
+			t.Errorf("%q was accepted", value)
+		}
+	}
+}
+
+func TestSetLocalTimes(t *testing.T) {
+	start, end := "2024-03-31T01:30", "2024-03-31T03:30"
+	activity := &domain.Activity{}
+	// Paris moves to summer time at 02:00 that night, so the two hours on
+	// the clock are one hour apart
+	if err := (&ActivityService{}).SetLocalTimes(context.Background(), activity, &start, &end, "Europe/Paris"); err != nil {
+		t.Fatal(err)
+	}
+	if got := activity.EndTime.Sub(*activity.StartTime); got != time.Hour {
+		t.Errorf("got %v between start and end, want 1h", got)
+	}
+	if want := time.Date(2024, 3, 31, 0, 30, 0, 0, time.UTC); !activity.StartTime.Equal(want) {
+		t.Errorf("got start %v, want %v", activity.StartTime.UTC(), want)
+	}
+
+	bad := []struct {
+		name, value, zone string
+	}{
+		{"unknown timezone", start, "Mars/Olympus_Mons"},
+		{"bad time", "half past nine", "Europe/Paris"},
+	}
+	for _, tt := range bad {
+		value := tt.value
+		err := (&ActivityService{}).SetLocalTimes(context.Background(), &domain.Activity{}, &value, nil, tt.zone)
+		if !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("%s: got %v, want ErrInvalidSchedule", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+)
+
+// ErrInvalidStatus wraps unknown statuses and disallowed transitions so
+// handlers can answer 422
+var ErrInvalidStatus = errors.New("invalid status")
+
+// activityTransitions lists the statuses each status may move to.
+// Finished states can be reopened to correct mistakes.
+var activityTransitions = map[string][]string{
+	domain.ActivityIdea: {
+		domain.ActivityPlanned, domain.ActivityBooked, domain.ActivityConfirmed,
+		domain.ActivitySkipped, domain.ActivityCancelled,
+	},
+	domain.ActivityPlanned: {
+		domain.ActivityIdea, domain.ActivityBooked, domain.ActivityConfirmed,
+		domain.ActivityCompleted, domain.ActivitySkipped, domain.ActivityCancelled,
+	},
+	domain.ActivityBooked: {
+		domain.ActivityPlanned, domain.ActivityConfirmed, domain.ActivityCompleted,
+		domain.ActivitySkipped, domain.ActivityCancelled,
+	},
+	domain.ActivityConfirmed: {
+		domain.ActivityBooked, domain.ActivityCompleted, domain.ActivitySkipped,
+		domain.ActivityCancelled,
+	},
+	domain.ActivityCompleted: {domain.ActivityConfirmed, domain.ActivityPlanned},
+	domain.ActivitySkipped:   {domain.ActivityIdea, domain.ActivityPlanned},
+	domain.ActivityCancelled: {domain.ActivityIdea, domain.ActivityPlanned},
+}
+
+// ActivityStatuses returns the allowed statuses and their transitions
+func ActivityStatuses() map[string][]string {
+	return activityTransitions
+}
+
+func validActivityStatus(status string) error {
+	if _, ok := activityTransitions[status]; !ok {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidStatus, status)
+	}
+	return nil
+}
+
+// checkStatusTransition returns an error unless from may move to to.
+// Keeping the same status is always allowed.
+func checkStatusTransition(from, to string) error {
+	if err := validActivityStatus(to); err != nil {
+		return err
+	}
+	if from == to {
+		return nil
+	}
+	for _, next := range activityTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: cannot change from %s to %s", ErrInvalidStatus, from, to)
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+)
+
+func TestCheckStatusTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		ok       bool
+	}{
+		{domain.ActivityIdea, domain.ActivityPlanned, true},
+		{domain.ActivityPlanned, domain.ActivityBooked, true},
+		{domain.ActivityBooked, domain.ActivityConfirmed, true},
+		{domain.ActivityConfirmed, domain.ActivityCompleted, true},
+		{domain.ActivityPlanned, domain.ActivityPlanned, true},
+		{domain.ActivityCompleted, domain.ActivityPlanned, true},
+		{domain.ActivityCancelled, domain.ActivityIdea, true},
+		{domain.ActivityIdea, domain.ActivityCompleted, false},
+		{domain.ActivityConfirmed, domain.ActivityIdea, false},
+		{domain.ActivityCompleted, domain.ActivityCancelled, false},
+		{domain.ActivitySkipped, domain.ActivityBooked, false},
+		{domain.ActivityPlanned, "done", false},
+	}
+	for _, tt := range tests {
+		err := checkStatusTransition(tt.from, tt.to)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s to %s: got %v, want allowed %v", tt.from, tt.to, err, tt.ok)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidStatus) {
+			t.Errorf("%s to %s: got %v, want ErrInvalidStatus", tt.from, tt.to, err)
+		}
+	}
+}
+
+func TestActivityTransitionsAreKnown(t *testing.T) {
+	for from, targets := range activityTransitions {
+		for _, to := range targets {
+			if err := validActivityStatus(to); err != nil {
+				t.Errorf("%s moves to %v", from, err)
+			}
+			if to == from {
+				t.Errorf("%s lists itself as a transition", from)
+			}
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/geo"
+	"github.com/NoahFola/travel_app_backend/internal/repository"
+	"github.com/NoahFola/travel_app_backend/internal/schedule"
+)
+
+// ErrInvalidSchedule is returned for bad end dates, recurrence rules and occurrence dates
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+func invalidSchedule(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidSchedule, fmt.Sprintf(format, args...))
+}
+
+// AgendaService lays a trip out day by day. Multi-day activities appear on
+// every day they cover and recurring ones on every day they repeat, with
+// per-occurrence overrides applied.
+type AgendaService struct {
+	ActivityRepo  *repository.ActivityRepository
+	ItineraryRepo *repository.ItineraryRepository
+	TripRepo      *repository.TripRepository
+}
+
+// AgendaDay is an itinerary with everything happening on its date
+type AgendaDay struct {
+	Itinerary   domain.Itinerary            `json:"itinerary"`
+	Occurrences []domain.ActivityOccurrence `json:"occurrences"`
+}
+
+// DayAgenda returns the occurrences on an itinerary's date
+func (s *AgendaService) DayAgenda(ctx context.Context, itineraryID string) ([]domain.ActivityOccurrence, error) {
+	itinerary, err := s.ItineraryRepo.GetByID(ctx, itineraryID)
+	if err != nil {
+		return nil, errors.New("itinerary not found")
+	}
+	trip, err := s.TripRepo.GetByID(ctx, itinerary.TripID)
+	if err != nil {
+		return nil, errors.New("trip not found")
+	}
+
+	byDate, err := s.occurrencesByDate(ctx, trip)
+	if err != nil {
+		return nil, err
+	}
+	return orderDay(itinerary.ID, byDate[dateKey(itinerary.Date)]), nil
+}
+
+// TripAgenda returns every itinerary of the trip with its occurrences
+func (s *AgendaService) TripAgenda(ctx context.Context, tripID string) ([]AgendaDay, error) {
+	trip, err := s.TripRepo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, errors.New("trip not found")
+	}
+	itineraries, err := s.ItineraryRepo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	byDate, err := s.occurrencesByDate(ctx, trip)
+	if err != nil {
+		return nil, err
+	}
+
+	days := make([]AgendaDay, 0, len(itineraries))
+	for _, it := range itineraries {
+		days = append(days, AgendaDay{Itinerary: it, Occurrences: orderDay(it.ID, byDate[dateKey(it.Date)])})
+	}
+	return days, nil
+}
+
+// SetOverride changes or cancels one occurrence of a multi-day or recurring activity
+func (s *AgendaService) SetOverride(ctx context.Context, o *domain.ActivityOverride) error {
+	activity, err := s.ActivityRepo.GetByID(ctx, o.ActivityID)
+	if err != nil {
+		return err
+	}
+	dates, err := s.activityDates(ctx, activity)
+	if err != nil {
+		return err
+	}
+	if len(dates) < 2 {
+		return invalidSchedule("only multi-day and recurring activities have occurrences")
+	}
+
+	o.Date = dateOnly(o.Date)
+	found := false
+	for _, d := range dates {
+		if d.Equal(o.Date) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return invalidSchedule("%s is not an occurrence of this activity", dateKey(o.Date))
+	}
+	if o.StartTime != nil && o.EndTime != nil && o.EndTime.Before(*o.StartTime) {
+		return invalidSchedule("end_time is before start_time")
+	}
+	return s.ActivityRepo.SaveOverride(ctx, o)
+}
+
+// DeleteOverride restores an occurrence to the activity's own details
+func (s *AgendaService) DeleteOverride(ctx context.Context, activityID string, date time.Time) error {
+	return s.ActivityRepo.DeleteOverride(ctx, activityID, dateOnly(date))
+}
+
+// activityDates lists the days an activity covers within its trip
+func (s *AgendaService) activityDates(ctx context.Context, activity *domain.Activity) ([]time.Time, error) {
+	if activity.ItineraryID == nil {
+		return nil, invalidSchedule("activity is not scheduled on a day")
+	}
+	itinerary, err := s.ItineraryRepo.GetByID(ctx, *activity.ItineraryID)
+	if err != nil {
+		return nil, errors.New("itinerary not found")
+	}
+	trip, err := s.TripRepo.GetByID(ctx, activity.TripID)
+	if err != nil {
+		return nil, errors.New("trip not found")
+	}
+	return occurrenceDates(activity, itinerary.Date, trip.EndDate), nil
+}
+
+// occurrencesByDate expands every scheduled activity of the trip, keyed by
+// date. Within a date, occurrences keep the trip's day and position order.
+func (s *AgendaService) occurrencesByDate(ctx context.Context, trip *domain.Trip) (map[string][]domain.ActivityOccurrence, error) {
+	activities, err := s.ActivityRepo.GetScheduleByTripID(ctx, trip.ID)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := s.ActivityRepo.GetOverridesByTripID(ctx, trip.ID)
+	if err != nil {
+		return nil, err
+	}
+	byKey := overridesByKey(overrides)
+
+	byDate := make(map[string][]domain.ActivityOccurrence)
+	for _, a := range activities {
+		if a.ItineraryDate == nil {
+			continue
+		}
+		for _, occ := range expandActivity(a.Activity, *a.ItineraryDate, trip.EndDate, byKey) {
+			key := dateKey(occ.Date)
+			byDate[key] = append(byDate[key], occ)
+		}
+	}
+	return byDate, nil
+}
+
+// overridesByKey indexes overrides by "activityID/date" for expandActivity
+func overridesByKey(overrides []domain.ActivityOverride) map[string]domain.ActivityOverride {
+	byKey := make(map[string]domain.ActivityOverride, len(overrides))
+	for _, o := range overrides {
+		byKey[o.ActivityID+"/"+dateKey(o.Date)] = o
+	}
+	return byKey
+}
+
+// occurrenceDates lists the days an activity starting on start covers.
+// Recurring activities repeat until the trip's last day.
+func occurrenceDates(a *domain.Activity, start, tripEnd time.Time) []time.Time {
+	start = dateOnly(start)
+	switch {
+	case a.Recurrence != nil:
+		rule, err := schedule.ParseRule(*a.Recurrence)
+		if err != nil {
+			return []time.Time{start}
+		}
+		until := dateOnly(tripEnd)
+		if until.Before(start) {
+			until = start
+		}
+		return rule.Expand(start, until)
+	case a.EndDate != nil && dateOnly(*a.EndDate).After(start):
+		var dates []time.Time
+		for d := start; !d.After(dateOnly(*a.EndDate)) && len(dates) < schedule.MaxOccurrences; d = d.AddDate(0, 0, 1) {
+			dates = append(dates, d)
+		}
+		return dates
+	}
+	return []time.Time{start}
+}
+
+// expandActivity returns the activity's occurrences with overrides (keyed
+// "activityID/date") applied. Cancelled occurrences are left out but still
+// count towards the numbering.
+func expandActivity(a domain.Activity, start, tripEnd time.Time, overrides map[string]domain.ActivityOverride) []domain.ActivityOccurrence {
+	dates := occurrenceDates(&a, start, tripEnd)
+	start = dateOnly(start)
+
+	// Repetitions keep the wall-clock time of the place across DST changes
+	zone := time.UTC
+	if a.Place != nil {
+		zone = geo.LoadZone(a.Place.Timezone)
+	}
+
+	var occurrences []domain.ActivityOccurrence
+	for i, date := range dates {
+		occ := domain.ActivityOccurrence{Activity: a, Date: date, Occurrence: i + 1, Total: len(dates)}
+		switch {
+		case a.Recurrence != nil:
+			// Each repetition keeps the time of day of the first
+			days := int(date.Sub(start).Hours() / 24)
+			occ.StartTime = shiftDays(a.StartTime, days, zone)
+			occ.EndTime = shiftDays(a.EndTime, days, zone)
+		case len(dates) > 1:
+			// A stay starts on its first day and ends on its last
+			if i > 0 {
+				occ.StartTime = nil
+			}
+			if i < len(dates)-1 {
+				occ.EndTime = nil
+			}
+		}
+
+		if o, ok := overrides[a.ID+"/"+dateKey(date)]; ok {
+			if o.Cancelled {
+				continue
+			}
+			if o.Name != nil {
+				occ.Name = *o.Name
+			}
+			if o.Description != nil {
+				occ.Description = o.Description
+			}
+			if o.Location != nil {
+				occ.Location = o.Location
+			}
+			if o.StartTime != nil {
+				occ.StartTime = o.StartTime
+			}
+			if o.EndTime != nil {
+				occ.EndTime = o.EndTime
+			}
+			occ.Overridden = true
+		}
+		occ.Localize()
+		occurrences = append(occurrences, occ)
+	}
+	return occurrences
+}
+
+// orderDay keeps the itinerary's own activities in their user-defined order
+// and slots occurrences carried over from other days in by start time.
+// Untimed carry-overs, like the middle nights of a hotel stay, come first.
+func orderDay(itineraryID string, occurrences []domain.ActivityOccurrence) []domain.ActivityOccurrence {
+	day := []domain.ActivityOccurrence{}
+	var own, carried []domain.ActivityOccurrence
+	for _, occ := range occurrences {
+		switch {
+		case occ.ItineraryID != nil && *occ.ItineraryID == itineraryID:
+			own = append(own, occ)
+		case occ.StartTime == nil:
+			day = append(day, occ)
+		default:
+			carried = append(carried, occ)
+		}
+	}
+	sort.SliceStable(carried, func(i, j int) bool { return carried[i].StartTime.Before(*carried[j].StartTime) })
+
+	next := 0
+	for _, occ := range own {
+		if occ.StartTime != nil {
+			for next < len(carried) && carried[next].StartTime.Before(*occ.StartTime) {
+				day = append(day, carried[next])
+				next++
+			}
+		}
+		day = append(day, occ)
+	}
+	return append(day, carried[next:]...)
+}
+
+// checkSchedule validates end_date and recurrence against the date the
+// activity starts on (nil when unknown) and normalizes the rule. An end
+// date on the start day makes the activity a single-day one again.
+func checkSchedule(activity *domain.Activity, start *time.Time) error {
+	if activity.Recurrence != nil && strings.TrimSpace(*activity.Recurrence) == "" {
+		activity.Recurrence = nil
+	}
+	if activity.EndDate != nil && activity.Recurrence != nil {
+		return invalidSchedule("an activity can have an end_date or a recurrence, not both")
+	}
+
+	if activity.Recurrence != nil {
+		rule, err := schedule.ParseRule(*activity.Recurrence)
+		if err != nil {
+			return invalidSchedule("recurrence: %s", err.Error())
+		}
+		normalized := rule.String()
+		activity.Recurrence = &normalized
+	}
+
+	if activity.EndDate != nil {
+		end := dateOnly(*activity.EndDate)
+		activity.EndDate = &end
+		if start != nil {
+			if end.Before(dateOnly(*start)) {
+				return invalidSchedule("end_date is before the activity's day")
+			}
+			if end.Equal(dateOnly(*start)) {
+				activity.EndDate = nil
+			}
+		}
+	}
+	return nil
+}
+
+func shiftDays(t *time.Time, days int, zone *time.Location) *time.Time {
+	if t == nil {
+		return nil
+	}
+	shifted := t.In(zone).AddDate(0, 0, days)
+	return &shifted
+}
+
+func dateKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/repository"
+)
+
+// ErrUnknownTemplate is returned for template names that aren't built in
+var ErrUnknownTemplate = errors.New("unknown checklist template")
+
+type ChecklistService struct {
+	Repo            *repository.ChecklistRepository
+	TripRepo        *repository.TripRepository
+	ParticipantRepo *repository.ParticipantRepository
+}
+
+var validChecklistKinds = map[string]bool{
+	domain.ChecklistPacking:      true,
+	domain.ChecklistPreDeparture: true,
+	domain.ChecklistDocuments:    true,
+	domain.ChecklistOther:        true,
+}
+
+func (s *ChecklistService) CreateChecklist(ctx context.Context, checklist *domain.Checklist) error {
+	if _, err := s.TripRepo.GetByID(ctx, checklist.TripID); err != nil {
+		return errors.New("trip not found")
+	}
+	if checklist.Kind == "" {
+		checklist.Kind = domain.ChecklistPacking
+	}
+	if !validChecklistKinds[checklist.Kind] {
+		return errors.New("kind must be one of packing, pre_departure, documents, other")
+	}
+	checklist.Items = []domain.ChecklistItem{}
+	return s.Repo.Create(ctx, checklist)
+}
+
+func (s *ChecklistService) GetChecklist(ctx context.Context, id string) (*domain.Checklist, error) {
+	return s.Repo.GetByID(ctx, id)
+}
+
+func (s *ChecklistService) ListChecklists(ctx context.Context, tripID string) ([]domain.Checklist, error) {
+	if _, err := s.TripRepo.GetByID(ctx, tripID); err != nil {
+		return nil, errors.New("trip not found")
+	}
+	return s.Repo.GetByTripID(ctx, tripID)
+}
+
+func (s *ChecklistService) UpdateChecklist(ctx context.Context, checklist *domain.Checklist) error {
+	if !validChecklistKinds[checklist.Kind] {
+		return errors.New("kind must be one of packing, pre_departure, documents, other")
+	}
+	return s.Repo.Update(ctx, checklist)
+}
+
+func (s *ChecklistService) DeleteChecklist(ctx context.Context, id string) error {
+	return s.Repo.Delete(ctx, id)
+}
+
+// Templates lists the built-in checklist templates
+func (s *ChecklistService) Templates() []ChecklistTemplate {
+	return checklistTemplates
+}
+
+// ApplyTemplate creates one checklist per template section on the trip, all
+// or none of them. Relative due dates are resolved against the trip's start
+// date.
+func (s *ChecklistService) ApplyTemplate(ctx context.Context, tripID, name string) ([]domain.Checklist, error) {
+	trip, err := s.TripRepo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, ErrTripNotFound
+	}
+
+	var template *ChecklistTemplate
+	for i := range checklistTemplates {
+		if checklistTemplates[i].Name == strings.ToLower(name) {
+			template = &checklistTemplates[i]
+		}
+	}
+	if template == nil {
+		return nil, fmt.Errorf("%w %q", ErrUnknownTemplate, name)
+	}
+
+	var checklists []*domain.Checklist
+	for _, section := range template.Checklists {
+		checklist := &domain.Checklist{
+			TripID: tripID,
+			Title:  section.Title,
+			Kind:   section.Kind,
+		}
+		for _, t := range section.Items {
+			item := domain.ChecklistItem{Title: t.Title, Quantity: t.Quantity}
+			if t.DueDaysBefore != nil {
+				due := trip.StartDate.AddDate(0, 0, -*t.DueDaysBefore)
+				item.DueDate = &due
+			}
+			checklist.Items = append(checklist.Items, item)
+		}
+		checklists = append(checklists, checklist)
+	}
+	if err := s.Repo.CreateMany(ctx, checklists); err != nil {
+		return nil, err
+	}
+
+	created := make([]domain.Checklist, len(checklists))
+	for i, checklist := range checklists {
+		created[i] = *checklist
+	}
+	return created, nil
+}
+
+// AddItem appends an item to a checklist
+func (s *ChecklistService) AddItem(ctx context.Context, item *domain.ChecklistItem) error {
+	checklist, err := s.Repo.GetByID(ctx, item.ChecklistID)
+	if err != nil {
+		return err
+	}
+	if err := s.validateItem(ctx, checklist.TripID, item); err != nil {
+		return err
+	}
+	return s.Repo.CreateItem(ctx, item)
+}
+
+func (s *ChecklistService) GetItem(ctx context.Context, checklistID, id string) (*domain.ChecklistItem, error) {
+	item, err := s.Repo.GetItem(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if item.ChecklistID != checklistID {
+		return nil, errors.New("checklist item not found")
+	}
+	return item, nil
+}
+
+func (s *ChecklistService) UpdateItem(ctx context.Context, item *domain.ChecklistItem) error {
+	checklist, err := s.Repo.GetByID(ctx, item.ChecklistID)
+	if err != nil {
+		return err
+	}
+	if err := s.validateItem(ctx, checklist.TripID, item); err != nil {
+		return err
+	}
+	return s.Repo.UpdateItem(ctx, item)
+}
+
+func (s *ChecklistService) DeleteItem(ctx context.Context, checklistID, id string) error {
+	if _, err := s.GetItem(ctx, checklistID, id); err != nil {
+		return err
+	}
+	return s.Repo.DeleteItem(ctx, id)
+}
+
+// ReorderItems sets the order of a checklist's items. itemIDs must list
+// every item of the checklist exactly once.
+func (s *ChecklistService) ReorderItems(ctx context.Context, checklistID string, itemIDs []string) (*domain.Checklist, error) {
+	checklist, err := s.Repo.GetByID(ctx, checklistID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]bool, len(checklist.Items))
+	for _, item := range checklist.Items {
+		existing[item.ID] = true
+	}
+	if len(itemIDs) != len(existing) {
+		return nil, errors.New("item_ids must list every item of the checklist")
+	}
+	for _, id := range itemIDs {
+		if !existing[id] {
+			return nil, errors.New("item_ids must list every item of the checklist")
+		}
+		delete(existing, id)
+	}
+
+	if err := s.Repo.ReorderItems(ctx, checklistID, itemIDs); err != nil {
+		return nil, err
+	}
+	return s.Repo.GetByID(ctx, checklistID)
+}
+
+func (s *ChecklistService) validateItem(ctx context.Context, tripID string, item *domain.ChecklistItem) error {
+	if strings.TrimSpace(item.Title) == "" {
+		return errors.New("title is required")
+	}
+	if item.Quantity == 0 {
+		item.Quantity = 1
+	}
+	if item.Quantity < 0 {
+		return errors.New("quantity must be positive")
+	}
+	if item.AssigneeID != nil {
+		p, err := s.ParticipantRepo.GetByID(ctx, *item.AssigneeID)
+		if err != nil || p.TripID != tripID {
+			return errors.New("assignee is not a participant of this trip")
+		}
+	}
+	return nil
+}
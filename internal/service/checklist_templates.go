@@ -0,0 +1,123 @@
+package service
+
+// ChecklistTemplate is a built-in set of checklists that can be applied to a trip
+type ChecklistTemplate struct {
+	Name       string                     `json:"name"`
+	Title      string                     `json:"title"`
+	Checklists []ChecklistTemplateSection `json:"checklists"`
+}
+
+type ChecklistTemplateSection struct {
+	Title string                  `json:"title"`
+	Kind  string                  `json:"kind"`
+	Items []ChecklistTemplateItem `json:"items"`
+}
+
+type ChecklistTemplateItem struct {
+	Title    string `json:"title"`
+	Quantity int    `json:"quantity"`
+	// DueDaysBefore sets the item's due date relative to the trip start
+	DueDaysBefore *int `json:"due_days_before,omitempty"`
+}
+
+func daysBefore(n int) *int { return &n }
+
+var commonDocuments = ChecklistTemplateSection{
+	Title: "Documents",
+	Kind:  "documents",
+	Items: []ChecklistTemplateItem{
+		{Title: "Passport / ID", Quantity: 1},
+		{Title: "Travel insurance", Quantity: 1},
+		{Title: "Booking confirmations", Quantity: 1},
+		{Title: "Credit / debit cards", Quantity: 1},
+	},
+}
+
+var checklistTemplates = []ChecklistTemplate{
+	{
+		Name:  "beach",
+		Title: "Beach holiday",
+		Checklists: []ChecklistTemplateSection{
+			{
+				Title: "Beach packing",
+				Kind:  "packing",
+				Items: []ChecklistTemplateItem{
+					{Title: "Swimsuit", Quantity: 2},
+					{Title: "Sunscreen", Quantity: 1},
+					{Title: "Sunglasses", Quantity: 1},
+					{Title: "Beach towel", Quantity: 1},
+					{Title: "Flip-flops", Quantity: 1},
+					{Title: "Hat", Quantity: 1},
+					{Title: "After-sun lotion", Quantity: 1},
+				},
+			},
+			commonDocuments,
+			{
+				Title: "Before departure",
+				Kind:  "pre_departure",
+				Items: []ChecklistTemplateItem{
+					{Title: "Check passport validity", Quantity: 1, DueDaysBefore: daysBefore(30)},
+					{Title: "Book airport transfer", Quantity: 1, DueDaysBefore: daysBefore(7)},
+					{Title: "Online check-in", Quantity: 1, DueDaysBefore: daysBefore(1)},
+				},
+			},
+		},
+	},
+	{
+		Name:  "ski",
+		Title: "Ski trip",
+		Checklists: []ChecklistTemplateSection{
+			{
+				Title: "Ski packing",
+				Kind:  "packing",
+				Items: []ChecklistTemplateItem{
+					{Title: "Ski jacket", Quantity: 1},
+					{Title: "Ski pants", Quantity: 1},
+					{Title: "Thermal base layers", Quantity: 3},
+					{Title: "Ski socks", Quantity: 4},
+					{Title: "Gloves", Quantity: 1},
+					{Title: "Goggles", Quantity: 1},
+					{Title: "Helmet", Quantity: 1},
+					{Title: "Lip balm with SPF", Quantity: 1},
+				},
+			},
+			commonDocuments,
+			{
+				Title: "Before departure",
+				Kind:  "pre_departure",
+				Items: []ChecklistTemplateItem{
+					{Title: "Book equipment rental", Quantity: 1, DueDaysBefore: daysBefore(14)},
+					{Title: "Buy lift passes", Quantity: 1, DueDaysBefore: daysBefore(7)},
+					{Title: "Check snow report", Quantity: 1, DueDaysBefore: daysBefore(2)},
+				},
+			},
+		},
+	},
+	{
+		Name:  "business",
+		Title: "Business trip",
+		Checklists: []ChecklistTemplateSection{
+			{
+				Title: "Business packing",
+				Kind:  "packing",
+				Items: []ChecklistTemplateItem{
+					{Title: "Laptop and charger", Quantity: 1},
+					{Title: "Suit / formal outfit", Quantity: 1},
+					{Title: "Business cards", Quantity: 1},
+					{Title: "Travel adapter", Quantity: 1},
+					{Title: "Presentation backup (USB)", Quantity: 1},
+				},
+			},
+			commonDocuments,
+			{
+				Title: "Before departure",
+				Kind:  "pre_departure",
+				Items: []ChecklistTemplateItem{
+					{Title: "Confirm meeting schedule", Quantity: 1, DueDaysBefore: daysBefore(3)},
+					{Title: "Set out-of-office reply", Quantity: 1, DueDaysBefore: daysBefore(1)},
+					{Title: "Online check-in", Quantity: 1, DueDaysBefore: daysBefore(1)},
+				},
+			},
+		},
+	},
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/places"
+	"github.com/NoahFola/travel_app_backend/internal/repository"
+)
+
+// ErrInvalidCollection is returned for malformed collections and saved places
+var ErrInvalidCollection = errors.New("invalid collection")
+
+// ErrNotCollectionOwner is returned when a user touches someone else's collection
+var ErrNotCollectionOwner = errors.New("this collection belongs to another user")
+
+func invalidCollection(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidCollection, fmt.Sprintf(format, args...))
+}
+
+// CollectionService manages users' saved places: private lists of
+// locations with notes and tags, which can be shared read-only and turned
+// into activities once the user decides when to go.
+type CollectionService struct {
+	Repo            *repository.CollectionRepository
+	Locations       *LocationService
+	Activities      *ActivityService
+	ParticipantRepo *repository.ParticipantRepository
+}
+
+// owned loads a collection and checks it belongs to userID
+func (s *CollectionService) owned(ctx context.Context, id, userID string) (*domain.PlaceCollection, error) {
+	c, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.UserID != userID {
+		return nil, ErrNotCollectionOwner
+	}
+	return c, nil
+}
+
+func checkCollection(c *domain.PlaceCollection) error {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		return invalidCollection("name is required")
+	}
+	return nil
+}
+
+// normalizeTags lowercases and trims tags, dropping blanks and duplicates
+func normalizeTags(tags []string) []string {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+func (s *CollectionService) CreateCollection(ctx context.Context, c *domain.PlaceCollection) error {
+	if err := checkCollection(c); err != nil {
+		return err
+	}
+	return s.Repo.Create(ctx, c)
+}
+
+func (s *CollectionService) ListCollections(ctx context.Context, userID string) ([]domain.PlaceCollection, error) {
+	return s.Repo.GetByUserID(ctx, userID)
+}
+
+// GetCollection returns one of the user's collections with its places,
+// optionally only those with a tag
+func (s *CollectionService) GetCollection(ctx context.Context, id, userID, tag string) (*domain.PlaceCollection, error) {
+	c, err := s.owned(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	c.Places, err = s.Repo.GetPlaces(ctx, c.ID, strings.ToLower(strings.TrimSpace(tag)))
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *CollectionService) UpdateCollection(ctx context.Context, c *domain.PlaceCollection, userID string) error {
+	if c.UserID != userID {
+		return ErrNotCollectionOwner
+	}
+	if err := checkCollection(c); err != nil {
+		return err
+	}
+	return s.Repo.Update(ctx, c)
+}
+
+func (s *CollectionService) DeleteCollection(ctx context.Context, id, userID string) error {
+	if _, err := s.owned(ctx, id, userID); err != nil {
+		return err
+	}
+	return s.Repo.Delete(ctx, id)
+}
+
+// SavePlace bookmarks a known location, or a place search result which is
+// saved as a location first. Saving a place twice updates the bookmark.
+func (s *CollectionService) SavePlace(ctx context.Context, collectionID, userID string, saved *domain.SavedPlace, place *places.Place) error {
+	if _, err := s.owned(ctx, collectionID, userID); err != nil {
+		return err
+	}
+
+	var loc *domain.Location
+	var err error
+	switch {
+	case place != nil:
+		if place.PlaceID == "" || place.Name == "" {
+			return ErrInvalidPlace
+		}
+		loc, err = s.Locations.GetOrCreateLocation(ctx, *place)
+	case saved.LocationID != "":
+		loc, err = s.Locations.Repo.GetByID(ctx, saved.LocationID)
+		if err == nil && loc == nil {
+			err = ErrLocationNotFound
+		}
+	default:
+		return invalidCollection("location_id or place is required")
+	}
+	if err != nil {
+		return err
+	}
+
+	saved.CollectionID = collectionID
+	saved.LocationID = loc.ID
+	saved.Place = loc
+	saved.Tags = normalizeTags(saved.Tags)
+	return s.Repo.SavePlace(ctx, saved)
+}
+
+// GetSavedPlace returns a place of one of the user's collections
+func (s *CollectionService) GetSavedPlace(ctx context.Context, collectionID, savedID, userID string) (*domain.SavedPlace, error) {
+	if _, err := s.owned(ctx, collectionID, userID); err != nil {
+		return nil, err
+	}
+	saved, err := s.Repo.GetPlace(ctx, savedID)
+	if err != nil {
+		return nil, err
+	}
+	if saved.CollectionID != collectionID {
+		return nil, errors.New("saved place not found")
+	}
+	return saved, nil
+}
+
+// UpdateSavedPlace saves a place's note and tags
+func (s *CollectionService) UpdateSavedPlace(ctx context.Context, saved *domain.SavedPlace, userID string) error {
+	if _, err := s.owned(ctx, saved.CollectionID, userID); err != nil {
+		return err
+	}
+	saved.Tags = normalizeTags(saved.Tags)
+	return s.Repo.UpdatePlace(ctx, saved)
+}
+
+func (s *CollectionService) RemovePlace(ctx context.Context, collectionID, savedID, userID string) error {
+	if _, err := s.GetSavedPlace(ctx, collectionID, savedID, userID); err != nil {
+		return err
+	}
+	return s.Repo.DeletePlace(ctx, savedID)
+}
+
+// ScheduleSavedPlace turns a saved place into an activity of a trip the
+// user takes part in: on a day when activity.ItineraryID is set, in the
+// trip's backlog otherwise. The name and description default to the place
+// name and the note. The place stays in the collection.
+func (s *CollectionService) ScheduleSavedPlace(ctx context.Context, collectionID, savedID, userID string, activity *domain.Activity, rejectConflicts bool) error {
+	saved, err := s.GetSavedPlace(ctx, collectionID, savedID, userID)
+	if err != nil {
+		return err
+	}
+
+	tripID := activity.TripID
+	if activity.ItineraryID != nil {
+		itinerary, err := s.Activities.ItineraryRepo.GetByID(ctx, *activity.ItineraryID)
+		if err != nil {
+			return ErrItineraryNotFound
+		}
+		tripID = itinerary.TripID
+	}
+	if tripID == "" {
+		return invalidCollection("trip_id or itinerary_id is required")
+	}
+	p, err := s.ParticipantRepo.GetByTripAndUser(ctx, tripID, userID)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return ErrNotParticipant
+	}
+
+	if strings.TrimSpace(activity.Name) == "" {
+		activity.Name = saved.Place.Name
+	}
+	if activity.Description == nil {
+		activity.Description = saved.Note
+	}
+	activity.LocationID = &saved.LocationID
+	activity.Place = saved.Place
+	return s.Activities.CreateActivity(ctx, activity, userID, rejectConflicts)
+}
+
+// Share gives the collection a public read-only token, keeping the current
+// one if it is already shared
+func (s *CollectionService) Share(ctx context.Context, id, userID string) (string, error) {
+	c, err := s.owned(ctx, id, userID)
+	if err != nil {
+		return "", err
+	}
+	if c.ShareToken != nil {
+		return *c.ShareToken, nil
+	}
+
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(bytes)
+	c.ShareToken = &token
+	if err := s.Repo.Update(ctx, c); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Unshare revokes the collection's public token
+func (s *CollectionService) Unshare(ctx context.Context, id, userID string) error {
+	c, err := s.owned(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	c.ShareToken = nil
+	return s.Repo.Update(ctx, c)
+}
+
+// GetSharedCollection returns a shared collection with its places
+func (s *CollectionService) GetSharedCollection(ctx context.Context, token string) (*domain.PlaceCollection, error) {
+	c, err := s.Repo.GetByShareToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	c.Places, err = s.Repo.GetPlaces(ctx, c.ID, "")
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
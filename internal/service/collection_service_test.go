@@ -0,0 +1,41 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want []string
+	}{
+		{"none", nil, []string{}},
+		{"kept in order", []string{"food", "rooftop"}, []string{"food", "rooftop"}},
+		{"case and spaces", []string{" Food ", "ROOFTOP"}, []string{"food", "rooftop"}},
+		{"duplicates", []string{"food", "Food", "food "}, []string{"food"}},
+		{"blanks", []string{"", "  ", "food"}, []string{"food"}},
+	}
+	for _, tt := range tests {
+		if got := normalizeTags(tt.tags); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckCollection(t *testing.T) {
+	c := &domain.PlaceCollection{Name: "  Lisbon eats "}
+	if err := checkCollection(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "Lisbon eats" {
+		t.Errorf("got name %q", c.Name)
+	}
+	if err := checkCollection(&domain.PlaceCollection{Name: " "}); !errors.Is(err, ErrInvalidCollection) {
+		t.Errorf("got %v for a blank name, want ErrInvalidCollection", err)
+	}
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/geo"
+	"github.com/NoahFola/travel_app_backend/internal/repository"
+	"github.com/NoahFola/travel_app_backend/internal/schedule"
+)
+
+// DefaultTravelSpeedKmh is a rough door-to-door city travel speed
+const DefaultTravelSpeedKmh = 30
+
+type ConflictService struct {
+	ActivityRepo  *repository.ActivityRepository
+	ItineraryRepo *repository.ItineraryRepository
+	TripRepo      *repository.TripRepository
+	SpeedKmh      float64
+}
+
+// ConflictError is returned when a write is rejected because of conflicts
+type ConflictError struct {
+	Conflicts []schedule.Conflict
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("activity has %d schedule conflict(s)", len(e.Conflicts))
+}
+
+func (s *ConflictService) speed(override float64) float64 {
+	if override > 0 {
+		return override
+	}
+	if s.SpeedKmh > 0 {
+		return s.SpeedKmh
+	}
+	return DefaultTravelSpeedKmh
+}
+
+// TripConflicts checks every activity of a trip
+func (s *ConflictService) TripConflicts(ctx context.Context, tripID string, speedKmh float64) ([]schedule.Conflict, error) {
+	trip, err := s.TripRepo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, errors.New("trip not found")
+	}
+	activities, err := s.loadSchedule(ctx, trip)
+	if err != nil {
+		return nil, err
+	}
+	return schedule.Detect(activities.items(), s.options(trip, speedKmh)), nil
+}
+
+// ItineraryConflicts checks the activities of a single day
+func (s *ConflictService) ItineraryConflicts(ctx context.Context, itineraryID string, speedKmh float64) ([]schedule.Conflict, error) {
+	itinerary, err := s.ItineraryRepo.GetByID(ctx, itineraryID)
+	if err != nil {
+		return nil, errors.New("itinerary not found")
+	}
+	trip, err := s.TripRepo.GetByID(ctx, itinerary.TripID)
+	if err != nil {
+		return nil, errors.New("trip not found")
+	}
+	activities, err := s.loadSchedule(ctx, trip)
+	if err != nil {
+		return nil, err
+	}
+
+	var day []schedule.Item
+	for _, it := range activities.items() {
+		if it.ItineraryID != nil && *it.ItineraryID == itineraryID {
+			day = append(day, it)
+		}
+	}
+	return schedule.Detect(day, s.options(trip, speedKmh)), nil
+}
+
+// CheckActivity returns the conflicts the activity would have if it were
+// saved as is. It works for new activities (empty ID) and updates alike.
+func (s *ConflictService) CheckActivity(ctx context.Context, activity *domain.Activity) ([]schedule.Conflict, error) {
+	trip, err := s.TripRepo.GetByID(ctx, activity.TripID)
+	if err != nil {
+		return nil, errors.New("trip not found")
+	}
+	sched, err := s.loadSchedule(ctx, trip)
+	if err != nil {
+		return nil, err
+	}
+
+	candidate := repository.ScheduledActivity{Activity: *activity}
+	if candidate.ID == "" {
+		candidate.ID = "new"
+	}
+	if activity.ItineraryID != nil {
+		itinerary, err := s.ItineraryRepo.GetByID(ctx, *activity.ItineraryID)
+		if err != nil {
+			return nil, errors.New("itinerary not found")
+		}
+		candidate.ItineraryDate = &itinerary.Date
+	}
+
+	// Drop the stored version of an existing activity
+	others := sched.activities[:0]
+	for _, a := range sched.activities {
+		if a.ID == activity.ID {
+			continue
+		}
+		others = append(others, a)
+	}
+	sched.activities = append(others, candidate)
+
+	var involved []schedule.Conflict
+	for _, c := range schedule.Detect(sched.items(), s.options(trip, 0)) {
+		if c.Involves(candidate.ID) {
+			involved = append(involved, c)
+		}
+	}
+	return involved, nil
+}
+
+func (s *ConflictService) options(trip *domain.Trip, speedKmh float64) schedule.Options {
+	return schedule.Options{
+		TripStart: trip.StartDate,
+		TripEnd:   trip.EndDate,
+		SpeedKmh:  s.speed(speedKmh),
+	}
+}
+
+// tripSchedule is what the conflict checker needs to know about a trip
+type tripSchedule struct {
+	activities  []repository.ScheduledActivity
+	overrides   map[string]domain.ActivityOverride // keyed "activityID/date"
+	itineraries []domain.Itinerary
+	tripEnd     time.Time
+}
+
+func (s *ConflictService) loadSchedule(ctx context.Context, trip *domain.Trip) (*tripSchedule, error) {
+	activities, err := s.ActivityRepo.GetScheduleByTripID(ctx, trip.ID)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := s.ActivityRepo.GetOverridesByTripID(ctx, trip.ID)
+	if err != nil {
+		return nil, err
+	}
+	itineraries, err := s.ItineraryRepo.GetByTripID(ctx, trip.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &tripSchedule{activities: activities, overrides: overridesByKey(overrides), itineraries: itineraries, tripEnd: trip.EndDate}, nil
+}
+
+// items returns an item for every day an activity occurs on, so multi-day
+// and recurring activities are checked against everything on each of their
+// days. A multi-day activity takes up the whole of its middle days, and its
+// first and last days from its start and until its end.
+func (t *tripSchedule) items() []schedule.Item {
+	days := make(map[string]*string, len(t.itineraries))
+	for i := range t.itineraries {
+		days[dateKey(t.itineraries[i].Date)] = &t.itineraries[i].ID
+	}
+
+	var items []schedule.Item
+	for _, a := range t.activities {
+		var point *geo.Point
+		if a.Place != nil {
+			point = &geo.Point{Lat: a.Place.Latitude, Lng: a.Place.Longitude}
+		}
+		if a.ItineraryDate == nil {
+			items = append(items, schedule.Item{ID: a.ID, Name: a.Name, ItineraryID: a.ItineraryID, Start: a.StartTime, End: a.EndTime, Point: point})
+			continue
+		}
+
+		zone := time.UTC
+		if a.Place != nil {
+			zone = geo.LoadZone(a.Place.Timezone)
+		}
+		timed := a.StartTime != nil || a.EndTime != nil
+		for _, occ := range expandActivity(a.Activity, *a.ItineraryDate, t.tripEnd, t.overrides) {
+			date := occ.Date
+			it := schedule.Item{ID: a.ID, Name: occ.Name, ItineraryID: days[dateKey(date)], ItineraryDate: &date, Start: occ.StartTime, End: occ.EndTime, Point: point}
+			if occ.Occurrence == 1 {
+				it.ItineraryID = a.ItineraryID
+			}
+			if a.Recurrence == nil && occ.Total > 1 && timed {
+				dayStart := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, zone)
+				dayEnd := dayStart.AddDate(0, 0, 1)
+				if it.Start == nil {
+					it.Start = &dayStart
+				}
+				if it.End == nil {
+					it.End = &dayEnd
+				}
+			}
+			items = append(items, it)
+		}
+	}
+	return items
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/repository"
+	"github.com/NoahFola/travel_app_backend/internal/schedule"
+)
+
+func TestTripScheduleConflicts(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC) }
+	clock := func(d, hour int) *time.Time { t := time.Date(2024, 5, d, hour, 0, 0, 0, time.UTC); return &t }
+	ids := []string{"day-1", "day-2", "day-3", "day-4"}
+	var itineraries []domain.Itinerary
+	for i, id := range ids {
+		itineraries = append(itineraries, domain.Itinerary{ID: id, Date: day(i + 1)})
+	}
+	scheduled := func(a domain.Activity, d int) repository.ScheduledActivity {
+		a.ItineraryID = &ids[d-1]
+		date := day(d)
+		return repository.ScheduledActivity{Activity: a, ItineraryDate: &date}
+	}
+	lastDay, daily := day(4), "FREQ=DAILY"
+
+	tests := []struct {
+		name       string
+		activities []repository.ScheduledActivity
+		overrides  []domain.ActivityOverride
+		want       [][]string
+	}{
+		{
+			name: "during a stay",
+			activities: []repository.ScheduledActivity{
+				scheduled(domain.Activity{ID: "hotel", StartTime: clock(1, 15), EndTime: clock(4, 11), EndDate: &lastDay}, 1),
+				scheduled(domain.Activity{ID: "dinner", StartTime: clock(2, 19), EndTime: clock(2, 21)}, 2),
+			},
+			want: [][]string{{"hotel", "dinner"}},
+		},
+		{
+			name: "after checking out",
+			activities: []repository.ScheduledActivity{
+				scheduled(domain.Activity{ID: "hotel", StartTime: clock(1, 15), EndTime: clock(4, 11), EndDate: &lastDay}, 1),
+				scheduled(domain.Activity{ID: "museum", StartTime: clock(4, 12), EndTime: clock(4, 14)}, 4),
+			},
+			want: nil,
+		},
+		{
+			name: "a later repetition",
+			activities: []repository.ScheduledActivity{
+				scheduled(domain.Activity{ID: "yoga", StartTime: clock(1, 8), EndTime: clock(1, 9), Recurrence: &daily}, 1),
+				scheduled(domain.Activity{ID: "tour", StartTime: clock(3, 8), EndTime: clock(3, 12)}, 3),
+			},
+			want: [][]string{{"yoga", "tour"}},
+		},
+		{
+			name: "cancelled repetition",
+			activities: []repository.ScheduledActivity{
+				scheduled(domain.Activity{ID: "yoga", StartTime: clock(1, 8), EndTime: clock(1, 9), Recurrence: &daily}, 1),
+				scheduled(domain.Activity{ID: "tour", StartTime: clock(3, 8), EndTime: clock(3, 12)}, 3),
+			},
+			overrides: []domain.ActivityOverride{{ActivityID: "yoga", Date: day(3), Cancelled: true}},
+			want:      nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sched := &tripSchedule{activities: tt.activities, overrides: overridesByKey(tt.overrides), itineraries: itineraries, tripEnd: day(4)}
+			var got [][]string
+			for _, c := range schedule.Detect(sched.items(), schedule.Options{TripStart: day(1), TripEnd: day(4)}) {
+				if c.Type != schedule.ConflictOverlap {
+					t.Errorf("unexpected %s conflict: %s", c.Type, c.Message)
+					continue
+				}
+				got = append(got, c.ActivityIDs)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/currency"
+	"github.com/NoahFola/travel_app_backend/internal/money"
+	"github.com/NoahFola/travel_app_backend/internal/repository"
+)
+
+type CurrencyService struct {
+	Repo *repository.RateRepository
+}
+
+// Conversion describes a converted amount and the rate that was used
+type Conversion struct {
+	From           string    `json:"from"`
+	To             string    `json:"to"`
+	AmountMinor    int64     `json:"amount_minor"`
+	Amount         string    `json:"amount"`
+	ConvertedMinor int64     `json:"converted_minor"`
+	Converted      string    `json:"converted"`
+	Rate           string    `json:"rate"`
+	RateDate       time.Time `json:"rate_date"`
+}
+
+// Rate returns how many units of `to` one unit of `from` bought on date,
+// using the latest published rate on or before that day. Cross rates are
+// derived through the base currency.
+func (s *CurrencyService) Rate(ctx context.Context, from, to string, date time.Time) (*big.Rat, time.Time, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return big.NewRat(1, 1), date, nil
+	}
+
+	rates, err := s.Repo.GetLatest(ctx, currency.DefaultBase, []string{from, to}, date)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	base := currency.Rate{Date: date, Value: big.NewRat(1, 1)}
+	lookup := func(code string) (currency.Rate, bool) {
+		if code == currency.DefaultBase {
+			return base, true
+		}
+		r, ok := rates[code]
+		return r, ok
+	}
+
+	fromRate, ok := lookup(from)
+	if !ok {
+		return nil, time.Time{}, currency.ErrRateNotFound
+	}
+	toRate, ok := lookup(to)
+	if !ok {
+		return nil, time.Time{}, currency.ErrRateNotFound
+	}
+
+	// Report the older of the two publication dates
+	rateDate := fromRate.Date
+	if toRate.Date.Before(rateDate) {
+		rateDate = toRate.Date
+	}
+	return currency.CrossRate(fromRate.Value, toRate.Value), rateDate, nil
+}
+
+// Convert converts an amount in from's minor units into to's minor units
+func (s *CurrencyService) Convert(ctx context.Context, amountMinor int64, from, to string, date time.Time) (*Conversion, error) {
+	rate, rateDate, err := s.Rate(ctx, from, to, date)
+	if err != nil {
+		return nil, err
+	}
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	converted := currency.Convert(amountMinor, from, to, rate)
+	return &Conversion{
+		From:           from,
+		To:             to,
+		AmountMinor:    amountMinor,
+		Amount:         money.Format(amountMinor, from),
+		ConvertedMinor: converted,
+		Converted:      money.Format(converted, to),
+		Rate:           rate.FloatString(10),
+		RateDate:       rateDate,
+	}, nil
+}
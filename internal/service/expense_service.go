@@ -0,0 +1,382 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/money"
+	"github.com/NoahFola/travel_app_backend/internal/repository"
+)
+
+// ErrInvalidExpense wraps every validation failure so handlers can answer 400
+var ErrInvalidExpense = errors.New("invalid expense")
+
+// ErrExpenseNotFound is returned for expenses of other trips too
+var ErrExpenseNotFound = errors.New("expense not found")
+
+type ExpenseService struct {
+	Repo            *repository.ExpenseRepository
+	ParticipantRepo *repository.ParticipantRepository
+	TripRepo        *repository.TripRepository
+	ActivityRepo    *repository.ActivityRepository
+	Currency        *CurrencyService
+}
+
+func invalidExpense(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidExpense, fmt.Sprintf(format, args...))
+}
+
+// CreateExpense validates the expense against the trip's participants and
+// fills in the split amounts according to SplitMethod.
+// For "equal" with no splits, the cost is shared by every participant.
+// userID must take part in the trip.
+func (s *ExpenseService) CreateExpense(ctx context.Context, e *domain.Expense, userID string) error {
+	if err := s.checkTrip(ctx, e.TripID, userID); err != nil {
+		return err
+	}
+
+	e.Currency = strings.ToUpper(e.Currency)
+	if !money.ValidCurrency(e.Currency) {
+		return invalidExpense("currency must be a 3-letter ISO code")
+	}
+	if e.AmountMinor <= 0 {
+		return invalidExpense("amount must be positive")
+	}
+	if strings.TrimSpace(e.Description) == "" {
+		return invalidExpense("description is required")
+	}
+
+	participants, err := s.ParticipantRepo.GetByTripID(ctx, e.TripID)
+	if err != nil {
+		return err
+	}
+	members := make(map[string]bool, len(participants))
+	for _, p := range participants {
+		members[p.ID] = true
+	}
+	if !members[e.PaidBy] {
+		return invalidExpense("paid_by is not a participant of this trip")
+	}
+
+	if e.ActivityID != nil {
+		activity, err := s.ActivityRepo.GetByID(ctx, *e.ActivityID)
+		if err != nil || activity.TripID != e.TripID {
+			return invalidExpense("activity does not belong to this trip")
+		}
+	}
+
+	if e.SplitMethod == "" {
+		e.SplitMethod = domain.SplitEqual
+	}
+	if e.SplitMethod == domain.SplitEqual && len(e.Splits) == 0 {
+		for _, p := range participants {
+			e.Splits = append(e.Splits, domain.ExpenseSplit{ParticipantID: p.ID})
+		}
+	}
+	if len(e.Splits) == 0 {
+		return invalidExpense("at least one split is required")
+	}
+
+	seen := make(map[string]bool, len(e.Splits))
+	for _, split := range e.Splits {
+		if !members[split.ParticipantID] {
+			return invalidExpense("participant %s is not part of this trip", split.ParticipantID)
+		}
+		if seen[split.ParticipantID] {
+			return invalidExpense("participant %s appears twice in splits", split.ParticipantID)
+		}
+		seen[split.ParticipantID] = true
+	}
+
+	if err := allocateSplits(e); err != nil {
+		return err
+	}
+
+	if e.Kind == "" {
+		e.Kind = domain.ExpenseKindExpense
+	}
+	if e.IncurredAt.IsZero() {
+		e.IncurredAt = time.Now()
+	}
+
+	if err := s.Repo.Create(ctx, e); err != nil {
+		return err
+	}
+	formatExpense(e)
+	return nil
+}
+
+// allocateSplits computes each split's AmountMinor from the split method
+func allocateSplits(e *domain.Expense) error {
+	switch e.SplitMethod {
+	case domain.SplitEqual, domain.SplitShares:
+		weights := make([]int64, len(e.Splits))
+		for i, split := range e.Splits {
+			weights[i] = 1
+			if e.SplitMethod == domain.SplitShares {
+				if split.Shares == nil || *split.Shares <= 0 {
+					return invalidExpense("every split needs a positive shares value")
+				}
+				weights[i] = int64(*split.Shares)
+			} else {
+				e.Splits[i].Shares = nil
+			}
+		}
+		parts, err := money.Allocate(e.AmountMinor, weights)
+		if err != nil {
+			return invalidExpense("%s", err.Error())
+		}
+		for i := range e.Splits {
+			e.Splits[i].AmountMinor = parts[i]
+		}
+	case domain.SplitExact:
+		var sum int64
+		for i, split := range e.Splits {
+			if split.AmountMinor < 0 {
+				return invalidExpense("split amounts cannot be negative")
+			}
+			e.Splits[i].Shares = nil
+			sum += split.AmountMinor
+		}
+		if sum != e.AmountMinor {
+			return invalidExpense("split amounts add up to %s, expected %s",
+				money.Format(sum, e.Currency), money.Format(e.AmountMinor, e.Currency))
+		}
+	default:
+		return invalidExpense("split_method must be one of equal, shares, exact")
+	}
+	return nil
+}
+
+func formatExpense(e *domain.Expense) {
+	e.Amount = money.Format(e.AmountMinor, e.Currency)
+	for i := range e.Splits {
+		e.Splits[i].Amount = money.Format(e.Splits[i].AmountMinor, e.Currency)
+	}
+}
+
+// checkTrip checks the trip exists and userID takes part in it
+func (s *ExpenseService) checkTrip(ctx context.Context, tripID, userID string) error {
+	if _, err := s.TripRepo.GetByID(ctx, tripID); err != nil {
+		return ErrTripNotFound
+	}
+	return requireParticipant(ctx, s.ParticipantRepo, tripID, userID)
+}
+
+func (s *ExpenseService) GetExpense(ctx context.Context, tripID, id, userID string) (*domain.Expense, error) {
+	if err := s.checkTrip(ctx, tripID, userID); err != nil {
+		return nil, err
+	}
+	e, err := s.Repo.GetByID(ctx, id)
+	if err != nil || e.TripID != tripID {
+		return nil, ErrExpenseNotFound
+	}
+	formatExpense(e)
+	return e, nil
+}
+
+func (s *ExpenseService) ListExpenses(ctx context.Context, tripID, userID string) ([]domain.Expense, error) {
+	if err := s.checkTrip(ctx, tripID, userID); err != nil {
+		return nil, err
+	}
+	expenses, err := s.Repo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range expenses {
+		formatExpense(&expenses[i])
+	}
+	return expenses, nil
+}
+
+func (s *ExpenseService) DeleteExpense(ctx context.Context, tripID, id, userID string) error {
+	if err := s.checkTrip(ctx, tripID, userID); err != nil {
+		return err
+	}
+	deleted, err := s.Repo.Delete(ctx, tripID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrExpenseNotFound
+	}
+	return nil
+}
+
+// RecordSettlement stores a payment from one participant to another as its
+// own entry: the payer "paid" the amount and the receiver "consumed" it.
+func (s *ExpenseService) RecordSettlement(ctx context.Context, tripID, userID, from, to string, amountMinor int64, currency string) (*domain.Expense, error) {
+	if from == to {
+		return nil, invalidExpense("cannot settle with yourself")
+	}
+	e := &domain.Expense{
+		TripID:      tripID,
+		PaidBy:      from,
+		Kind:        domain.ExpenseKindSettlement,
+		Description: "Settlement",
+		AmountMinor: amountMinor,
+		Currency:    currency,
+		SplitMethod: domain.SplitExact,
+		Splits:      []domain.ExpenseSplit{{ParticipantID: to, AmountMinor: amountMinor}},
+	}
+	if err := s.CreateExpense(ctx, e, userID); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Balances returns every participant's net position per currency together
+// with the transfers that would bring everyone back to zero.
+// If target is set, every expense is first converted into that currency
+// using the rate of the day it was incurred.
+func (s *ExpenseService) Balances(ctx context.Context, tripID, userID, target string) ([]domain.Balance, []domain.Transfer, error) {
+	expenses, err := s.ListExpenses(ctx, tripID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if target != "" {
+		target = strings.ToUpper(target)
+		for i := range expenses {
+			if err := s.convertExpense(ctx, &expenses[i], target); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	participants, err := s.ParticipantRepo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nets := computeNets(expenses)
+
+	currencies := make([]string, 0, len(nets))
+	for currency := range nets {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	balances := []domain.Balance{}
+	transfers := []domain.Transfer{}
+	for _, currency := range currencies {
+		for _, p := range participants {
+			net := nets[currency][p.ID]
+			balances = append(balances, domain.Balance{
+				ParticipantID: p.ID,
+				Currency:      currency,
+				NetMinor:      net,
+				Net:           money.Format(net, currency),
+			})
+		}
+		transfers = append(transfers, settleUp(nets[currency], currency)...)
+	}
+	return balances, transfers, nil
+}
+
+// convertExpense rewrites an expense in another currency. Splits are
+// re-allocated from the converted total so they still add up exactly.
+func (s *ExpenseService) convertExpense(ctx context.Context, e *domain.Expense, target string) error {
+	if e.Currency == target {
+		return nil
+	}
+	conversion, err := s.Currency.Convert(ctx, e.AmountMinor, e.Currency, target, e.IncurredAt)
+	if err != nil {
+		return fmt.Errorf("converting %s to %s: %w", e.Currency, target, err)
+	}
+
+	var weights []int64
+	var indexes []int
+	for i, split := range e.Splits {
+		e.Splits[i].AmountMinor = 0
+		if split.AmountMinor > 0 {
+			weights = append(weights, split.AmountMinor)
+			indexes = append(indexes, i)
+		}
+	}
+	if len(weights) > 0 {
+		parts, err := money.Allocate(conversion.ConvertedMinor, weights)
+		if err != nil {
+			return err
+		}
+		for k, i := range indexes {
+			e.Splits[i].AmountMinor = parts[k]
+		}
+	}
+
+	e.AmountMinor = conversion.ConvertedMinor
+	e.Currency = target
+	formatExpense(e)
+	return nil
+}
+
+// computeNets returns currency -> participant -> net amount in minor units
+func computeNets(expenses []domain.Expense) map[string]map[string]int64 {
+	nets := make(map[string]map[string]int64)
+	for _, e := range expenses {
+		if nets[e.Currency] == nil {
+			nets[e.Currency] = make(map[string]int64)
+		}
+		nets[e.Currency][e.PaidBy] += e.AmountMinor
+		for _, split := range e.Splits {
+			nets[e.Currency][split.ParticipantID] -= split.AmountMinor
+		}
+	}
+	return nets
+}
+
+// settleUp greedily pairs the largest debtor with the largest creditor.
+// This yields at most n-1 transfers, which is what users expect from a
+// settle-up screen (the exact minimum is NP-hard).
+func settleUp(nets map[string]int64, currency string) []domain.Transfer {
+	type position struct {
+		id     string
+		amount int64
+	}
+	var creditors, debtors []position
+	for id, net := range nets {
+		if net > 0 {
+			creditors = append(creditors, position{id, net})
+		} else if net < 0 {
+			debtors = append(debtors, position{id, -net})
+		}
+	}
+	byAmount := func(p []position) func(i, j int) bool {
+		return func(i, j int) bool {
+			if p[i].amount != p[j].amount {
+				return p[i].amount > p[j].amount
+			}
+			return p[i].id < p[j].id
+		}
+	}
+	sort.Slice(creditors, byAmount(creditors))
+	sort.Slice(debtors, byAmount(debtors))
+
+	var transfers []domain.Transfer
+	i, j := 0, 0
+	for i < len(debtors) && j < len(creditors) {
+		amount := debtors[i].amount
+		if creditors[j].amount < amount {
+			amount = creditors[j].amount
+		}
+		transfers = append(transfers, domain.Transfer{
+			From:        debtors[i].id,
+			To:          creditors[j].id,
+			Currency:    currency,
+			AmountMinor: amount,
+			Amount:      money.Format(amount, currency),
+		})
+		debtors[i].amount -= amount
+		creditors[j].amount -= amount
+		if debtors[i].amount == 0 {
+			i++
+		}
+		if creditors[j].amount == 0 {
+			j++
+		}
+	}
+	return transfers
+}
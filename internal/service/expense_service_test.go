@@ -0,0 +1,138 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+)
+
+func TestAllocateSplits(t *testing.T) {
+	two, one := 2, 1
+	tests := []struct {
+		name    string
+		expense domain.Expense
+		want    []int64
+		wantErr bool
+	}{
+		{
+			name: "equal",
+			expense: domain.Expense{AmountMinor: 1000, Currency: "EUR", SplitMethod: domain.SplitEqual,
+				Splits: []domain.ExpenseSplit{{ParticipantID: "a"}, {ParticipantID: "b"}, {ParticipantID: "c"}}},
+			want: []int64{334, 333, 333},
+		},
+		{
+			name: "shares",
+			expense: domain.Expense{AmountMinor: 900, Currency: "EUR", SplitMethod: domain.SplitShares,
+				Splits: []domain.ExpenseSplit{{ParticipantID: "a", Shares: &two}, {ParticipantID: "b", Shares: &one}}},
+			want: []int64{600, 300},
+		},
+		{
+			name: "shares missing",
+			expense: domain.Expense{AmountMinor: 900, Currency: "EUR", SplitMethod: domain.SplitShares,
+				Splits: []domain.ExpenseSplit{{ParticipantID: "a", Shares: &two}, {ParticipantID: "b"}}},
+			wantErr: true,
+		},
+		{
+			name: "exact",
+			expense: domain.Expense{AmountMinor: 1000, Currency: "EUR", SplitMethod: domain.SplitExact,
+				Splits: []domain.ExpenseSplit{{ParticipantID: "a", AmountMinor: 700}, {ParticipantID: "b", AmountMinor: 300}}},
+			want: []int64{700, 300},
+		},
+		{
+			name: "exact not adding up",
+			expense: domain.Expense{AmountMinor: 1000, Currency: "EUR", SplitMethod: domain.SplitExact,
+				Splits: []domain.ExpenseSplit{{ParticipantID: "a", AmountMinor: 700}, {ParticipantID: "b", AmountMinor: 200}}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown method",
+			expense: domain.Expense{AmountMinor: 1000, Currency: "EUR", SplitMethod: "percent"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := tt.expense
+			err := allocateSplits(&e)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidExpense) {
+					t.Fatalf("got error %v, want ErrInvalidExpense", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]int64, len(e.Splits))
+			for i, split := range e.Splits {
+				got[i] = split.AmountMinor
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got splits %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSettleUp(t *testing.T) {
+	tests := []struct {
+		name string
+		nets map[string]int64
+		want []domain.Transfer
+	}{
+		{
+			name: "settled",
+			nets: map[string]int64{"a": 0, "b": 0},
+		},
+		{
+			name: "one debtor",
+			nets: map[string]int64{"a": 1500, "b": -1500},
+			want: []domain.Transfer{{From: "b", To: "a", Currency: "EUR", AmountMinor: 1500, Amount: "15.00"}},
+		},
+		{
+			name: "largest debtor pays the largest creditor first",
+			nets: map[string]int64{"a": 2000, "b": 1000, "c": -2500, "d": -500},
+			want: []domain.Transfer{
+				{From: "c", To: "a", Currency: "EUR", AmountMinor: 2000, Amount: "20.00"},
+				{From: "c", To: "b", Currency: "EUR", AmountMinor: 500, Amount: "5.00"},
+				{From: "d", To: "b", Currency: "EUR", AmountMinor: 500, Amount: "5.00"},
+			},
+		},
+		{
+			name: "ties are broken by participant",
+			nets: map[string]int64{"b": 100, "a": 100, "c": -200},
+			want: []domain.Transfer{
+				{From: "c", To: "a", Currency: "EUR", AmountMinor: 100, Amount: "1.00"},
+				{From: "c", To: "b", Currency: "EUR", AmountMinor: 100, Amount: "1.00"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := settleUp(tt.nets, "EUR")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeNets(t *testing.T) {
+	expenses := []domain.Expense{
+		{PaidBy: "a", AmountMinor: 900, Currency: "EUR", Splits: []domain.ExpenseSplit{
+			{ParticipantID: "a", AmountMinor: 300}, {ParticipantID: "b", AmountMinor: 300}, {ParticipantID: "c", AmountMinor: 300},
+		}},
+		{PaidBy: "b", AmountMinor: 1000, Currency: "JPY", Splits: []domain.ExpenseSplit{
+			{ParticipantID: "a", AmountMinor: 1000},
+		}},
+	}
+	want := map[string]map[string]int64{
+		"EUR": {"a": 600, "b": -300, "c": -300},
+		"JPY": {"a": -1000, "b": 1000},
+	}
+	if got := computeNets(expenses); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/inbound"
+	"github.com/NoahFola/travel_app_backend/internal/money"
+	"github.com/NoahFola/travel_app_backend/internal/repository"
+)
+
+// DefaultInboundDomain is used when INBOUND_EMAIL_DOMAIN is not set
+const DefaultInboundDomain = "inbound.localhost"
+
+// ErrNoBookings is returned for emails without schema.org reservations
+var ErrNoBookings = errors.New("no flight, hotel or car rental bookings found in email")
+
+// ErrUnknownRecipient is returned when no recipient is a known inbound address
+var ErrUnknownRecipient = errors.New("no recipient matches an inbound address")
+
+// InboundService turns forwarded confirmation emails into draft activities.
+// Bookings whose date falls within one of the user's trips are placed on
+// that day; the rest wait in the user's inbox.
+type InboundService struct {
+	Repo            *repository.InboxRepository
+	TripRepo        *repository.TripRepository
+	ItineraryRepo   *repository.ItineraryRepository
+	ParticipantRepo *repository.ParticipantRepository
+	Reservations    *ReservationService
+	Domain          string // host part of inbound addresses
+}
+
+// Address returns the user's inbound address, creating it on first use
+func (s *InboundService) Address(ctx context.Context, userID string) (*domain.InboundAddress, error) {
+	addr, err := s.Repo.GetAddress(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if addr == nil {
+		return s.RotateAddress(ctx, userID)
+	}
+	addr.Address = s.formatAddress(addr.Token)
+	return addr, nil
+}
+
+// RotateAddress replaces the user's inbound address, e.g. after it leaked
+func (s *InboundService) RotateAddress(ctx context.Context, userID string) (*domain.InboundAddress, error) {
+	bytes := make([]byte, 10)
+	if _, err := rand.Read(bytes); err != nil {
+		return nil, err
+	}
+	addr, err := s.Repo.SetAddress(ctx, userID, hex.EncodeToString(bytes))
+	if err != nil {
+		return nil, err
+	}
+	addr.Address = s.formatAddress(addr.Token)
+	return addr, nil
+}
+
+func (s *InboundService) formatAddress(token string) string {
+	return token + "@" + s.domain()
+}
+
+func (s *InboundService) domain() string {
+	if s.Domain == "" {
+		return DefaultInboundDomain
+	}
+	return strings.ToLower(s.Domain)
+}
+
+// Receive ingests an email delivered to one of our inbound addresses
+func (s *InboundService) Receive(ctx context.Context, raw io.Reader) ([]domain.InboxItem, error) {
+	email, err := inbound.ParseEmail(raw)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := s.userForRecipients(ctx, email.Recipients)
+	if err != nil {
+		return nil, err
+	}
+	return s.ingest(ctx, userID, email)
+}
+
+// Upload ingests a .eml file uploaded by the user
+func (s *InboundService) Upload(ctx context.Context, userID string, raw io.Reader) ([]domain.InboxItem, error) {
+	email, err := inbound.ParseEmail(raw)
+	if err != nil {
+		return nil, err
+	}
+	return s.ingest(ctx, userID, email)
+}
+
+// userForRecipients finds the user whose token is the local part of a
+// recipient on our domain. Plus-suffixes ("token+tag@") are ignored.
+func (s *InboundService) userForRecipients(ctx context.Context, recipients []string) (string, error) {
+	suffix := "@" + s.domain()
+	for _, r := range recipients {
+		if !strings.HasSuffix(r, suffix) {
+			continue
+		}
+		token, _, _ := strings.Cut(strings.TrimSuffix(r, suffix), "+")
+		userID, err := s.Repo.GetUserIDByToken(ctx, token)
+		if err == nil {
+			return userID, nil
+		}
+	}
+	return "", ErrUnknownRecipient
+}
+
+// ingest stores every new booking of the email and places the ones that
+// match a trip. Bookings already received are skipped.
+func (s *InboundService) ingest(ctx context.Context, userID string, email *inbound.Email) ([]domain.InboxItem, error) {
+	var bookings []domain.ParsedBooking
+	for _, body := range email.HTML {
+		bookings = append(bookings, inbound.ExtractBookings(body)...)
+	}
+	if len(bookings) == 0 {
+		return nil, ErrNoBookings
+	}
+
+	trips, err := s.TripRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	receivedAt := time.Now()
+	if email.Date != nil {
+		receivedAt = *email.Date
+	}
+
+	items := []domain.InboxItem{}
+	for _, b := range bookings {
+		item := domain.InboxItem{
+			UserID:     userID,
+			Status:     domain.InboxPending,
+			Subject:    optionalString(email.Subject),
+			Sender:     optionalString(email.From),
+			Booking:    b,
+			ReceivedAt: receivedAt,
+		}
+		created, err := s.Repo.CreateItem(ctx, &item, inbound.Fingerprint(b))
+		if err != nil {
+			return nil, err
+		}
+		if !created {
+			continue
+		}
+
+		if trip := matchTrip(trips, b); trip != nil {
+			// A booking we can't turn into a valid reservation stays in the inbox
+			if err := s.place(ctx, &item, trip); err != nil && !errors.Is(err, ErrInvalidReservation) {
+				return nil, err
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// ListInbox returns the user's items with status (pending by default)
+func (s *InboundService) ListInbox(ctx context.Context, userID, status string) ([]domain.InboxItem, error) {
+	if status == "" {
+		status = domain.InboxPending
+	}
+	switch status {
+	case domain.InboxPending, domain.InboxAssigned, domain.InboxDismissed:
+	default:
+		return nil, fmt.Errorf("unknown status %q", status)
+	}
+	return s.Repo.ListItems(ctx, userID, status)
+}
+
+// AssignItem places a pending item on a trip the user owns or takes part in
+func (s *InboundService) AssignItem(ctx context.Context, userID, itemID, tripID string) (*domain.InboxItem, error) {
+	item, err := s.pendingItem(ctx, userID, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	trip, err := s.TripRepo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, errors.New("trip not found")
+	}
+	if trip.UserID != userID {
+		p, err := s.ParticipantRepo.GetByTripAndUser(ctx, tripID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			return nil, errors.New("trip not found")
+		}
+	}
+
+	if err := s.place(ctx, item, trip); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// DismissItem hides a pending item from the inbox
+func (s *InboundService) DismissItem(ctx context.Context, userID, itemID string) error {
+	item, err := s.pendingItem(ctx, userID, itemID)
+	if err != nil {
+		return err
+	}
+	item.Status = domain.InboxDismissed
+	return s.Repo.UpdateItem(ctx, item)
+}
+
+func (s *InboundService) pendingItem(ctx context.Context, userID, itemID string) (*domain.InboxItem, error) {
+	item, err := s.Repo.GetItem(ctx, itemID)
+	if err != nil || item.UserID != userID {
+		return nil, errors.New("inbox item not found")
+	}
+	if item.Status != domain.InboxPending {
+		return nil, fmt.Errorf("inbox item is already %s", item.Status)
+	}
+	return item, nil
+}
+
+// place creates the draft activity and its reservation on the booking's day
+// of the trip, adding the day if the trip doesn't have it yet. Either all of
+// them are saved or none.
+func (s *InboundService) place(ctx context.Context, item *domain.InboxItem, trip *domain.Trip) error {
+	b := item.Booking
+	res := &domain.Reservation{
+		TripID:             trip.ID,
+		Type:               b.Kind,
+		Provider:           optionalString(b.Provider),
+		ConfirmationNumber: optionalString(b.ConfirmationNumber),
+		StartsAt:           b.StartsAt,
+		EndsAt:             b.EndsAt,
+		Details:            b.Details,
+	}
+	if b.Cost != "" && b.Currency != "" {
+		currency := strings.ToUpper(b.Currency)
+		if cost, err := money.Parse(b.Cost, currency); err == nil {
+			res.CostMinor = &cost
+			res.Currency = &currency
+		}
+	}
+	if err := s.Reservations.validate(ctx, res, nil); err != nil {
+		return err
+	}
+
+	date := bookingDate(b)
+	itinerary, err := s.ItineraryRepo.GetByTripAndDate(ctx, trip.ID, date)
+	if err != nil {
+		return err
+	}
+	if itinerary == nil {
+		itinerary = &domain.Itinerary{TripID: trip.ID, Slug: daySlug(trip, date), Date: date}
+	}
+
+	kind := b.Kind
+	activity := &domain.Activity{
+		TripID:   trip.ID,
+		Name:     b.Name,
+		Location: optionalString(b.Location),
+		Type:     &kind,
+		Status:   domain.ActivityIdea,
+	}
+	if !b.AllDay {
+		activity.StartTime = b.StartsAt
+		// Multi-day bookings like hotel stays only keep their start on the day
+		if b.EndsAt != nil && dateOnly(*b.EndsAt).Equal(date) {
+			activity.EndTime = b.EndsAt
+		}
+	}
+	// and are shown on every day through the end of the booking
+	if b.EndsAt != nil && dateOnly(*b.EndsAt).After(date) {
+		end := dateOnly(*b.EndsAt)
+		activity.EndDate = &end
+	}
+	if err := checkSchedule(activity, &date); err != nil {
+		return err
+	}
+	return s.Repo.Place(ctx, item, itinerary, activity, res)
+}
+
+// matchTrip returns the shortest trip whose dates cover the booking's day
+func matchTrip(trips []domain.Trip, b domain.ParsedBooking) *domain.Trip {
+	date := bookingDate(b)
+	var best *domain.Trip
+	for i := range trips {
+		t := &trips[i]
+		if date.Before(dateOnly(t.StartDate)) || date.After(dateOnly(t.EndDate)) {
+			continue
+		}
+		if best == nil || t.EndDate.Sub(t.StartDate) < best.EndDate.Sub(best.StartDate) {
+			best = t
+		}
+	}
+	return best
+}
+
+// bookingDate is the local calendar date the booking starts on, as UTC midnight
+func bookingDate(b domain.ParsedBooking) time.Time {
+	return dateOnly(*b.StartsAt)
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// daySlug names a new itinerary "Day N" after its position in the trip
+func daySlug(trip *domain.Trip, date time.Time) string {
+	day := int(date.Sub(dateOnly(trip.StartDate)).Hours()/24) + 1
+	if day < 1 {
+		return date.Format("Jan 2")
+	}
+	return fmt.Sprintf("Day %d", day)
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
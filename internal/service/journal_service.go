@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/repository"
+	"github.com/NoahFola/travel_app_backend/internal/storage"
+)
+
+type JournalService struct {
+	Repo            *repository.JournalRepository
+	TripRepo        *repository.TripRepository
+	ItineraryRepo   *repository.ItineraryRepository
+	LocationRepo    *repository.LocationRepository
+	MediaRepo       *repository.MediaRepository
+	ParticipantRepo *repository.ParticipantRepository
+	Blobs           storage.BlobStore
+}
+
+var journalMoods = map[string]bool{
+	"happy": true, "excited": true, "relaxed": true, "adventurous": true,
+	"grateful": true, "tired": true, "homesick": true, "sad": true,
+}
+
+var journalWeather = map[string]bool{
+	"sunny": true, "partly_cloudy": true, "cloudy": true, "rainy": true,
+	"stormy": true, "snowy": true, "windy": true, "foggy": true, "hot": true, "cold": true,
+}
+
+// CreateEntry adds an entry written by e.AuthorID, who must take part in the trip
+func (s *JournalService) CreateEntry(ctx context.Context, e *domain.JournalEntry, mediaIDs []string) error {
+	if _, err := s.TripRepo.GetByID(ctx, e.TripID); err != nil {
+		return errors.New("trip not found")
+	}
+	if e.AuthorID == nil {
+		return ErrNotParticipant
+	}
+	if err := requireParticipant(ctx, s.ParticipantRepo, e.TripID, *e.AuthorID); err != nil {
+		return err
+	}
+	if e.EntryTime.IsZero() {
+		e.EntryTime = time.Now()
+	}
+	if err := s.validate(ctx, e, mediaIDs); err != nil {
+		return err
+	}
+	if err := s.Repo.Create(ctx, e, mediaIDs); err != nil {
+		return err
+	}
+	return s.reload(ctx, e)
+}
+
+// GetEntry returns an entry of a trip the user takes part in
+func (s *JournalService) GetEntry(ctx context.Context, id, userID string) (*domain.JournalEntry, error) {
+	e, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireParticipant(ctx, s.ParticipantRepo, e.TripID, userID); err != nil {
+		return nil, err
+	}
+	resolveJournalMedia(ctx, s.Blobs, e)
+	return e, nil
+}
+
+// ListEntries returns a trip's entries chronologically, optionally for one day
+func (s *JournalService) ListEntries(ctx context.Context, tripID, userID string, itineraryID *string) ([]domain.JournalEntry, error) {
+	if _, err := s.TripRepo.GetByID(ctx, tripID); err != nil {
+		return nil, errors.New("trip not found")
+	}
+	if err := requireParticipant(ctx, s.ParticipantRepo, tripID, userID); err != nil {
+		return nil, err
+	}
+	entries, err := s.Repo.GetByTripID(ctx, tripID, itineraryID, false)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		resolveJournalMedia(ctx, s.Blobs, &entries[i])
+	}
+	return entries, nil
+}
+
+// UpdateEntry saves an entry loaded with GetEntry, which checked the user.
+// A non-nil mediaIDs replaces the attachments.
+func (s *JournalService) UpdateEntry(ctx context.Context, e *domain.JournalEntry, mediaIDs []string) error {
+	if err := s.validate(ctx, e, mediaIDs); err != nil {
+		return err
+	}
+	if err := s.Repo.Update(ctx, e, mediaIDs); err != nil {
+		return err
+	}
+	return s.reload(ctx, e)
+}
+
+func (s *JournalService) DeleteEntry(ctx context.Context, id, userID string) error {
+	e, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := requireParticipant(ctx, s.ParticipantRepo, e.TripID, userID); err != nil {
+		return err
+	}
+	return s.Repo.Delete(ctx, id)
+}
+
+// reload refreshes the attached media after a write
+func (s *JournalService) reload(ctx context.Context, e *domain.JournalEntry) error {
+	fresh, err := s.Repo.GetByID(ctx, e.ID)
+	if err != nil {
+		return err
+	}
+	resolveJournalMedia(ctx, s.Blobs, fresh)
+	*e = *fresh
+	return nil
+}
+
+func (s *JournalService) validate(ctx context.Context, e *domain.JournalEntry, mediaIDs []string) error {
+	if e.Mood != nil {
+		mood := strings.ToLower(*e.Mood)
+		if !journalMoods[mood] {
+			return errors.New("unknown mood")
+		}
+		e.Mood = &mood
+	}
+	if e.Weather != nil {
+		weather := strings.ToLower(*e.Weather)
+		if !journalWeather[weather] {
+			return errors.New("unknown weather")
+		}
+		e.Weather = &weather
+	}
+
+	if e.ItineraryID != nil {
+		itinerary, err := s.ItineraryRepo.GetByID(ctx, *e.ItineraryID)
+		if err != nil || itinerary.TripID != e.TripID {
+			return errors.New("itinerary does not belong to this trip")
+		}
+	}
+
+	if e.LocationID != nil {
+		loc, err := s.LocationRepo.GetByID(ctx, *e.LocationID)
+		if err != nil {
+			return err
+		}
+		if loc == nil {
+			return errors.New("location not found")
+		}
+	}
+
+	if len(mediaIDs) > 0 {
+		unique := make(map[string]bool, len(mediaIDs))
+		for _, id := range mediaIDs {
+			unique[id] = true
+		}
+		if len(unique) != len(mediaIDs) {
+			return errors.New("media_ids contains duplicates")
+		}
+		count, err := s.MediaRepo.CountInTrip(ctx, e.TripID, mediaIDs)
+		if err != nil {
+			return err
+		}
+		if count != len(mediaIDs) {
+			return errors.New("media does not belong to this trip")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+)
+
+func TestValidateJournalEntry(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name        string
+		entry       domain.JournalEntry
+		mediaIDs    []string
+		wantErr     bool
+		wantMood    *string
+		wantWeather *string
+	}{
+		{name: "plain text", entry: domain.JournalEntry{}},
+		{name: "mood and weather", entry: domain.JournalEntry{Mood: str("happy"), Weather: str("partly_cloudy")}, wantMood: str("happy"), wantWeather: str("partly_cloudy")},
+		{name: "lowercased", entry: domain.JournalEntry{Mood: str("Grateful"), Weather: str("SUNNY")}, wantMood: str("grateful"), wantWeather: str("sunny")},
+		{name: "unknown mood", entry: domain.JournalEntry{Mood: str("hangry")}, wantErr: true},
+		{name: "unknown weather", entry: domain.JournalEntry{Weather: str("hail")}, wantErr: true},
+		{name: "duplicate media", mediaIDs: []string{"a", "b", "a"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := tt.entry
+			err := (&JournalService{}).validate(context.Background(), &e, tt.mediaIDs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !sameString(e.Mood, tt.wantMood) || !sameString(e.Weather, tt.wantWeather) {
+				t.Errorf("got mood %v and weather %v", e.Mood, e.Weather)
+			}
+		})
+	}
+}
+
+func sameString(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
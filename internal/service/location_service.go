@@ -3,78 +3,131 @@ package service
 import (
 	"context"
 	"encoding/json"
-	"errors"
-	"fmt"
-	"net/http"
-	"net/url"
-	"os"
+	"time"
 
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/geo"
+	"github.com/NoahFola/travel_app_backend/internal/places"
 	"github.com/NoahFola/travel_app_backend/internal/repository"
 )
 
-type LocationService struct {
-	Repo *repository.LocationRepository
+// PlacesProvider is a place search backend such as Google Places
+type PlacesProvider interface {
+	Search(ctx context.Context, req places.SearchRequest) ([]places.Place, error)
+	Details(ctx context.Context, placeID string) (*places.Place, error)
+	Autocomplete(ctx context.Context, input string) ([]places.Prediction, error)
+	ReverseGeocode(ctx context.Context, lat, lng float64) ([]places.Place, error)
 }
 
-type GooglePlaceResult struct {
-	PlaceID          string `json:"place_id"`
-	Name             string `json:"name"`
-	FormattedAddress string `json:"formatted_address"`
-	Geometry         struct {
-		Location struct {
-			Lat float64 `json:"lat"`
-			Lng float64 `json:"lng"`
-		} `json:"location"`
-	} `json:"geometry"`
+// DefaultPlaceCacheTTL is how long place search results are reused
+const DefaultPlaceCacheTTL = 24 * time.Hour
+
+const (
+	// DefaultNearbyRadiusM is the nearby lookup radius when none is given
+	DefaultNearbyRadiusM = 1000
+	// MaxSearchRadiusM caps search and nearby radii, as Google does
+	MaxSearchRadiusM = 50000
+	// DefaultNearbyLimit is how many nearby locations are returned by default
+	DefaultNearbyLimit = 20
+	// MaxNearbyLimit caps the nearby page size
+	MaxNearbyLimit = 100
+)
+
+type LocationService struct {
+	Repo     *repository.LocationRepository
+	Places   PlacesProvider
+	Cache    *repository.PlaceCacheRepository // nil disables caching
+	CacheTTL time.Duration
 }
 
-type GooglePlacesResponse struct {
-	Results []GooglePlaceResult `json:"results"`
-	Status  string              `json:"status"`
+// PlaceSearchResult is a search result with the ID of the location it was
+// saved as, ready to use as an activity's location_id
+type PlaceSearchResult struct {
+	places.Place
+	LocationID string `json:"location_id,omitempty"`
 }
 
-// SearchPlaces proxies the request to Google Places API
-func (s *LocationService) SearchPlaces(query string) ([]GooglePlaceResult, error) {
-	apiKey := os.Getenv("GOOGLE_MAPS_API_KEY")
-	if apiKey == "" {
-		return nil, errors.New("GOOGLE_MAPS_API_KEY is not set")
+// SearchPlaces runs a search with the places provider, or answers from the
+// cache. Every result is saved as a location.
+func (s *LocationService) SearchPlaces(ctx context.Context, req places.SearchRequest) ([]PlaceSearchResult, error) {
+	key := req.CacheKey()
+	if s.Cache != nil {
+		// A broken cache only costs us a provider call
+		if raw, err := s.Cache.Get(ctx, key); err == nil && raw != nil {
+			var cached []PlaceSearchResult
+			if err := json.Unmarshal(raw, &cached); err == nil {
+				return cached, nil
+			}
+		}
 	}
 
-	baseURL := "https://maps.googleapis.com/maps/api/place/textsearch/json"
-	u, err := url.Parse(baseURL)
+	found, err := s.Places.Search(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-
-	q := u.Query()
-	q.Set("query", query)
-	q.Set("key", apiKey)
-	u.RawQuery = q.Encode()
-
-	resp, err := http.Get(u.String())
-	if err != nil {
-		return nil, err
+	results := make([]PlaceSearchResult, 0, len(found))
+	for _, p := range found {
+		result := PlaceSearchResult{Place: p}
+		if p.PlaceID != "" && p.Name != "" {
+			loc := locationFromPlace(p)
+			if err := s.Repo.UpsertByPlaceID(ctx, loc); err != nil {
+				return nil, err
+			}
+			result.LocationID = loc.ID
+		}
+		results = append(results, result)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("google places api returned status: %d", resp.StatusCode)
+	if s.Cache != nil {
+		if raw, err := json.Marshal(results); err == nil {
+			s.Cache.Put(ctx, key, req.Query, raw, s.cacheTTL())
+		}
 	}
+	return results, nil
+}
 
-	var placesResp GooglePlacesResponse
-	if err := json.NewDecoder(resp.Body).Decode(&placesResp); err != nil {
-		return nil, err
+func (s *LocationService) cacheTTL() time.Duration {
+	if s.CacheTTL > 0 {
+		return s.CacheTTL
 	}
+	return DefaultPlaceCacheTTL
+}
 
-	if placesResp.Status != "OK" && placesResp.Status != "ZERO_RESULTS" {
-		return nil, fmt.Errorf("google places api error: %s", placesResp.Status)
+// Nearby returns locations we already know within radiusM meters of a
+// point, closest first. Zero radius and limit take the defaults.
+func (s *LocationService) Nearby(ctx context.Context, lat, lng float64, radiusM int, placeType string, limit int) ([]domain.NearbyLocation, error) {
+	if radiusM <= 0 {
+		radiusM = DefaultNearbyRadiusM
+	}
+	if radiusM > MaxSearchRadiusM {
+		radiusM = MaxSearchRadiusM
 	}
+	if limit <= 0 {
+		limit = DefaultNearbyLimit
+	}
+	if limit > MaxNearbyLimit {
+		limit = MaxNearbyLimit
+	}
+	return s.Repo.Nearby(ctx, geo.Point{Lat: lat, Lng: lng}, float64(radiusM)/1000, placeType, limit)
+}
+
+// PlaceDetails looks a place up by its provider ID
+func (s *LocationService) PlaceDetails(ctx context.Context, placeID string) (*places.Place, error) {
+	return s.Places.Details(ctx, placeID)
+}
+
+// Autocomplete suggests places while the user types
+func (s *LocationService) Autocomplete(ctx context.Context, input string) ([]places.Prediction, error) {
+	return s.Places.Autocomplete(ctx, input)
+}
 
-	return placesResp.Results, nil
+// ReverseGeocode returns the places at a coordinate, most specific first
+func (s *LocationService) ReverseGeocode(ctx context.Context, lat, lng float64) ([]places.Place, error) {
+	return s.Places.ReverseGeocode(ctx, lat, lng)
 }
 
 // GetOrCreateLocation checks if a location exists by PlaceID, otherwise creates it from provided data
-func (s *LocationService) GetOrCreateLocation(ctx context.Context, placeData GooglePlaceResult) (*repository.Location, error) {
+func (s *LocationService) GetOrCreateLocation(ctx context.Context, placeData places.Place) (*domain.Location, error) {
 	// 1. Check if exists
 	existing, err := s.Repo.GetByPlaceID(ctx, placeData.PlaceID)
 	if err != nil {
@@ -85,17 +138,22 @@ func (s *LocationService) GetOrCreateLocation(ctx context.Context, placeData Goo
 	}
 
 	// 2. Create new
-	newLoc := &repository.Location{
-		Name:          placeData.Name,
-		Address:       placeData.FormattedAddress,
-		Latitude:      placeData.Geometry.Location.Lat,
-		Longitude:     placeData.Geometry.Location.Lng,
-		GooglePlaceID: &placeData.PlaceID,
-	}
-
+	newLoc := locationFromPlace(placeData)
 	if err := s.Repo.Create(ctx, newLoc); err != nil {
 		return nil, err
 	}
 
 	return newLoc, nil
 }
+
+func locationFromPlace(p places.Place) *domain.Location {
+	placeID := p.PlaceID
+	return &domain.Location{
+		Name:          p.Name,
+		Address:       p.FormattedAddress,
+		Latitude:      p.Geometry.Location.Lat,
+		Longitude:     p.Geometry.Location.Lng,
+		GooglePlaceID: &placeID,
+		Types:         p.Types,
+	}
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/geo"
+	"github.com/NoahFola/travel_app_backend/internal/repository"
+)
+
+const (
+	// placementSlack widens activity windows, photos are often taken on the
+	// way there or just after
+	placementSlack = 30 * time.Minute
+
+	// defaultActivityLength is assumed for activities without an end time
+	defaultActivityLength = time.Hour
+
+	// maxPlacementDistanceKm keeps photos away from activities whose place is
+	// farther than this from where they were taken
+	maxPlacementDistanceKm = 2.0
+
+	// minutesPerKm weighs distance against time when several activities
+	// match: 100 m counts as much as 1 minute outside the activity's window
+	minutesPerKm = 10.0
+
+	// placementDateLayout compares capture dates with itinerary dates
+	placementDateLayout = "2006-01-02"
+)
+
+// How a photo was placed
+const (
+	PlacedByTime       = "time"
+	PlacedByTimeAndGeo = "time_and_location"
+	PlacedByDay        = "day"
+)
+
+// MediaAssignment is where AutoAssign placed a photo
+type MediaAssignment struct {
+	MediaID     string  `json:"media_id"`
+	ActivityID  *string `json:"activity_id"`
+	ItineraryID *string `json:"itinerary_id"`
+	MatchedBy   string  `json:"matched_by"` // time, time_and_location or day
+}
+
+// AutoAssignResult lists the placed photos and those no activity or day
+// matched, which stay unassigned
+type AutoAssignResult struct {
+	Assigned  []MediaAssignment `json:"assigned"`
+	Unmatched []string          `json:"unmatched"`
+}
+
+// AutoAssign places the trip's unassigned media on the activity whose time
+// window, and place when both have coordinates, best match when they were
+// taken. Media no activity matches go on the itinerary day they were taken,
+// and stay unassigned so a later run can still find them an activity.
+// Media without a capture time are left alone.
+func (s *MediaService) AutoAssign(ctx context.Context, tripID, userID string) (*AutoAssignResult, error) {
+	if err := s.checkParticipant(ctx, tripID, userID); err != nil {
+		return nil, err
+	}
+	medias, err := s.Repo.ListByTripID(ctx, tripID, true)
+	if err != nil {
+		return nil, err
+	}
+	activities, err := s.ActivityRepo.GetByTripID(ctx, tripID, false)
+	if err != nil {
+		return nil, err
+	}
+	itineraries, err := s.ItineraryRepo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AutoAssignResult{Assigned: []MediaAssignment{}, Unmatched: []string{}}
+	for _, m := range medias {
+		assignment := MediaAssignment{MediaID: m.ID}
+		if a, matchedBy := matchActivity(m, activities); a != nil {
+			assignment.ActivityID, assignment.ItineraryID, assignment.MatchedBy = &a.ID, a.ItineraryID, matchedBy
+		} else if day := matchDay(m, itineraries); day != nil {
+			assignment.ItineraryID, assignment.MatchedBy = &day.ID, PlacedByDay
+		} else {
+			result.Unmatched = append(result.Unmatched, m.ID)
+			continue
+		}
+
+		if err := s.Repo.Assign(ctx, m.ID, assignment.ActivityID, assignment.ItineraryID); err != nil {
+			return nil, err
+		}
+		result.Assigned = append(result.Assigned, assignment)
+	}
+	return result, nil
+}
+
+// takenIn returns when a photo was taken: the exact time when known,
+// otherwise its wall clock read in zone
+func takenIn(m repository.Media, zone *time.Location) (time.Time, bool) {
+	if m.TakenAt != nil {
+		return *m.TakenAt, true
+	}
+	if m.TakenLocal == nil {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(repository.TakenLocalLayout, *m.TakenLocal, zone)
+	return t, err == nil
+}
+
+// matchActivity finds the scheduled activity closest to when, and where,
+// the photo was taken. Activities are compared in their place's timezone.
+func matchActivity(m repository.Media, activities []domain.Activity) (*domain.Activity, string) {
+	var best *domain.Activity
+	var bestScore float64
+	var matchedBy string
+	for i := range activities {
+		a := &activities[i]
+		if a.StartTime == nil {
+			continue
+		}
+		taken, ok := takenIn(m, geo.LoadZone(a.Timezone))
+		if !ok {
+			return nil, ""
+		}
+
+		start := *a.StartTime
+		end := start.Add(defaultActivityLength)
+		if a.EndTime != nil && a.EndTime.After(start) {
+			end = *a.EndTime
+		}
+		if taken.Before(start.Add(-placementSlack)) || taken.After(end.Add(placementSlack)) {
+			continue
+		}
+
+		// Minutes outside the activity's own window
+		score := 0.0
+		if taken.Before(start) {
+			score = start.Sub(taken).Minutes()
+		} else if taken.After(end) {
+			score = taken.Sub(end).Minutes()
+		}
+		by := PlacedByTime
+		if m.Latitude != nil && m.Longitude != nil && a.Place != nil {
+			km := geo.Haversine(geo.Point{Lat: *m.Latitude, Lng: *m.Longitude}, geo.Point{Lat: a.Place.Latitude, Lng: a.Place.Longitude})
+			if km > maxPlacementDistanceKm {
+				continue
+			}
+			score += km * minutesPerKm
+			by = PlacedByTimeAndGeo
+		}
+
+		if best == nil || score < bestScore {
+			best, bestScore, matchedBy = a, score, by
+		}
+	}
+	return best, matchedBy
+}
+
+// matchDay finds the itinerary day of the date the photo was taken, on the
+// camera's clock
+func matchDay(m repository.Media, itineraries []domain.Itinerary) *domain.Itinerary {
+	var date string
+	switch {
+	case m.TakenLocal != nil && len(*m.TakenLocal) >= len(placementDateLayout):
+		date = (*m.TakenLocal)[:len(placementDateLayout)]
+	case m.TakenAt != nil:
+		date = m.TakenAt.UTC().Format(placementDateLayout)
+	default:
+		return nil
+	}
+	for i := range itineraries {
+		if itineraries[i].Date.Format(placementDateLayout) == date {
+			return &itineraries[i]
+		}
+	}
+	return nil
+}
@@ -2,72 +2,361 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"mime/multipart"
-	"os"
-	"path/filepath"
+	"net/http"
+	"strings"
 	"time"
+	"unicode"
 
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/geo"
+	"github.com/NoahFola/travel_app_backend/internal/imaging"
 	"github.com/NoahFola/travel_app_backend/internal/repository"
+	"github.com/NoahFola/travel_app_backend/internal/storage"
 )
 
-type MediaService struct {
-	Repo *repository.MediaRepository
+// ErrInvalidMedia is returned for uploads that can't be read
+var ErrInvalidMedia = errors.New("invalid upload")
+
+// ErrUnsupportedMedia is returned for files of a type that can't be uploaded
+var ErrUnsupportedMedia = errors.New("unsupported file type")
+
+// ErrMediaTooLarge is returned for files over their type's size limit
+var ErrMediaTooLarge = errors.New("file is too large")
+
+// Upload size limits per media type
+const (
+	MaxImageSize    = 25 << 20
+	MaxVideoSize    = 500 << 20
+	MaxDocumentSize = 20 << 20
+
+	// MaxMediaSize is the largest file any type allows
+	MaxMediaSize = MaxVideoSize
+)
+
+// mediaKind describes an accepted MIME type
+type mediaKind struct {
+	Type    string // media.type
+	Ext     string // extension of the stored object
+	MaxSize int64
 }
 
-func (s *MediaService) UploadMedia(ctx context.Context, file *multipart.FileHeader, activityID string) (*repository.Media, error) {
-	// 1. Ensure upload dir exists
-	uploadDir := "./uploads"
-	if _, err := os.Stat(uploadDir); os.IsNotExist(err) {
-		if err := os.Mkdir(uploadDir, 0755); err != nil {
-			return nil, err
-		}
-	}
+// allowedMedia lists what can be uploaded, by sniffed MIME type
+var allowedMedia = map[string]mediaKind{
+	"image/jpeg":      {"image", ".jpg", MaxImageSize},
+	"image/png":       {"image", ".png", MaxImageSize},
+	"image/gif":       {"image", ".gif", MaxImageSize},
+	"image/webp":      {"image", ".webp", MaxImageSize},
+	"image/heic":      {"image", ".heic", MaxImageSize},
+	"image/heif":      {"image", ".heif", MaxImageSize},
+	"video/mp4":       {"video", ".mp4", MaxVideoSize},
+	"video/quicktime": {"video", ".mov", MaxVideoSize},
+	"video/webm":      {"video", ".webm", MaxVideoSize},
+	"application/pdf": {"document", ".pdf", MaxDocumentSize}, // tickets, receipts, scans
+}
 
-	// 2. Save file
-	filename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), file.Filename)
-	dst := filepath.Join(uploadDir, filename)
+const allowedMediaDescription = "JPEG, PNG, GIF, WebP and HEIC images, MP4, MOV and WebM videos, PDF documents"
 
-	if err := s.saveFile(file, dst); err != nil {
-		return nil, err
-	}
+// maxExifScan is how far into a JPEG the EXIF block is looked for
+const maxExifScan = 1 << 20
+
+type MediaService struct {
+	Repo            *repository.MediaRepository
+	ParticipantRepo *repository.ParticipantRepository
+	ActivityRepo    *repository.ActivityRepository
+	ItineraryRepo   *repository.ItineraryRepository
+	Blobs           storage.BlobStore
+	URLs            *storage.URLSigner  // checks links to the file endpoint
+	Variants        *MediaVariantWorker // told about new images
+}
 
-	// 3. Create DB record
-	// Determine type based on extension or header? Simple check for now.
-	mediaType := "image" // Default
-	// Could add logic here to check extension
+// UploadFailure tells which file of a bulk upload was rejected and why
+type UploadFailure struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
 
-	media := &repository.Media{
-		URL:        "/uploads/" + filename,
-		Type:       mediaType,
-		ActivityID: activityID,
+// checkParticipant makes sure the user takes part in the trip
+func (s *MediaService) checkParticipant(ctx context.Context, tripID, userID string) error {
+	return requireParticipant(ctx, s.ParticipantRepo, tripID, userID)
+}
+
+// requireParticipant returns ErrNotParticipant unless the user takes part in
+// the trip. Services call it before handing out links to the trip's files.
+func requireParticipant(ctx context.Context, repo *repository.ParticipantRepository, tripID, userID string) error {
+	p, err := repo.GetByTripAndUser(ctx, tripID, userID)
+	if err != nil {
+		return err
 	}
+	if p == nil {
+		return ErrNotParticipant
+	}
+	return nil
+}
 
-	if err := s.Repo.Create(ctx, media); err != nil {
+// UploadMedia stores a file on an activity, or on the trip without placing
+// it when activityID is empty. The user must take part in the trip.
+func (s *MediaService) UploadMedia(ctx context.Context, file *multipart.FileHeader, activityID, tripID, userID string) (*repository.Media, error) {
+	media := &repository.Media{TripID: tripID}
+	if activityID != "" {
+		activity, err := s.ActivityRepo.GetByID(ctx, activityID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: activity not found", ErrInvalidMedia)
+		}
+		if tripID != "" && tripID != activity.TripID {
+			return nil, fmt.Errorf("%w: the activity belongs to another trip", ErrInvalidMedia)
+		}
+		media.TripID, media.ActivityID, media.ItineraryID = activity.TripID, &activity.ID, activity.ItineraryID
+	}
+	if media.TripID == "" {
+		return nil, fmt.Errorf("%w: activity_id or trip_id is required", ErrInvalidMedia)
+	}
+	if err := s.checkParticipant(ctx, media.TripID, userID); err != nil {
 		return nil, err
 	}
 
+	if err := s.store(ctx, file, media); err != nil {
+		return nil, err
+	}
 	return media, nil
 }
 
-func (s *MediaService) saveFile(file *multipart.FileHeader, dst string) error {
+// BulkUpload stores files on a trip without placing them, e.g. a camera
+// roll to be sorted with AutoAssign. Each file is checked on its own: the
+// rejected ones are reported and the others are still stored.
+func (s *MediaService) BulkUpload(ctx context.Context, files []*multipart.FileHeader, tripID, userID string) ([]repository.Media, []UploadFailure, error) {
+	if err := s.checkParticipant(ctx, tripID, userID); err != nil {
+		return nil, nil, err
+	}
+
+	uploaded := []repository.Media{}
+	failed := []UploadFailure{}
+	for _, file := range files {
+		media := &repository.Media{TripID: tripID}
+		if err := s.store(ctx, file, media); err != nil {
+			failed = append(failed, UploadFailure{File: originalName(file.Filename), Error: err.Error()})
+			continue
+		}
+		uploaded = append(uploaded, *media)
+	}
+	return uploaded, failed, nil
+}
+
+// store checks the file's type from its content and its size, then stores
+// it under a generated name and saves media. The client's file name is only
+// kept as original_name. JPEG photos get their capture time and position
+// from their EXIF data.
+func (s *MediaService) store(ctx context.Context, file *multipart.FileHeader, media *repository.Media) error {
+	if file.Size == 0 {
+		return fmt.Errorf("%w: file is empty", ErrInvalidMedia)
+	}
+
 	src, err := file.Open()
 	if err != nil {
 		return err
 	}
 	defer src.Close()
 
-	out, err := os.Create(dst)
-	if err != nil {
+	// 1. Check what the file really is
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return fmt.Errorf("%w: %v", ErrInvalidMedia, err)
+	}
+	mimeType := sniffMIME(head[:n])
+	kind, ok := allowedMedia[mimeType]
+	if !ok {
+		return fmt.Errorf("%w: %s (allowed: %s)", ErrUnsupportedMedia, mimeType, allowedMediaDescription)
+	}
+	if file.Size > kind.MaxSize {
+		return fmt.Errorf("%w: %ss can be at most %d MB", ErrMediaTooLarge, kind.Type, kind.MaxSize>>20)
+	}
+
+	// 2. Read when and where a photo was taken
+	if mimeType == "image/jpeg" {
+		if _, err := src.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if exif, err := imaging.ReadExif(io.LimitReader(src, maxExifScan)); err == nil {
+			applyExif(media, exif)
+		}
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
 		return err
 	}
-	defer out.Close()
 
-	_, err = io.Copy(out, src)
-	return err
+	// 3. Store it, hashing on the way
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return err
+	}
+	key := "media/" + hex.EncodeToString(name) + kind.Ext
+	hash := sha256.New()
+	if err := s.Blobs.Put(ctx, key, io.TeeReader(src, hash), file.Size, mimeType); err != nil {
+		return err
+	}
+
+	// 4. Create DB record
+	media.Key = key
+	media.Type = kind.Type
+	media.OriginalName = originalName(file.Filename)
+	media.Size = file.Size
+	media.MimeType = mimeType
+	media.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	if imaging.Decodable(mimeType) {
+		media.VariantsStatus = repository.VariantsPending
+	}
+
+	if err := s.Repo.Create(ctx, media); err != nil {
+		s.Blobs.Delete(ctx, key)
+		return err
+	}
+	if media.VariantsStatus != "" && s.Variants != nil {
+		s.Variants.Notify()
+	}
+
+	resolveMedia(ctx, s.Blobs, media)
+	return nil
+}
+
+// applyExif records when and where a photo was taken. Without a UTC offset
+// in the EXIF data, the exact time comes from the timezone at the GPS
+// position; with neither only the camera's wall clock is known.
+func applyExif(m *repository.Media, e *imaging.Exif) {
+	if e.HasGPS {
+		lat, lng := e.Latitude, e.Longitude
+		m.Latitude, m.Longitude = &lat, &lng
+	}
+	local, ok := e.TakenLocal()
+	if !ok {
+		return
+	}
+	wallClock := local.Format(repository.TakenLocalLayout)
+	m.TakenLocal = &wallClock
+
+	if at, ok := e.TakenAt(); ok {
+		at = at.UTC()
+		m.TakenAt = &at
+	} else if e.HasGPS {
+		zone := geo.LoadZone(geo.Timezone(geo.Point{Lat: e.Latitude, Lng: e.Longitude}))
+		at := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), 0, zone).UTC()
+		m.TakenAt = &at
+	}
+}
+
+// sniffMIME detects a file's MIME type from its first bytes.
+// http.DetectContentType doesn't know most of the ISO media brands phones use
+// for HEIC photos and MP4/MOV videos, so those are checked first.
+func sniffMIME(head []byte) string {
+	if len(head) >= 12 && string(head[4:8]) == "ftyp" {
+		switch string(head[8:12]) {
+		case "heic", "heix", "heim", "heis":
+			return "image/heic"
+		case "mif1", "msf1":
+			return "image/heif"
+		case "qt  ":
+			return "video/quicktime"
+		case "isom", "iso2", "mp41", "mp42", "avc1", "M4V ":
+			return "video/mp4"
+		}
+	}
+	mimeType, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	return mimeType
+}
+
+// originalName keeps the base name of the client's file, for display only
+func originalName(filename string) string {
+	filename = strings.ReplaceAll(filename, "\\", "/")
+	if i := strings.LastIndex(filename, "/"); i >= 0 {
+		filename = filename[i+1:]
+	}
+	filename = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, filename)
+	if len(filename) > 255 {
+		filename = strings.ToValidUTF8(filename[:255], "")
+	}
+	return strings.TrimSpace(filename)
+}
+
+// ListTripMedia lists a trip's media for a participant, with unassigned
+// only those not placed on an activity yet
+func (s *MediaService) ListTripMedia(ctx context.Context, tripID, userID string, unassigned bool) ([]repository.Media, error) {
+	if err := s.checkParticipant(ctx, tripID, userID); err != nil {
+		return nil, err
+	}
+	medias, err := s.Repo.ListByTripID(ctx, tripID, unassigned)
+	if err != nil {
+		return nil, err
+	}
+	for i := range medias {
+		resolveMedia(ctx, s.Blobs, &medias[i])
+	}
+	return medias, nil
 }
 
-func (s *MediaService) ListByActivityID(ctx context.Context, activityID string) ([]repository.Media, error) {
-	return s.Repo.ListByActivityID(ctx, activityID)
+// OpenMedia returns a media file for download by a participant of its trip
+func (s *MediaService) OpenMedia(ctx context.Context, id, userID string) (*repository.Media, io.ReadSeekCloser, error) {
+	media, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.checkParticipant(ctx, media.TripID, userID); err != nil {
+		return nil, nil, err
+	}
+	file, err := s.Blobs.Open(ctx, media.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return media, file, nil
+}
+
+// OpenSigned returns a stored file for a signed link handed out in a media URL
+func (s *MediaService) OpenSigned(ctx context.Context, key, expires, signature string) (io.ReadSeekCloser, error) {
+	if err := s.URLs.Verify(key, expires, signature, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.Blobs.Open(ctx, key)
+}
+
+// mediaURL returns the download URL of a stored object, or "" if the store
+// can't produce one
+func mediaURL(ctx context.Context, blobs storage.BlobStore, key string) string {
+	if blobs == nil {
+		return ""
+	}
+	url, err := blobs.URL(ctx, key)
+	if err != nil {
+		log.Printf("media: no URL for %q: %v", key, err)
+		return ""
+	}
+	return url
+}
+
+// resolveMedia sets the download URLs of a media file and its variants.
+// Variants still being made are left out.
+func resolveMedia(ctx context.Context, blobs storage.BlobStore, m *repository.Media) {
+	m.URL = mediaURL(ctx, blobs, m.Key)
+	m.Variants = map[string]string{}
+	for size, key := range m.VariantKeys {
+		if url := mediaURL(ctx, blobs, key); url != "" {
+			m.Variants[size] = url
+		}
+	}
+}
+
+func resolveJournalMedia(ctx context.Context, blobs storage.BlobStore, e *domain.JournalEntry) {
+	for i := range e.Media {
+		e.Media[i].URL = mediaURL(ctx, blobs, e.Media[i].Key)
+	}
 }
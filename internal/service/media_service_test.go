@@ -0,0 +1,115 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/imaging"
+	"github.com/NoahFola/travel_app_backend/internal/repository"
+)
+
+// ftyp builds the start of an ISO media file with the given major brand
+func ftyp(brand string) []byte {
+	return append([]byte{0, 0, 0, 0x18, 'f', 't', 'y', 'p'}, brand+"\x00\x00\x00\x00mif1"...)
+}
+
+func TestSniffMIME(t *testing.T) {
+	tests := []struct {
+		name    string
+		head    []byte
+		want    string
+		allowed bool
+	}{
+		{"JPEG", []byte("\xff\xd8\xff\xe1\x00\x10Exif\x00\x00"), "image/jpeg", true},
+		{"PNG", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), "image/png", true},
+		{"GIF", []byte("GIF89a\x01\x00\x01\x00"), "image/gif", true},
+		{"WebP", []byte("RIFF\x24\x00\x00\x00WEBPVP8 "), "image/webp", true},
+		{"HEIC", ftyp("heic"), "image/heic", true},
+		{"HEIF", ftyp("mif1"), "image/heif", true},
+		{"MOV", ftyp("qt  "), "video/quicktime", true},
+		{"MP4", ftyp("isom"), "video/mp4", true},
+		{"iPhone MP4", ftyp("mp42"), "video/mp4", true},
+		{"WebM", []byte("\x1a\x45\xdf\xa3\x01\x00\x00\x00\x00\x00\x00\x1fB\x86\x81\x01B\x82\x84webm"), "video/webm", true},
+		{"PDF", []byte("%PDF-1.7\n"), "application/pdf", true},
+		{"HTML named photo.jpg", []byte("<html><script>alert(1)</script>"), "text/html", false},
+		{"unknown ISO brand", ftyp("crx "), "application/octet-stream", false},
+		{"truncated ftyp box", []byte("\x00\x00\x00\x18ftyp"), "application/octet-stream", false},
+	}
+	for _, tt := range tests {
+		got := sniffMIME(tt.head)
+		if got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+		if _, ok := allowedMedia[got]; ok != tt.allowed {
+			t.Errorf("%s: %s allowed is %v, want %v", tt.name, got, ok, tt.allowed)
+		}
+	}
+}
+
+func TestOriginalName(t *testing.T) {
+	for in, want := range map[string]string{
+		"photo.jpg":                   "photo.jpg",
+		`C:\Users\ann\Pictures\a.jpg`: "a.jpg",
+		"../../etc/passwd":            "passwd",
+		" bad\x00name\n.pdf ":         "badname.pdf",
+		strings.Repeat("é", 200):      strings.Repeat("é", 127),
+	} {
+		if got := originalName(in); got != want {
+			t.Errorf("originalName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestApplyExif(t *testing.T) {
+	tests := []struct {
+		name         string
+		exif         imaging.Exif
+		wantLocal    string
+		wantAt       string
+		wantPosition bool
+	}{
+		{
+			name:      "with an offset",
+			exif:      imaging.Exif{DateTime: "2024:05:01 14:03:22", Offset: "+09:00"},
+			wantLocal: "2024-05-01T14:03:22",
+			wantAt:    "2024-05-01T05:03:22Z",
+		},
+		{
+			name:         "offset from the GPS position",
+			exif:         imaging.Exif{DateTime: "2024:05:01 14:03:22", Latitude: 48.8584, Longitude: 2.2945, HasGPS: true},
+			wantLocal:    "2024-05-01T14:03:22",
+			wantAt:       "2024-05-01T12:03:22Z", // Paris is on summer time
+			wantPosition: true,
+		},
+		{
+			name:      "wall clock only",
+			exif:      imaging.Exif{DateTime: "2024:05:01 14:03:22"},
+			wantLocal: "2024-05-01T14:03:22",
+		},
+		{
+			name:         "position only",
+			exif:         imaging.Exif{Latitude: -33.8568, Longitude: 151.2153, HasGPS: true},
+			wantPosition: true,
+		},
+	}
+	for _, tt := range tests {
+		var m repository.Media
+		applyExif(&m, &tt.exif)
+
+		local, at := "", ""
+		if m.TakenLocal != nil {
+			local = *m.TakenLocal
+		}
+		if m.TakenAt != nil {
+			at = m.TakenAt.Format(time.RFC3339)
+		}
+		if local != tt.wantLocal || at != tt.wantAt {
+			t.Errorf("%s: got taken_local %q, taken_at %q", tt.name, local, at)
+		}
+		if hasPosition := m.Latitude != nil && m.Longitude != nil; hasPosition != tt.wantPosition ||
+			hasPosition && (*m.Latitude != tt.exif.Latitude || *m.Longitude != tt.exif.Longitude) {
+			t.Errorf("%s: got position %v, %v", tt.name, m.Latitude, m.Longitude)
+		}
+	}
+}
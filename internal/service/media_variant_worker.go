@@ -0,0 +1,132 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/imaging"
+	"github.com/NoahFola/travel_app_backend/internal/repository"
+	"github.com/NoahFola/travel_app_backend/internal/storage"
+)
+
+// VariantSizes are the longest sides, in pixels, of the variants made for
+// each image: a thumbnail for lists and cards and a screen-sized copy
+var VariantSizes = []int{256, 1024}
+
+// DefaultVariantInterval is how often the worker looks for images it
+// wasn't told about, e.g. after a restart
+const DefaultVariantInterval = time.Minute
+
+// variantBatch is how many images the worker claims at a time
+const variantBatch = 10
+
+// MediaVariantWorker makes downsized JPEG copies of uploaded images in the
+// background, so uploads don't wait for them. Variants are stored next to
+// the original, turned upright if the EXIF orientation says so; images
+// smaller than a size get no variant of that size.
+type MediaVariantWorker struct {
+	Repo     *repository.MediaRepository
+	Blobs    storage.BlobStore
+	Interval time.Duration
+	wake     chan struct{}
+}
+
+func NewMediaVariantWorker(repo *repository.MediaRepository, blobs storage.BlobStore) *MediaVariantWorker {
+	return &MediaVariantWorker{Repo: repo, Blobs: blobs, Interval: DefaultVariantInterval, wake: make(chan struct{}, 1)}
+}
+
+// Notify wakes the worker up after an upload
+func (w *MediaVariantWorker) Notify() {
+	select {
+	case w.wake <- struct{}{}:
+	default: // already woken up
+	}
+}
+
+// Run processes pending images until ctx is done
+func (w *MediaVariantWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.wake:
+		}
+		for w.processBatch(ctx) {
+		}
+	}
+}
+
+// processBatch handles one batch and reports whether there may be more
+func (w *MediaVariantWorker) processBatch(ctx context.Context) bool {
+	medias, err := w.Repo.ClaimPendingVariants(ctx, variantBatch)
+	if err != nil {
+		log.Printf("media variants: claiming images: %v", err)
+		return false
+	}
+	for _, m := range medias {
+		status := repository.VariantsDone
+		keys, err := w.generate(ctx, m)
+		if err != nil {
+			log.Printf("media variants: %s: %v", m.ID, err)
+			status = repository.VariantsFailed
+		}
+		if err := w.Repo.SaveVariants(ctx, m.ID, keys, status); err != nil {
+			log.Printf("media variants: saving %s: %v", m.ID, err)
+		}
+	}
+	return len(medias) == variantBatch
+}
+
+// generate stores the variants of an image and returns their keys
+func (w *MediaVariantWorker) generate(ctx context.Context, m repository.Media) (map[string]string, error) {
+	file, err := w.Blobs.Open(ctx, m.Key)
+	if err != nil {
+		return nil, err
+	}
+	orientation := 0
+	if exif, err := imaging.ReadExif(file); err == nil {
+		orientation = exif.Orientation
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	img, err := imaging.Decode(file)
+	file.Close()
+	if err != nil {
+		return nil, fmt.Errorf("decoding: %w", err)
+	}
+
+	keys := map[string]string{}
+	for _, size := range VariantSizes {
+		resized, ok := imaging.Fit(img, size)
+		if !ok {
+			continue
+		}
+		data, err := imaging.EncodeJPEG(imaging.Orient(resized, orientation))
+		if err != nil {
+			return keys, err
+		}
+		key := variantKey(m.Key, size)
+		if err := w.Blobs.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "image/jpeg"); err != nil {
+			return keys, err
+		}
+		keys[strconv.Itoa(size)] = key
+	}
+	return keys, nil
+}
+
+// variantKey names a variant after its original: media/abc.png -> media/abc_256.jpg
+func variantKey(key string, size int) string {
+	return strings.TrimSuffix(key, path.Ext(key)) + "_" + strconv.Itoa(size) + ".jpg"
+}
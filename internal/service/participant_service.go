@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/repository"
+)
+
+// ErrInvalidParticipant is returned for participants without a name
+var ErrInvalidParticipant = errors.New("display_name is required")
+
+// ErrParticipantNotFound is returned for participants of other trips too
+var ErrParticipantNotFound = errors.New("participant not found")
+
+// ErrParticipantHasExpenses is returned when removing a participant would
+// change the other participants' balances
+var ErrParticipantHasExpenses = errors.New("participant has expenses; delete or settle them first")
+
+// ErrLinkOtherAccount is returned when a participant would be linked to
+// someone else's account, which needs their consent through an invite
+var ErrLinkOtherAccount = errors.New("you can only link your own account to a participant")
+
+// ErrAlreadyParticipant is returned when an account already takes part in the trip
+var ErrAlreadyParticipant = errors.New("this account is already a participant of the trip")
+
+type ParticipantService struct {
+	Repo     *repository.ParticipantRepository
+	TripRepo *repository.TripRepository
+}
+
+// AddParticipant adds a companion to a trip on behalf of userID, who must
+// already take part in it. Companions are added by name; an account can
+// only be linked by its own user.
+func (s *ParticipantService) AddParticipant(ctx context.Context, p *domain.Participant, userID string) error {
+	if _, err := s.TripRepo.GetByID(ctx, p.TripID); err != nil {
+		return ErrTripNotFound
+	}
+	if err := requireParticipant(ctx, s.Repo, p.TripID, userID); err != nil {
+		return err
+	}
+	if p.UserID != nil {
+		// The caller takes part already, so only others' accounts are left
+		if *p.UserID != userID {
+			return ErrLinkOtherAccount
+		}
+		return ErrAlreadyParticipant
+	}
+	p.DisplayName = strings.TrimSpace(p.DisplayName)
+	if p.DisplayName == "" {
+		return ErrInvalidParticipant
+	}
+	return s.Repo.Create(ctx, p)
+}
+
+func (s *ParticipantService) ListParticipants(ctx context.Context, tripID, userID string) ([]domain.Participant, error) {
+	if _, err := s.TripRepo.GetByID(ctx, tripID); err != nil {
+		return nil, ErrTripNotFound
+	}
+	if err := requireParticipant(ctx, s.Repo, tripID, userID); err != nil {
+		return nil, err
+	}
+	return s.Repo.GetByTripID(ctx, tripID)
+}
+
+// RemoveParticipant removes someone who has no expenses. Their expenses and
+// shares would otherwise vanish with them and change everyone's balance.
+func (s *ParticipantService) RemoveParticipant(ctx context.Context, tripID, id, userID string) error {
+	if err := requireParticipant(ctx, s.Repo, tripID, userID); err != nil {
+		return err
+	}
+	p, err := s.Repo.GetByID(ctx, id)
+	if err != nil || p.TripID != tripID {
+		return ErrParticipantNotFound
+	}
+	hasExpenses, err := s.Repo.HasExpenses(ctx, id)
+	if err != nil {
+		return err
+	}
+	if hasExpenses {
+		return ErrParticipantHasExpenses
+	}
+	return s.Repo.Delete(ctx, id)
+}
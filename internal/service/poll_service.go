@@ -0,0 +1,534 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/repository"
+)
+
+// ErrInvalidPoll is returned for invalid polls, options and ballots
+var ErrInvalidPoll = errors.New("invalid poll")
+
+// ErrPollClosed is returned when proposing or voting after the poll closed
+var ErrPollClosed = errors.New("poll is closed")
+
+// ErrNotParticipant is returned when the user doesn't take part in the trip
+var ErrNotParticipant = errors.New("you are not a participant of this trip")
+
+// ErrNotTripOwner is returned for actions reserved to the trip owner
+var ErrNotTripOwner = errors.New("only the trip owner can do this")
+
+func invalidPoll(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidPoll, fmt.Sprintf(format, args...))
+}
+
+// PollService runs group votes on proposed activities. Options are
+// activities, usually ideas in the trip's backlog; every participant with
+// an account has one ballot per poll, which they can change until the poll
+// closes.
+type PollService struct {
+	Repo            *repository.PollRepository
+	TripRepo        *repository.TripRepository
+	ParticipantRepo *repository.ParticipantRepository
+	ActivityRepo    *repository.ActivityRepository
+	Activities      *ActivityService
+}
+
+// participant returns the user's participant record in the trip
+func (s *PollService) participant(ctx context.Context, tripID, userID string) (*domain.Participant, error) {
+	p, err := s.ParticipantRepo.GetByTripAndUser(ctx, tripID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, ErrNotParticipant
+	}
+	return p, nil
+}
+
+// CreatePoll opens a poll, optionally seeded with existing activities of the trip
+func (s *PollService) CreatePoll(ctx context.Context, poll *domain.Poll, userID string, activityIDs []string) error {
+	if _, err := s.TripRepo.GetByID(ctx, poll.TripID); err != nil {
+		return errors.New("trip not found")
+	}
+	p, err := s.participant(ctx, poll.TripID, userID)
+	if err != nil {
+		return err
+	}
+
+	poll.Title = strings.TrimSpace(poll.Title)
+	if poll.Title == "" {
+		return invalidPoll("title is required")
+	}
+	if poll.Mode == "" {
+		poll.Mode = domain.PollUpDown
+	}
+	if poll.Mode != domain.PollUpDown && poll.Mode != domain.PollRank {
+		return invalidPoll("mode must be %s or %s", domain.PollUpDown, domain.PollRank)
+	}
+	if poll.ClosesAt != nil && !poll.ClosesAt.After(time.Now()) {
+		return invalidPoll("closes_at must be in the future")
+	}
+	for _, id := range activityIDs {
+		if err := s.checkActivity(ctx, poll.TripID, id); err != nil {
+			return err
+		}
+	}
+
+	poll.CreatedBy = &p.ID
+	if err := s.Repo.Create(ctx, poll); err != nil {
+		return err
+	}
+	poll.Options = []domain.PollOption{}
+	for _, id := range activityIDs {
+		option := domain.PollOption{PollID: poll.ID, ActivityID: id, ProposedBy: &p.ID}
+		if err := s.Repo.AddOption(ctx, &option); err != nil {
+			return err
+		}
+		poll.Options = append(poll.Options, option)
+	}
+	poll.Status = pollStatus(poll)
+	return nil
+}
+
+// ListPolls returns the trip's polls with their options
+func (s *PollService) ListPolls(ctx context.Context, tripID, userID string) ([]domain.Poll, error) {
+	if _, err := s.participant(ctx, tripID, userID); err != nil {
+		return nil, err
+	}
+	polls, err := s.Repo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range polls {
+		polls[i].Status = pollStatus(&polls[i])
+		if polls[i].Options, err = s.Repo.GetOptions(ctx, polls[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return polls, nil
+}
+
+// GetPoll returns the poll with its options, aggregated results and the
+// user's own ballot
+func (s *PollService) GetPoll(ctx context.Context, id, userID string) (*domain.Poll, error) {
+	poll, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.participant(ctx, poll.TripID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.load(ctx, poll); err != nil {
+		return nil, err
+	}
+
+	votes, err := s.Repo.GetVotes(ctx, poll.ID)
+	if err != nil {
+		return nil, err
+	}
+	poll.Results = tallyPoll(poll, votes)
+	for _, v := range votes {
+		if v.ParticipantID == p.ID {
+			poll.MyBallot = append(poll.MyBallot, v)
+		}
+	}
+	return poll, nil
+}
+
+// load fills in the status and the options with their activities
+func (s *PollService) load(ctx context.Context, poll *domain.Poll) error {
+	poll.Status = pollStatus(poll)
+	options, err := s.Repo.GetOptions(ctx, poll.ID)
+	if err != nil {
+		return err
+	}
+	for i := range options {
+		if options[i].Activity, err = s.ActivityRepo.GetByID(ctx, options[i].ActivityID); err != nil {
+			return err
+		}
+	}
+	poll.Options = options
+	return nil
+}
+
+// UpdatePoll changes the title, description or scheduled closing. Only the
+// poll's creator and the trip owner may edit it.
+func (s *PollService) UpdatePoll(ctx context.Context, poll *domain.Poll, userID string) error {
+	if err := s.checkManager(ctx, poll, userID); err != nil {
+		return err
+	}
+	poll.Title = strings.TrimSpace(poll.Title)
+	if poll.Title == "" {
+		return invalidPoll("title is required")
+	}
+	stored, err := s.Repo.GetByID(ctx, poll.ID)
+	if err != nil {
+		return err
+	}
+	if poll.ClosesAt != nil && !sameTime(poll.ClosesAt, stored.ClosesAt) && !poll.ClosesAt.After(time.Now()) {
+		return invalidPoll("closes_at must be in the future")
+	}
+	if err := s.Repo.Update(ctx, poll); err != nil {
+		return err
+	}
+	return s.load(ctx, poll)
+}
+
+// ClosePoll ends voting now
+func (s *PollService) ClosePoll(ctx context.Context, id, userID string) (*domain.Poll, error) {
+	poll, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkManager(ctx, poll, userID); err != nil {
+		return nil, err
+	}
+	if pollStatus(poll) == domain.PollOpen {
+		now := time.Now()
+		poll.ClosedAt = &now
+		if err := s.Repo.Update(ctx, poll); err != nil {
+			return nil, err
+		}
+	}
+	return poll, s.load(ctx, poll)
+}
+
+func (s *PollService) DeletePoll(ctx context.Context, id, userID string) error {
+	poll, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.checkManager(ctx, poll, userID); err != nil {
+		return err
+	}
+	return s.Repo.Delete(ctx, id)
+}
+
+// checkManager allows the poll's creator and the trip owner
+func (s *PollService) checkManager(ctx context.Context, poll *domain.Poll, userID string) error {
+	trip, err := s.TripRepo.GetByID(ctx, poll.TripID)
+	if err != nil {
+		return errors.New("trip not found")
+	}
+	if trip.UserID == userID {
+		return nil
+	}
+	p, err := s.participant(ctx, poll.TripID, userID)
+	if err != nil {
+		return err
+	}
+	if poll.CreatedBy == nil || *poll.CreatedBy != p.ID {
+		return ErrNotTripOwner
+	}
+	return nil
+}
+
+// Propose adds an option to an open poll: an existing activity of the trip
+// when activityID is set, otherwise activity is created in the backlog as
+// an idea
+func (s *PollService) Propose(ctx context.Context, pollID, userID, activityID string, activity *domain.Activity) (*domain.PollOption, error) {
+	poll, err := s.Repo.GetByID(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.participant(ctx, poll.TripID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if pollStatus(poll) != domain.PollOpen {
+		return nil, ErrPollClosed
+	}
+
+	if activityID != "" {
+		if err := s.checkActivity(ctx, poll.TripID, activityID); err != nil {
+			return nil, err
+		}
+		if activity, err = s.ActivityRepo.GetByID(ctx, activityID); err != nil {
+			return nil, err
+		}
+	} else {
+		if activity == nil || strings.TrimSpace(activity.Name) == "" {
+			return nil, invalidPoll("activity_id or name is required")
+		}
+		activity.TripID = poll.TripID
+		activity.ItineraryID = nil
+		activity.Status = domain.ActivityIdea
+		if err := s.Activities.CreateActivity(ctx, activity, userID, false); err != nil {
+			return nil, err
+		}
+	}
+
+	options, err := s.Repo.GetOptions(ctx, poll.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range options {
+		if o.ActivityID == activity.ID {
+			return nil, invalidPoll("activity is already an option")
+		}
+	}
+
+	option := &domain.PollOption{PollID: poll.ID, ActivityID: activity.ID, ProposedBy: &p.ID, Activity: activity}
+	if err := s.Repo.AddOption(ctx, option); err != nil {
+		return nil, err
+	}
+	return option, nil
+}
+
+// RemoveOption withdraws an option from an open poll, along with its
+// votes. Only whoever proposed it and the trip owner may remove it.
+func (s *PollService) RemoveOption(ctx context.Context, pollID, optionID, userID string) error {
+	poll, err := s.Repo.GetByID(ctx, pollID)
+	if err != nil {
+		return err
+	}
+	if pollStatus(poll) != domain.PollOpen {
+		return ErrPollClosed
+	}
+	options, err := s.Repo.GetOptions(ctx, poll.ID)
+	if err != nil {
+		return err
+	}
+	var option *domain.PollOption
+	for i := range options {
+		if options[i].ID == optionID {
+			option = &options[i]
+		}
+	}
+	if option == nil {
+		return errors.New("option not found")
+	}
+
+	trip, err := s.TripRepo.GetByID(ctx, poll.TripID)
+	if err != nil {
+		return errors.New("trip not found")
+	}
+	if trip.UserID != userID {
+		p, err := s.participant(ctx, poll.TripID, userID)
+		if err != nil {
+			return err
+		}
+		if option.ProposedBy == nil || *option.ProposedBy != p.ID {
+			return ErrNotTripOwner
+		}
+	}
+	return s.Repo.DeleteOption(ctx, poll.ID, optionID)
+}
+
+// Vote replaces the user's ballot. In updown polls votes holds +1 or -1
+// per option; in rank polls ranking lists options from favourite down and
+// may leave some out. An empty ballot withdraws the user's votes.
+func (s *PollService) Vote(ctx context.Context, pollID, userID string, votes []domain.PollVote, ranking []string) ([]domain.PollVote, error) {
+	poll, err := s.Repo.GetByID(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.participant(ctx, poll.TripID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if pollStatus(poll) != domain.PollOpen {
+		return nil, ErrPollClosed
+	}
+	options, err := s.Repo.GetOptions(ctx, poll.ID)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(options))
+	for _, o := range options {
+		known[o.ID] = true
+	}
+
+	var ballot []domain.PollVote
+	seen := make(map[string]bool)
+	check := func(optionID string) error {
+		if !known[optionID] {
+			return invalidPoll("unknown option %s", optionID)
+		}
+		if seen[optionID] {
+			return invalidPoll("option %s is listed twice", optionID)
+		}
+		seen[optionID] = true
+		return nil
+	}
+
+	switch poll.Mode {
+	case domain.PollRank:
+		if len(votes) > 0 {
+			return nil, invalidPoll("rank polls take a ranking, not votes")
+		}
+		for i, optionID := range ranking {
+			if err := check(optionID); err != nil {
+				return nil, err
+			}
+			ballot = append(ballot, domain.PollVote{OptionID: optionID, Value: i + 1})
+		}
+	default:
+		if len(ranking) > 0 {
+			return nil, invalidPoll("updown polls take votes, not a ranking")
+		}
+		for _, v := range votes {
+			if err := check(v.OptionID); err != nil {
+				return nil, err
+			}
+			if v.Value != 1 && v.Value != -1 {
+				return nil, invalidPoll("vote value must be 1 or -1")
+			}
+			ballot = append(ballot, domain.PollVote{OptionID: v.OptionID, Value: v.Value})
+		}
+	}
+
+	for i := range ballot {
+		ballot[i].ParticipantID = p.ID
+	}
+	if err := s.Repo.SetBallot(ctx, poll.ID, p.ID, ballot); err != nil {
+		return nil, err
+	}
+	if ballot == nil {
+		ballot = []domain.PollVote{}
+	}
+	return ballot, nil
+}
+
+// Promote schedules an option on one of the trip's days and closes the
+// poll. It defaults to the winner; on a tie the owner has to pick
+// optionID. Ideas become planned. A poll is only promoted once.
+func (s *PollService) Promote(ctx context.Context, pollID, userID, optionID, itineraryID string, index int) (*domain.Poll, error) {
+	poll, err := s.Repo.GetByID(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	trip, err := s.TripRepo.GetByID(ctx, poll.TripID)
+	if err != nil {
+		return nil, errors.New("trip not found")
+	}
+	if trip.UserID != userID {
+		return nil, ErrNotTripOwner
+	}
+	if poll.PromotedActivityID != nil {
+		return nil, repository.ErrPollPromoted
+	}
+	if err := s.load(ctx, poll); err != nil {
+		return nil, err
+	}
+
+	if optionID == "" {
+		votes, err := s.Repo.GetVotes(ctx, poll.ID)
+		if err != nil {
+			return nil, err
+		}
+		results := tallyPoll(poll, votes)
+		if results.WinnerID == nil {
+			return nil, invalidPoll("the poll has no winner, pick option_id")
+		}
+		optionID = *results.WinnerID
+	}
+	var option *domain.PollOption
+	for i := range poll.Options {
+		if poll.Options[i].ID == optionID {
+			option = &poll.Options[i]
+		}
+	}
+	if option == nil {
+		return nil, errors.New("option not found")
+	}
+
+	itinerary, err := s.Activities.ItineraryRepo.GetByID(ctx, itineraryID)
+	if err != nil {
+		return nil, ErrItineraryNotFound
+	}
+	if itinerary.TripID != poll.TripID {
+		return nil, invalidPoll("itinerary belongs to a different trip")
+	}
+
+	activity, err := s.Repo.Promote(ctx, poll, option.ActivityID, itineraryID, index, userID)
+	if err != nil {
+		return nil, err
+	}
+	activity.Localize()
+	option.Activity = activity
+	poll.Status = pollStatus(poll)
+	return poll, nil
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// checkActivity verifies the activity belongs to the trip
+func (s *PollService) checkActivity(ctx context.Context, tripID, activityID string) error {
+	activity, err := s.ActivityRepo.GetByID(ctx, activityID)
+	if err != nil || activity.TripID != tripID {
+		return invalidPoll("activity %s is not part of this trip", activityID)
+	}
+	return nil
+}
+
+// pollStatus is closed once closed_at is set or closes_at has passed
+func pollStatus(poll *domain.Poll) string {
+	if poll.ClosedAt != nil || (poll.ClosesAt != nil && !time.Now().Before(*poll.ClosesAt)) {
+		return domain.PollClosed
+	}
+	return domain.PollOpen
+}
+
+// tallyPoll aggregates the ballots. Updown options score upvotes minus
+// downvotes. Ranked options get Borda points: with n options, a first
+// choice is worth n points, a second n-1 and so on, unranked ones nothing.
+// The winner is the single best-scoring option with a positive score.
+func tallyPoll(poll *domain.Poll, votes []domain.PollVote) *domain.PollResults {
+	index := make(map[string]int, len(poll.Options))
+	results := &domain.PollResults{Options: make([]domain.PollResult, len(poll.Options))}
+	for i, o := range poll.Options {
+		index[o.ID] = i
+		results.Options[i] = domain.PollResult{OptionID: o.ID, ActivityID: o.ActivityID}
+	}
+
+	voters := make(map[string]bool)
+	for _, v := range votes {
+		i, ok := index[v.OptionID]
+		if !ok {
+			continue
+		}
+		voters[v.ParticipantID] = true
+		r := &results.Options[i]
+		if poll.Mode == domain.PollRank {
+			if points := len(poll.Options) - v.Value + 1; points > 0 {
+				r.Score += points
+			}
+			if v.Value == 1 {
+				r.FirstChoices++
+			}
+			continue
+		}
+		if v.Value > 0 {
+			r.Upvotes++
+		} else {
+			r.Downvotes++
+		}
+		r.Score += v.Value
+	}
+	results.Ballots = len(voters)
+
+	// Options keep their proposal order among equal scores
+	sort.SliceStable(results.Options, func(i, j int) bool { return results.Options[i].Score > results.Options[j].Score })
+	if len(results.Options) > 0 && results.Options[0].Score > 0 {
+		if len(results.Options) > 1 && results.Options[1].Score == results.Options[0].Score {
+			results.Tied = true
+		} else {
+			results.WinnerID = &results.Options[0].OptionID
+		}
+	}
+	return results
+}
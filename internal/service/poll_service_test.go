@@ -0,0 +1,120 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+)
+
+func pollWithOptions(mode string, ids ...string) *domain.Poll {
+	poll := &domain.Poll{Mode: mode}
+	for _, id := range ids {
+		poll.Options = append(poll.Options, domain.PollOption{ID: id, ActivityID: "activity-" + id})
+	}
+	return poll
+}
+
+func vote(participant, option string, value int) domain.PollVote {
+	return domain.PollVote{ParticipantID: participant, OptionID: option, Value: value}
+}
+
+func scores(results *domain.PollResults) map[string]int {
+	s := make(map[string]int)
+	for _, r := range results.Options {
+		s[r.OptionID] = r.Score
+	}
+	return s
+}
+
+func TestTallyPollUpDown(t *testing.T) {
+	poll := pollWithOptions(domain.PollUpDown, "louvre", "orsay", "catacombs")
+	results := tallyPoll(poll, []domain.PollVote{
+		vote("ann", "louvre", 1), vote("ann", "orsay", 1), vote("ann", "catacombs", -1),
+		vote("bob", "orsay", 1), vote("bob", "louvre", -1),
+		vote("cid", "orsay", -1),
+		vote("cid", "removed-option", 1),
+	})
+
+	if results.Ballots != 3 {
+		t.Errorf("got %d ballots, want 3", results.Ballots)
+	}
+	order := [3]string{results.Options[0].OptionID, results.Options[1].OptionID, results.Options[2].OptionID}
+	if order != [3]string{"orsay", "louvre", "catacombs"} {
+		t.Errorf("got order %v", order)
+	}
+	orsay := results.Options[0]
+	if orsay.Score != 1 || orsay.Upvotes != 2 || orsay.Downvotes != 1 || orsay.ActivityID != "activity-orsay" {
+		t.Errorf("got %+v for orsay", orsay)
+	}
+	if results.WinnerID == nil || *results.WinnerID != "orsay" || results.Tied {
+		t.Errorf("got winner %v, tied %v", results.WinnerID, results.Tied)
+	}
+}
+
+func TestTallyPollRank(t *testing.T) {
+	poll := pollWithOptions(domain.PollRank, "a", "b", "c")
+	results := tallyPoll(poll, []domain.PollVote{
+		vote("ann", "a", 1), vote("ann", "b", 2), vote("ann", "c", 3),
+		vote("bob", "b", 1), vote("bob", "a", 2),
+		vote("cid", "b", 1),
+	})
+
+	// Borda with 3 options: first 3 points, second 2, third 1
+	want := map[string]int{"a": 5, "b": 8, "c": 1}
+	got := scores(results)
+	for id, score := range want {
+		if got[id] != score {
+			t.Errorf("option %s: got %d points, want %d", id, got[id], score)
+		}
+	}
+	if results.Options[0].OptionID != "b" || results.Options[0].FirstChoices != 2 {
+		t.Errorf("got %+v first", results.Options[0])
+	}
+	if results.WinnerID == nil || *results.WinnerID != "b" {
+		t.Errorf("got winner %v, want b", results.WinnerID)
+	}
+}
+
+func TestTallyPollNoWinner(t *testing.T) {
+	tests := []struct {
+		name     string
+		votes    []domain.PollVote
+		wantTied bool
+	}{
+		{name: "no votes"},
+		{name: "only downvotes", votes: []domain.PollVote{vote("ann", "a", -1)}},
+		{name: "tie", votes: []domain.PollVote{vote("ann", "a", 1), vote("bob", "b", 1)}, wantTied: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := tallyPoll(pollWithOptions(domain.PollUpDown, "a", "b"), tt.votes)
+			if results.WinnerID != nil || results.Tied != tt.wantTied {
+				t.Errorf("got winner %v, tied %v", results.WinnerID, results.Tied)
+			}
+			// Equal scores keep the proposal order
+			if tt.wantTied && (results.Options[0].OptionID != "a" || results.Options[1].OptionID != "b") {
+				t.Errorf("got order %s, %s", results.Options[0].OptionID, results.Options[1].OptionID)
+			}
+		})
+	}
+}
+
+func TestPollStatus(t *testing.T) {
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	tests := []struct {
+		name string
+		poll domain.Poll
+		want string
+	}{
+		{"open", domain.Poll{}, domain.PollOpen},
+		{"closing later", domain.Poll{ClosesAt: &future}, domain.PollOpen},
+		{"past its closing time", domain.Poll{ClosesAt: &past}, domain.PollClosed},
+		{"closed early", domain.Poll{ClosesAt: &future, ClosedAt: &past}, domain.PollClosed},
+	}
+	for _, tt := range tests {
+		if got := pollStatus(&tt.poll); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
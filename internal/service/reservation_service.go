@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/money"
+	"github.com/NoahFola/travel_app_backend/internal/repository"
+	"github.com/NoahFola/travel_app_backend/internal/storage"
+)
+
+// ErrInvalidReservation wraps every validation failure so handlers can answer 400
+var ErrInvalidReservation = errors.New("invalid reservation")
+
+type ReservationService struct {
+	Repo            *repository.ReservationRepository
+	ActivityRepo    *repository.ActivityRepository
+	TripRepo        *repository.TripRepository
+	MediaRepo       *repository.MediaRepository
+	ParticipantRepo *repository.ParticipantRepository
+	Blobs           storage.BlobStore
+}
+
+var reservationTypes = map[string]bool{
+	domain.ReservationFlight: true,
+	domain.ReservationHotel:  true,
+	domain.ReservationFood:   true,
+	domain.ReservationCar:    true,
+	domain.ReservationTrain:  true,
+	domain.ReservationOther:  true,
+}
+
+var flightCabins = map[string]bool{
+	"economy": true, "premium_economy": true, "business": true, "first": true,
+}
+
+var (
+	flightNumberPattern = regexp.MustCompile(`^[A-Z0-9]{2}[0-9]{1,4}[A-Z]?$`)
+	iataAirportPattern  = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+func invalidReservation(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidReservation, fmt.Sprintf(format, args...))
+}
+
+// CreateReservation attaches a booking to an activity. An empty Type
+// defaults to the activity's type when that is a reservation type.
+func (s *ReservationService) CreateReservation(ctx context.Context, res *domain.Reservation, mediaIDs []string, userID string) error {
+	activity, err := s.ActivityRepo.GetByID(ctx, res.ActivityID)
+	if err != nil {
+		return errors.New("activity not found")
+	}
+	if err := requireParticipant(ctx, s.ParticipantRepo, activity.TripID, userID); err != nil {
+		return err
+	}
+	res.TripID = activity.TripID
+
+	if res.Type == "" {
+		res.Type = domain.ReservationOther
+		if activity.Type != nil && reservationTypes[strings.ToLower(*activity.Type)] {
+			res.Type = strings.ToLower(*activity.Type)
+		}
+	}
+
+	if err := s.validate(ctx, res, mediaIDs); err != nil {
+		return err
+	}
+	if err := s.Repo.Create(ctx, res, mediaIDs); err != nil {
+		return err
+	}
+	return s.reload(ctx, res)
+}
+
+// GetReservation returns a reservation of a trip the user takes part in
+func (s *ReservationService) GetReservation(ctx context.Context, id, userID string) (*domain.Reservation, error) {
+	res, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireParticipant(ctx, s.ParticipantRepo, res.TripID, userID); err != nil {
+		return nil, err
+	}
+	s.format(ctx, res)
+	return res, nil
+}
+
+// ListByActivity returns an activity's reservations
+func (s *ReservationService) ListByActivity(ctx context.Context, activityID, userID string) ([]domain.Reservation, error) {
+	activity, err := s.ActivityRepo.GetByID(ctx, activityID)
+	if err != nil {
+		return nil, errors.New("activity not found")
+	}
+	if err := requireParticipant(ctx, s.ParticipantRepo, activity.TripID, userID); err != nil {
+		return nil, err
+	}
+	reservations, err := s.Repo.GetByActivityID(ctx, activityID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range reservations {
+		s.format(ctx, &reservations[i])
+	}
+	return reservations, nil
+}
+
+// ListByTrip returns every reservation of a trip, earliest first
+func (s *ReservationService) ListByTrip(ctx context.Context, tripID, userID string) ([]domain.Reservation, error) {
+	if _, err := s.TripRepo.GetByID(ctx, tripID); err != nil {
+		return nil, errors.New("trip not found")
+	}
+	if err := requireParticipant(ctx, s.ParticipantRepo, tripID, userID); err != nil {
+		return nil, err
+	}
+	reservations, err := s.Repo.GetByTripID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range reservations {
+		s.format(ctx, &reservations[i])
+	}
+	return reservations, nil
+}
+
+// UpdateReservation saves a reservation loaded with GetReservation, which
+// checked the user. A non-nil mediaIDs replaces the attachments.
+func (s *ReservationService) UpdateReservation(ctx context.Context, res *domain.Reservation, mediaIDs []string) error {
+	if err := s.validate(ctx, res, mediaIDs); err != nil {
+		return err
+	}
+	if err := s.Repo.Update(ctx, res, mediaIDs); err != nil {
+		return err
+	}
+	return s.reload(ctx, res)
+}
+
+func (s *ReservationService) DeleteReservation(ctx context.Context, id, userID string) error {
+	res, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := requireParticipant(ctx, s.ParticipantRepo, res.TripID, userID); err != nil {
+		return err
+	}
+	return s.Repo.Delete(ctx, id)
+}
+
+// reload refreshes the attachments after a write
+func (s *ReservationService) reload(ctx context.Context, res *domain.Reservation) error {
+	fresh, err := s.Repo.GetByID(ctx, res.ID)
+	if err != nil {
+		return err
+	}
+	s.format(ctx, fresh)
+	*res = *fresh
+	return nil
+}
+
+// format fills the display cost and the attachment URLs
+func (s *ReservationService) format(ctx context.Context, res *domain.Reservation) {
+	res.Cost = nil
+	if res.CostMinor != nil && res.Currency != nil {
+		cost := money.Format(*res.CostMinor, *res.Currency)
+		res.Cost = &cost
+	}
+	for i := range res.Attachments {
+		res.Attachments[i].URL = mediaURL(ctx, s.Blobs, res.Attachments[i].Key)
+	}
+}
+
+func (s *ReservationService) validate(ctx context.Context, res *domain.Reservation, mediaIDs []string) error {
+	res.Type = strings.ToLower(res.Type)
+	if !reservationTypes[res.Type] {
+		return invalidReservation("unknown type %q", res.Type)
+	}
+
+	if res.ConfirmationNumber != nil {
+		code := strings.TrimSpace(*res.ConfirmationNumber)
+		res.ConfirmationNumber = &code
+	}
+	if res.StartsAt != nil && res.EndsAt != nil && res.EndsAt.Before(*res.StartsAt) {
+		return invalidReservation("ends_at is before starts_at")
+	}
+
+	if res.CostMinor != nil {
+		if res.Currency == nil {
+			return invalidReservation("currency is required with a cost")
+		}
+		code := strings.ToUpper(*res.Currency)
+		if !money.ValidCurrency(code) {
+			return invalidReservation("currency must be a 3-letter ISO code")
+		}
+		res.Currency = &code
+		if *res.CostMinor < 0 {
+			return invalidReservation("cost cannot be negative")
+		}
+	} else {
+		res.Currency = nil
+	}
+
+	if err := validateReservationDetails(res); err != nil {
+		return err
+	}
+
+	if len(mediaIDs) > 0 {
+		unique := make(map[string]bool, len(mediaIDs))
+		for _, id := range mediaIDs {
+			unique[id] = true
+		}
+		if len(unique) != len(mediaIDs) {
+			return invalidReservation("attachment_ids contains duplicates")
+		}
+		count, err := s.MediaRepo.CountInTrip(ctx, res.TripID, mediaIDs)
+		if err != nil {
+			return err
+		}
+		if count != len(mediaIDs) {
+			return invalidReservation("attachments must belong to this trip")
+		}
+	}
+	return nil
+}
+
+// validateReservationDetails checks that only the details for the
+// reservation's type are set and that their required fields are present
+func validateReservationDetails(res *domain.Reservation) error {
+	d := &res.Details
+	set := []struct {
+		typ string
+		ok  bool
+	}{
+		{domain.ReservationFlight, d.Flight != nil},
+		{domain.ReservationHotel, d.Hotel != nil},
+		{domain.ReservationFood, d.Food != nil},
+		{domain.ReservationCar, d.Car != nil},
+		{domain.ReservationTrain, d.Train != nil},
+	}
+	for _, x := range set {
+		if x.ok && x.typ != res.Type {
+			return invalidReservation("%s details on a %s reservation", x.typ, res.Type)
+		}
+	}
+
+	switch res.Type {
+	case domain.ReservationFlight:
+		f := d.Flight
+		if f == nil {
+			return invalidReservation("flight details are required")
+		}
+		f.FlightNumber = strings.ToUpper(strings.ReplaceAll(f.FlightNumber, " ", ""))
+		if !flightNumberPattern.MatchString(f.FlightNumber) {
+			return invalidReservation("flight_number must look like BA117")
+		}
+		f.DepartureAirport = strings.ToUpper(f.DepartureAirport)
+		f.ArrivalAirport = strings.ToUpper(f.ArrivalAirport)
+		if !iataAirportPattern.MatchString(f.DepartureAirport) || !iataAirportPattern.MatchString(f.ArrivalAirport) {
+			return invalidReservation("airports must be 3-letter IATA codes")
+		}
+		if f.DepartureAirport == f.ArrivalAirport {
+			return invalidReservation("departure and arrival airports are the same")
+		}
+		if f.Cabin != nil {
+			cabin := strings.ToLower(*f.Cabin)
+			if !flightCabins[cabin] {
+				return invalidReservation("unknown cabin %q", *f.Cabin)
+			}
+			f.Cabin = &cabin
+		}
+		if res.StartsAt == nil {
+			return invalidReservation("starts_at (departure) is required for flights")
+		}
+		if f.BoardingTime != nil && f.BoardingTime.After(*res.StartsAt) {
+			return invalidReservation("boarding_time is after departure")
+		}
+
+	case domain.ReservationHotel:
+		if d.Hotel == nil {
+			d.Hotel = &domain.HotelDetails{}
+		}
+		if d.Hotel.Rooms == 0 {
+			d.Hotel.Rooms = 1
+		}
+		if d.Hotel.Guests == 0 {
+			d.Hotel.Guests = 1
+		}
+		if d.Hotel.Rooms < 0 || d.Hotel.Guests < 0 {
+			return invalidReservation("rooms and guests must be positive")
+		}
+		if res.StartsAt == nil || res.EndsAt == nil {
+			return invalidReservation("starts_at (check-in) and ends_at (check-out) are required for hotels")
+		}
+		if !res.EndsAt.After(*res.StartsAt) {
+			return invalidReservation("check-out must be after check-in")
+		}
+
+	case domain.ReservationFood:
+		if d.Food == nil || d.Food.PartySize < 1 {
+			return invalidReservation("food details with a party_size of at least 1 are required")
+		}
+		if res.StartsAt == nil {
+			return invalidReservation("starts_at (table time) is required for restaurants")
+		}
+
+	case domain.ReservationCar:
+		if d.Car == nil || strings.TrimSpace(d.Car.PickupLocation) == "" {
+			return invalidReservation("car details with a pickup_location are required")
+		}
+		if res.StartsAt == nil || res.EndsAt == nil {
+			return invalidReservation("starts_at (pick-up) and ends_at (drop-off) are required for cars")
+		}
+
+	case domain.ReservationTrain:
+		t := d.Train
+		if t == nil || strings.TrimSpace(t.DepartureStation) == "" || strings.TrimSpace(t.ArrivalStation) == "" {
+			return invalidReservation("train details with departure_station and arrival_station are required")
+		}
+		if res.StartsAt == nil {
+			return invalidReservation("starts_at (departure) is required for trains")
+		}
+	}
+	return nil
+}
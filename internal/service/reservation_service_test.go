@@ -0,0 +1,82 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+)
+
+func TestValidateReservationDetails(t *testing.T) {
+	at := func(hour int) *time.Time { t := time.Date(2024, 5, 1, hour, 0, 0, 0, time.UTC); return &t }
+	str := func(s string) *string { return &s }
+	flight := func(number, from, to string) domain.ReservationDetails {
+		return domain.ReservationDetails{Flight: &domain.FlightDetails{FlightNumber: number, DepartureAirport: from, ArrivalAirport: to}}
+	}
+
+	tests := []struct {
+		name    string
+		res     domain.Reservation
+		wantErr bool
+	}{
+		{"flight", domain.Reservation{Type: domain.ReservationFlight, StartsAt: at(9), Details: flight("BA117", "LHR", "JFK")}, false},
+		{"flight without details", domain.Reservation{Type: domain.ReservationFlight, StartsAt: at(9)}, true},
+		{"bad flight number", domain.Reservation{Type: domain.ReservationFlight, StartsAt: at(9), Details: flight("speedbird", "LHR", "JFK")}, true},
+		{"bad airport", domain.Reservation{Type: domain.ReservationFlight, StartsAt: at(9), Details: flight("BA117", "London", "JFK")}, true},
+		{"round trip to nowhere", domain.Reservation{Type: domain.ReservationFlight, StartsAt: at(9), Details: flight("BA117", "LHR", "lhr")}, true},
+		{"flight without departure", domain.Reservation{Type: domain.ReservationFlight, Details: flight("BA117", "LHR", "JFK")}, true},
+		{"boarding after departure", domain.Reservation{Type: domain.ReservationFlight, StartsAt: at(9), Details: domain.ReservationDetails{Flight: &domain.FlightDetails{FlightNumber: "BA117", DepartureAirport: "LHR", ArrivalAirport: "JFK", BoardingTime: at(10)}}}, true},
+		{"unknown cabin", domain.Reservation{Type: domain.ReservationFlight, StartsAt: at(9), Details: domain.ReservationDetails{Flight: &domain.FlightDetails{FlightNumber: "BA117", DepartureAirport: "LHR", ArrivalAirport: "JFK", Cabin: str("steerage")}}}, true},
+		{"hotel", domain.Reservation{Type: domain.ReservationHotel, StartsAt: at(15), EndsAt: at(23)}, false},
+		{"hotel checking out before check-in", domain.Reservation{Type: domain.ReservationHotel, StartsAt: at(15), EndsAt: at(11)}, true},
+		{"hotel without check-out", domain.Reservation{Type: domain.ReservationHotel, StartsAt: at(15)}, true},
+		{"negative guests", domain.Reservation{Type: domain.ReservationHotel, StartsAt: at(15), EndsAt: at(23), Details: domain.ReservationDetails{Hotel: &domain.HotelDetails{Guests: -1}}}, true},
+		{"table for two", domain.Reservation{Type: domain.ReservationFood, StartsAt: at(19), Details: domain.ReservationDetails{Food: &domain.FoodDetails{PartySize: 2}}}, false},
+		{"table for nobody", domain.Reservation{Type: domain.ReservationFood, StartsAt: at(19), Details: domain.ReservationDetails{Food: &domain.FoodDetails{}}}, true},
+		{"car", domain.Reservation{Type: domain.ReservationCar, StartsAt: at(9), EndsAt: at(18), Details: domain.ReservationDetails{Car: &domain.CarDetails{PickupLocation: "Airport"}}}, false},
+		{"car without pick-up", domain.Reservation{Type: domain.ReservationCar, StartsAt: at(9), EndsAt: at(18), Details: domain.ReservationDetails{Car: &domain.CarDetails{PickupLocation: " "}}}, true},
+		{"train", domain.Reservation{Type: domain.ReservationTrain, StartsAt: at(9), Details: domain.ReservationDetails{Train: &domain.TrainDetails{DepartureStation: "Paris Nord", ArrivalStation: "London St Pancras"}}}, false},
+		{"train without arrival", domain.Reservation{Type: domain.ReservationTrain, StartsAt: at(9), Details: domain.ReservationDetails{Train: &domain.TrainDetails{DepartureStation: "Paris Nord"}}}, true},
+		{"details of another type", domain.Reservation{Type: domain.ReservationHotel, StartsAt: at(15), EndsAt: at(23), Details: flight("BA117", "LHR", "JFK")}, true},
+		{"other", domain.Reservation{Type: domain.ReservationOther}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := tt.res
+			err := validateReservationDetails(&res)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidReservation) {
+				t.Errorf("got %v, want ErrInvalidReservation", err)
+			}
+		})
+	}
+}
+
+func TestValidateReservationDetailsNormalizes(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	cabin := "Business"
+	res := domain.Reservation{
+		Type:     domain.ReservationFlight,
+		StartsAt: &start,
+		Details:  domain.ReservationDetails{Flight: &domain.FlightDetails{FlightNumber: "ba 117", DepartureAirport: "lhr", ArrivalAirport: "jfk", Cabin: &cabin}},
+	}
+	if err := validateReservationDetails(&res); err != nil {
+		t.Fatal(err)
+	}
+	f := res.Details.Flight
+	if f.FlightNumber != "BA117" || f.DepartureAirport != "LHR" || f.ArrivalAirport != "JFK" || *f.Cabin != "business" {
+		t.Errorf("got %+v", f)
+	}
+
+	end := start.Add(24 * time.Hour)
+	hotel := domain.Reservation{Type: domain.ReservationHotel, StartsAt: &start, EndsAt: &end}
+	if err := validateReservationDetails(&hotel); err != nil {
+		t.Fatal(err)
+	}
+	if h := hotel.Details.Hotel; h == nil || h.Rooms != 1 || h.Guests != 1 {
+		t.Errorf("got hotel details %+v, want one room for one guest", h)
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/NoahFola/travel_app_backend/internal/geo"
+	"github.com/NoahFola/travel_app_backend/internal/repository"
+	"github.com/NoahFola/travel_app_backend/internal/schedule"
+)
+
+type RouteService struct {
+	ActivityRepo  *repository.ActivityRepository
+	ItineraryRepo *repository.ItineraryRepository
+	LocationRepo  *repository.LocationRepository
+}
+
+// RouteStop is one activity in a proposed visiting order
+type RouteStop struct {
+	ActivityID    string     `json:"activity_id"`
+	Name          string     `json:"name"`
+	StartTime     *time.Time `json:"start_time"`
+	Fixed         bool       `json:"fixed"`   // has a start time, so it is an anchor
+	Located       bool       `json:"located"` // has coordinates
+	LegDistanceKm float64    `json:"leg_distance_km"`
+}
+
+type RouteProposal struct {
+	ItineraryID       string      `json:"itinerary_id"`
+	Stops             []RouteStop `json:"stops"`
+	TotalDistanceKm   float64     `json:"total_distance_km"`
+	CurrentDistanceKm float64     `json:"current_distance_km"`
+	Applied           bool        `json:"applied"`
+}
+
+// ResolvePoint returns the coordinates of a stored location
+func (s *RouteService) ResolvePoint(ctx context.Context, locationID string) (*geo.Point, error) {
+	loc, err := s.LocationRepo.GetByID(ctx, locationID)
+	if err != nil {
+		return nil, err
+	}
+	if loc == nil {
+		return nil, errors.New("location not found")
+	}
+	return &geo.Point{Lat: loc.Latitude, Lng: loc.Longitude}, nil
+}
+
+// OptimizeItinerary proposes a visiting order for a day's activities that
+// minimises travel distance. Activities with a start time stay in time order;
+// the rest are placed around them. With apply, the order is saved.
+func (s *RouteService) OptimizeItinerary(ctx context.Context, itineraryID string, start, end *geo.Point, apply bool) (*RouteProposal, error) {
+	itinerary, err := s.ItineraryRepo.GetByID(ctx, itineraryID)
+	if err != nil {
+		return nil, errors.New("itinerary not found")
+	}
+
+	all, err := s.ActivityRepo.GetScheduleByTripID(ctx, itinerary.TripID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]repository.ScheduledActivity)
+	var current []schedule.Stop
+	for _, a := range all {
+		if a.ItineraryID == nil || *a.ItineraryID != itineraryID {
+			continue
+		}
+		byID[a.ID] = a
+		stop := schedule.Stop{ID: a.ID, Fixed: a.StartTime != nil}
+		if a.Place != nil {
+			stop.Point = &geo.Point{Lat: a.Place.Latitude, Lng: a.Place.Longitude}
+		}
+		current = append(current, stop)
+	}
+
+	// Anchors are visited in time order, whatever their current position
+	var anchors []schedule.Stop
+	for _, stop := range current {
+		if stop.Fixed {
+			anchors = append(anchors, stop)
+		}
+	}
+	sort.SliceStable(anchors, func(i, j int) bool {
+		return byID[anchors[i].ID].StartTime.Before(*byID[anchors[j].ID].StartTime)
+	})
+	ordered := append([]schedule.Stop{}, current...)
+	k := 0
+	for i := range ordered {
+		if ordered[i].Fixed {
+			ordered[i] = anchors[k]
+			k++
+		}
+	}
+
+	optimized := schedule.OptimizeRoute(ordered, start, end)
+	legs, total := schedule.RouteLegs(optimized, start, end)
+	_, currentTotal := schedule.RouteLegs(current, start, end)
+
+	proposal := &RouteProposal{
+		ItineraryID:       itineraryID,
+		Stops:             make([]RouteStop, 0, len(optimized)),
+		TotalDistanceKm:   round2(total),
+		CurrentDistanceKm: round2(currentTotal),
+	}
+	ids := make([]string, 0, len(optimized))
+	for i, stop := range optimized {
+		a := byID[stop.ID]
+		proposal.Stops = append(proposal.Stops, RouteStop{
+			ActivityID:    a.ID,
+			Name:          a.Name,
+			StartTime:     a.StartTime,
+			Fixed:         stop.Fixed,
+			Located:       stop.Point != nil,
+			LegDistanceKm: round2(legs[i].DistanceKm),
+		})
+		ids = append(ids, a.ID)
+	}
+
+	if apply && len(ids) > 0 {
+		if err := s.ActivityRepo.Reorder(ctx, itineraryID, ids); err != nil {
+			return nil, err
+		}
+		proposal.Applied = true
+	}
+
+	return proposal, nil
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
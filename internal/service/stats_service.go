@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+	"github.com/NoahFola/travel_app_backend/internal/geo"
+	"github.com/NoahFola/travel_app_backend/internal/money"
+	"github.com/NoahFola/travel_app_backend/internal/repository"
+)
+
+type StatsService struct {
+	Repo     *repository.StatsRepository
+	TripRepo *repository.TripRepository
+	Currency *CurrencyService
+}
+
+// TripStats computes statistics for a single trip. If currency is set, the
+// spend is also totalled in that currency.
+func (s *StatsService) TripStats(ctx context.Context, tripID, currency string) (*domain.TravelStats, error) {
+	trip, err := s.TripRepo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, errors.New("trip not found")
+	}
+	return s.compute(ctx, []domain.Trip{*trip}, nil, currency)
+}
+
+// UserStats computes statistics over every trip the user owns or joined.
+// With a year, it becomes a year-in-review: only trips overlapping that
+// calendar year count, and days, activities, photos and spend are clipped to it.
+func (s *StatsService) UserStats(ctx context.Context, userID string, year *int, currency string) (*domain.TravelStats, error) {
+	var from, to *time.Time
+	if year != nil {
+		start := time.Date(*year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(*year, time.December, 31, 0, 0, 0, 0, time.UTC)
+		from, to = &start, &end
+	}
+
+	trips, err := s.Repo.GetUserTrips(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return s.compute(ctx, trips, year, currency)
+}
+
+func (s *StatsService) compute(ctx context.Context, trips []domain.Trip, year *int, currency string) (*domain.TravelStats, error) {
+	stats := &domain.TravelStats{
+		Year:             year,
+		Trips:            len(trips),
+		Countries:        []string{},
+		Cities:           []string{},
+		ActivitiesByType: map[string]int{},
+		Spend:            []domain.SpendTotal{},
+	}
+	if len(trips) == 0 {
+		return stats, nil
+	}
+
+	inYear := func(t time.Time) bool { return year == nil || t.Year() == *year }
+
+	tripIDs := make([]string, len(trips))
+	days := make(map[string]bool)
+	for i, trip := range trips {
+		tripIDs[i] = trip.ID
+		for d := trip.StartDate; !d.After(trip.EndDate); d = d.AddDate(0, 0, 1) {
+			if inYear(d) {
+				days[d.Format("2006-01-02")] = true
+			}
+		}
+	}
+	stats.DaysTravelled = len(days)
+
+	facts, err := s.Repo.GetActivityFacts(ctx, tripIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	countries := make(map[string]bool)
+	cities := make(map[string]bool)
+	perDay := make(map[[2]string]int)
+	var prev *repository.ActivityFact
+	for i := range facts {
+		f := &facts[i]
+		if f.Day != nil && !inYear(*f.Day) {
+			continue
+		}
+
+		stats.Activities++
+		activityType := "other"
+		if f.Type != nil && *f.Type != "" {
+			activityType = strings.ToLower(*f.Type)
+		}
+		stats.ActivitiesByType[activityType]++
+
+		if f.Day != nil {
+			perDay[[2]string{f.Day.Format("2006-01-02"), f.TripID}]++
+		}
+
+		if f.Address != nil {
+			city, country := placeFromAddress(*f.Address)
+			if country != "" {
+				countries[country] = true
+			}
+			if city != "" {
+				cities[city] = true
+			}
+		}
+
+		// Distance is measured between consecutive located activities of the same trip
+		if f.Latitude != nil && f.Longitude != nil {
+			if prev != nil && prev.TripID == f.TripID {
+				stats.DistanceKm += geo.Haversine(
+					geo.Point{Lat: *prev.Latitude, Lng: *prev.Longitude},
+					geo.Point{Lat: *f.Latitude, Lng: *f.Longitude},
+				)
+			}
+			prev = f
+		}
+	}
+	stats.DistanceKm = math.Round(stats.DistanceKm*10) / 10
+	stats.Countries = sortedKeys(countries)
+	stats.Cities = sortedKeys(cities)
+
+	for key, count := range perDay {
+		b := stats.BusiestDay
+		if b == nil || count > b.Activities || (count == b.Activities && key[0] < b.Date) {
+			stats.BusiestDay = &domain.BusiestDay{Date: key[0], TripID: key[1], Activities: count}
+		}
+	}
+
+	if stats.Photos, err = s.Repo.CountPhotos(ctx, tripIDs, year); err != nil {
+		return nil, err
+	}
+
+	spend, err := s.Repo.GetSpend(ctx, tripIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.totalSpend(ctx, stats, spend, inYear, currency); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func (s *StatsService) totalSpend(ctx context.Context, stats *domain.TravelStats, spend []repository.SpendFact, inYear func(time.Time) bool, currency string) error {
+	byCurrency := make(map[string]int64)
+	var converted int64
+	currency = strings.ToUpper(currency)
+	for _, f := range spend {
+		if !inYear(f.IncurredAt) {
+			continue
+		}
+		byCurrency[f.Currency] += f.AmountMinor
+		if currency != "" {
+			c, err := s.Currency.Convert(ctx, f.AmountMinor, f.Currency, currency, f.IncurredAt)
+			if err != nil {
+				return err
+			}
+			converted += c.ConvertedMinor
+		}
+	}
+
+	for _, code := range sortedKeys(byCurrency) {
+		stats.Spend = append(stats.Spend, domain.SpendTotal{
+			Currency:    code,
+			AmountMinor: byCurrency[code],
+			Amount:      money.Format(byCurrency[code], code),
+		})
+	}
+	if currency != "" {
+		stats.TotalSpend = &domain.SpendTotal{
+			Currency:    currency,
+			AmountMinor: converted,
+			Amount:      money.Format(converted, currency),
+		}
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// placeFromAddress guesses city and country from a Google formatted address
+// such as "5 Av. Anatole France, 75007 Paris, France" or
+// "350 5th Ave, New York, NY 10118, USA". It is a heuristic, not a geocoder.
+func placeFromAddress(address string) (city, country string) {
+	var parts []string
+	for _, p := range strings.Split(address, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) < 2 {
+		return "", ""
+	}
+
+	country = parts[len(parts)-1]
+	city = stripPostalCode(parts[len(parts)-2])
+
+	// "NY 10118" style region codes sit between city and country
+	if len(parts) >= 3 && isRegionCode(city) {
+		city = stripPostalCode(parts[len(parts)-3])
+	}
+	return city, country
+}
+
+func stripPostalCode(s string) string {
+	var words []string
+	for _, w := range strings.Fields(s) {
+		hasDigit := strings.IndexFunc(w, unicode.IsDigit) >= 0
+		if !hasDigit {
+			words = append(words, w)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func isRegionCode(s string) bool {
+	if len(s) < 2 || len(s) > 3 {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsUpper(r) {
+			return false
+		}
+	}
+	return true
+}
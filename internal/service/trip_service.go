@@ -10,19 +10,47 @@ import (
 
 	"github.com/NoahFola/travel_app_backend/internal/domain"
 	"github.com/NoahFola/travel_app_backend/internal/repository"
+	"github.com/NoahFola/travel_app_backend/internal/storage"
 )
 
 type TripService struct {
-	Repo      *repository.TripRepository
-	ShareRepo *repository.ShareRepository
+	Repo            *repository.TripRepository
+	ShareRepo       *repository.ShareRepository
+	ParticipantRepo *repository.ParticipantRepository
+	JournalRepo     *repository.JournalRepository
+	Blobs           storage.BlobStore // resolves journal media URLs in previews
 }
 
-func NewTripService(repo *repository.TripRepository, shareRepo *repository.ShareRepository) *TripService {
-	return &TripService{Repo: repo, ShareRepo: shareRepo}
+func NewTripService(repo *repository.TripRepository, shareRepo *repository.ShareRepository, participantRepo *repository.ParticipantRepository, journalRepo *repository.JournalRepository, blobs storage.BlobStore) *TripService {
+	return &TripService{Repo: repo, ShareRepo: shareRepo, ParticipantRepo: participantRepo, JournalRepo: journalRepo, Blobs: blobs}
+}
+
+// TripPreview is what a share link exposes: the trip plus the journal
+// entries its authors chose to share.
+type TripPreview struct {
+	domain.Trip
+	Journal []domain.JournalEntry `json:"journal,omitempty"`
+}
+
+// newTripPreview builds the preview behind a valid share link. The link is
+// what shares the trip, so it needs no other check; entries stay private
+// unless their author shared them, whatever the query returned.
+func newTripPreview(trip *domain.Trip, journal []domain.JournalEntry) *TripPreview {
+	preview := &TripPreview{Trip: *trip}
+	for _, e := range journal {
+		if e.Shared {
+			preview.Journal = append(preview.Journal, e)
+		}
+	}
+	return preview
 }
 
 func (s *TripService) CreateTrip(ctx context.Context, trip *domain.Trip) error {
-	return s.Repo.Create(ctx, trip)
+	if err := s.Repo.Create(ctx, trip); err != nil {
+		return err
+	}
+	// The owner is always the first participant of their trip
+	return s.ParticipantRepo.AddOwner(ctx, trip.ID, trip.UserID)
 }
 
 func (s *TripService) GetTrip(ctx context.Context, id string) (*domain.Trip, error) {
@@ -61,7 +89,7 @@ func (s *TripService) GenerateShareToken(ctx context.Context, tripID string) (st
 	return token, nil
 }
 
-func (s *TripService) GetTripByShareToken(ctx context.Context, token string) (*domain.Trip, error) {
+func (s *TripService) GetTripByShareToken(ctx context.Context, token string) (*TripPreview, error) {
 	tripID, err := s.ShareRepo.GetTripIDByToken(ctx, token)
 	if err != nil {
 		return nil, err
@@ -70,5 +98,18 @@ func (s *TripService) GetTripByShareToken(ctx context.Context, token string) (*d
 		return nil, errors.New("invalid or crossed share token")
 	}
 
-	return s.Repo.GetByID(ctx, tripID)
+	trip, err := s.Repo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	journal, err := s.JournalRepo.GetByTripID(ctx, tripID, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	for i := range journal {
+		resolveJournalMedia(ctx, s.Blobs, &journal[i])
+	}
+
+	return newTripPreview(trip, journal), nil
 }
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/NoahFola/travel_app_backend/internal/domain"
+)
+
+func TestNewTripPreview(t *testing.T) {
+	trip := &domain.Trip{ID: "trip", Location: "Lisbon"}
+	journal := []domain.JournalEntry{
+		{ID: "arrival", Shared: true},
+		{ID: "private", Shared: false},
+		{ID: "belem", Shared: true},
+	}
+
+	preview := newTripPreview(trip, journal)
+	if preview.ID != "trip" || preview.Location != "Lisbon" {
+		t.Errorf("got trip %+v", preview.Trip)
+	}
+	var ids []string
+	for _, e := range preview.Journal {
+		ids = append(ids, e.ID)
+	}
+	if len(ids) != 2 || ids[0] != "arrival" || ids[1] != "belem" {
+		t.Errorf("got entries %v, want the shared ones", ids)
+	}
+
+	if preview := newTripPreview(trip, []domain.JournalEntry{{ID: "private"}}); preview.Journal != nil {
+		t.Errorf("got entries %+v from a trip without shared ones", preview.Journal)
+	}
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	l := NewLocal(t.TempDir(), nil)
+
+	if err := l.Put(ctx, "media/photo.jpg", strings.NewReader("jpeg bytes"), 10, "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	f, err := l.Open(ctx, "media/photo.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(5, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	rest, _ := io.ReadAll(f)
+	f.Close()
+	if string(rest) != "bytes" {
+		t.Errorf("read %q after seeking, want %q", rest, "bytes")
+	}
+
+	if err := l.Delete(ctx, "media/photo.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Open(ctx, "media/photo.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v after deleting, want ErrNotFound", err)
+	}
+	if err := l.Delete(ctx, "media/photo.jpg"); err != nil {
+		t.Errorf("deleting twice: %v", err)
+	}
+	if err := l.Put(ctx, "../outside.jpg", strings.NewReader(""), 0, ""); err == nil {
+		t.Error("wrote outside the directory")
+	}
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestURLSigner(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s := NewURLSigner("secret", "/api/v1/files", 0)
+	if s.Expiry != DefaultURLExpiry {
+		t.Errorf("got expiry %v, want the default", s.Expiry)
+	}
+
+	link, err := s.Sign("media/a b.jpg", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, query, _ := strings.Cut(link, "?")
+	if path != "/api/v1/files/media/a%20b.jpg" {
+		t.Errorf("got path %s", path)
+	}
+	q, _ := url.ParseQuery(query)
+	expires, signature := q.Get("expires"), q.Get("signature")
+	if expires != "1714568400" {
+		t.Errorf("got expires %s, want an hour from now", expires)
+	}
+
+	tests := []struct {
+		name                    string
+		signer                  *URLSigner
+		key, expires, signature string
+		at                      time.Time
+		valid                   bool
+	}{
+		{"valid", s, "media/a b.jpg", expires, signature, now, true},
+		{"on the last second", s, "media/a b.jpg", expires, signature, now.Add(time.Hour), true},
+		{"expired", s, "media/a b.jpg", expires, signature, now.Add(time.Hour + time.Second), false},
+		{"another key", s, "media/other.jpg", expires, signature, now, false},
+		{"extended expiry", s, "media/a b.jpg", "1714572000", signature, now, false},
+		{"malformed expiry", s, "media/a b.jpg", "soon", signature, now, false},
+		{"tampered signature", s, "media/a b.jpg", expires, strings.Repeat("0", len(signature)), now, false},
+		{"no signature", s, "media/a b.jpg", expires, "", now, false},
+		{"another secret", NewURLSigner("other", "/api/v1/files", 0), "media/a b.jpg", expires, signature, now, false},
+		{"escaping the store", s, "../etc/passwd", expires, signature, now, false},
+	}
+	for _, tt := range tests {
+		err := tt.signer.Verify(tt.key, tt.expires, tt.signature, tt.at)
+		if tt.valid && err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: got %v, want ErrInvalidSignature", tt.name, err)
+		}
+	}
+
+	if _, err := s.Sign("/etc/passwd", now); err == nil {
+		t.Error("signed an absolute key")
+	}
+}
@@ -0,0 +1,38 @@
+// Package storage keeps uploaded files in a blob store: a local directory
+// for development, or an S3-compatible bucket (AWS S3, MinIO, R2, ...) so
+// several API instances can share media.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// DefaultURLExpiry is how long signed download URLs stay valid
+const DefaultURLExpiry = time.Hour
+
+// ErrNotFound is returned when no object has the key
+var ErrNotFound = errors.New("object not found")
+
+// BlobStore stores objects by key, e.g. "media/1700000000_photo.jpg"
+type BlobStore interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Open returns the object's content. It can seek, so downloads can serve
+	// byte ranges.
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	Delete(ctx context.Context, key string) error
+	// URL returns where clients can download the object
+	URL(ctx context.Context, key string) (string, error)
+}
+
+// checkKey rejects keys that could escape the store's root
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return errors.New("invalid object key: " + key)
+	}
+	return nil
+}